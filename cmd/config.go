@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/posilva/simpleboards/internal/adapters/output/configprovider"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/spf13/cobra"
+)
+
+var functionNames = map[domain.LeaderboardFunctionType]string{
+	domain.Last:  "last",
+	domain.Max:   "max",
+	domain.Min:   "min",
+	domain.Sum:   "sum",
+	domain.Decay: "decay",
+}
+
+var resetNames = map[domain.LeaderboardResetType]string{
+	domain.Manually: "manual",
+	domain.Hourly:   "hourly",
+	domain.Daily:    "daily",
+	domain.Weekly:   "weekly",
+	domain.Monthly:  "monthly",
+	domain.Custom:   "custom",
+	domain.Window:   "window",
+}
+
+// configCmd groups the commands to manage the leaderboard configurations as code
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the leaderboard configurations from a file",
+	Long: `Manage the stored leaderboard configurations from a YAML or JSON file with a leaderboards
+list of configurations with the fields of the stored JSON. Leaderboards which are stored but not
+in the file are kept, and the lifecycle of stored leaderboards is only changed by transitions.
+New leaderboards are created as draft or active and names are matched regardless of case.
+Webhook secrets are redacted in exports and diffs, a file which omits or redacts a secret keeps
+the stored one`,
+}
+
+// configApplyCmd stores the configurations of a file
+var configApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Validate and store the configurations of a file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		configs, err := readConfigFlag(cmd)
+		if err != nil {
+			return err
+		}
+		service, err := app.NewConfigsService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		changes, err := service.Apply(configs, dryRun)
+		if changes != nil {
+			printChanges(cmd.OutOrStdout(), changes)
+		}
+		if err == nil && dryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), "dry run, nothing was stored")
+		}
+		return err
+	},
+}
+
+// configDiffCmd shows the changes applying a file makes
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the changes applying the configurations of a file makes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configs, err := readConfigFlag(cmd)
+		if err != nil {
+			return err
+		}
+		service, err := app.NewConfigsService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		changes, err := service.Plan(configs)
+		if err != nil {
+			return err
+		}
+		printChanges(cmd.OutOrStdout(), changes)
+		return nil
+	},
+}
+
+// configListCmd lists the stored configurations
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stored leaderboards",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		service, err := app.NewConfigsService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		configs, err := service.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFUNCTION\tRESET\tSCOREBOARDS\tSTATE")
+		now := time.Now()
+		for _, config := range configs {
+			fields := make([]string, 0, len(config.Scoreboards))
+			for _, sb := range config.Scoreboards {
+				fields = append(fields, sb.Field)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", config.Name, functionNames[config.Function],
+				resetNames[config.ResetExpression.Type], strings.Join(fields, ","), config.Lifecycle.StateAt(now))
+		}
+		return w.Flush()
+	},
+}
+
+// configExportCmd writes the stored configurations as a file
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the stored configurations as a YAML file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		service, err := app.NewConfigsService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		configs, err := service.List()
+		if err != nil {
+			return err
+		}
+		data, err := configprovider.MarshalConfigFile(configs)
+		if err != nil {
+			return err
+		}
+		if output == "" || output == "-" {
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		}
+		return os.WriteFile(output, data, 0o644)
+	},
+}
+
+func readConfigFlag(cmd *cobra.Command) ([]domain.LeaderboardConfig, error) {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		return nil, fmt.Errorf("the configuration file is required")
+	}
+	return configprovider.ReadConfigFile(path)
+}
+
+// printChanges writes the changes as a readable diff
+func printChanges(w io.Writer, changes []domain.ConfigChange) {
+	counts := map[domain.ConfigAction]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+		switch change.Action {
+		case domain.ConfigCreate:
+			fmt.Fprintf(w, "+ %s will be created\n", change.Name)
+		case domain.ConfigUpdate:
+			fmt.Fprintf(w, "~ %s will be updated\n", change.Name)
+		case domain.ConfigUnchanged:
+			fmt.Fprintf(w, "  %s is unchanged\n", change.Name)
+		case domain.ConfigUnmanaged:
+			fmt.Fprintf(w, "! %s is stored but not in the file, it is kept\n", change.Name)
+		}
+		for _, field := range change.Fields {
+			switch {
+			case field.From == "":
+				fmt.Fprintf(w, "    + %s: %s\n", field.Path, field.To)
+			case field.To == "":
+				fmt.Fprintf(w, "    - %s: %s\n", field.Path, field.From)
+			default:
+				fmt.Fprintf(w, "    ~ %s: %s -> %s\n", field.Path, field.From, field.To)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d to create, %d to update, %d unchanged, %d unmanaged\n",
+		counts[domain.ConfigCreate], counts[domain.ConfigUpdate], counts[domain.ConfigUnchanged], counts[domain.ConfigUnmanaged])
+}
+
+func init() {
+	for _, c := range []*cobra.Command{configApplyCmd, configDiffCmd} {
+		c.Flags().StringP("file", "f", "", "YAML or JSON file with the leaderboard configurations")
+	}
+	configApplyCmd.Flags().Bool("dry-run", false, "Show the changes without storing them")
+	configExportCmd.Flags().StringP("output", "o", "", "Output file, stdout when empty")
+	configCmd.AddCommand(configApplyCmd, configDiffCmd, configListCmd, configExportCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/spf13/cobra"
+)
+
+// dbCmd groups the commands to manage the dynamodb table
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the dynamodb table",
+	Long: `Manage the layout of the dynamodb table, its keys, indexes and time to live. The version of
+the layout is recorded in the table so the commands can run again and only apply what is missing`,
+}
+
+// dbInitCmd creates the table and applies the migrations
+var dbInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the table when it does not exist and apply the pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applied, err := app.NewSchemaManager().Init()
+		printMigrations(cmd.OutOrStdout(), applied, err)
+		return err
+	},
+}
+
+// dbMigrateCmd applies the migrations of an existing table
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply the pending migrations of an existing table",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applied, err := app.NewSchemaManager().Migrate()
+		printMigrations(cmd.OutOrStdout(), applied, err)
+		return err
+	},
+}
+
+// dbStatusCmd shows the schema version of the table
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version of the table and the pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := app.NewSchemaManager().Status()
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		if !status.Exists {
+			fmt.Fprintf(w, "table %s does not exist\n", status.Table)
+			return nil
+		}
+		fmt.Fprintf(w, "table %s schema version %d of %d\n", status.Table, status.Version, status.Latest)
+		if len(status.Pending) > 0 {
+			fmt.Fprintf(w, "pending migrations:\n  %s\n", strings.Join(status.Pending, "\n  "))
+		}
+		return nil
+	},
+}
+
+// printMigrations writes the applied migrations, a failed migration is reported by the error
+func printMigrations(w io.Writer, applied []string, err error) {
+	for _, migration := range applied {
+		fmt.Fprintf(w, "applied: %s\n", migration)
+	}
+	if len(applied) == 0 && err == nil {
+		fmt.Fprintln(w, "schema is up to date")
+	}
+}
+
+func init() {
+	dbCmd.AddCommand(dbInitCmd, dbMigrateCmd, dbStatusCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/spf13/cobra"
+)
+
+// epochCmd groups the commands to manage leaderboard epochs
+var epochCmd = &cobra.Command{
+	Use:   "epoch",
+	Short: "Manage leaderboard epochs",
+}
+
+// epochAdvanceCmd starts the next epoch of a manually reset leaderboard
+var epochAdvanceCmd = &cobra.Command{
+	Use:   "advance <leaderboard>",
+	Short: "Start the next epoch of a manually reset leaderboard",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		info, err := service.AdvanceEpoch(args[0])
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	},
+}
+
+func init() {
+	epochCmd.AddCommand(epochAdvanceCmd)
+	rootCmd.AddCommand(epochCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/posilva/simpleboards/internal/adapters/output/export"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd writes the standings of a leaderboard epoch
+var exportCmd = &cobra.Command{
+	Use:   "export <leaderboard>",
+	Short: "Export the standings of a leaderboard epoch as CSV or NDJSON",
+	Long: `Export the standings of the global scoreboard and of the selected scoreboards of a
+leaderboard epoch, scoreboards are selected with field:value or field:* for all values.
+
+The standings are read in pages from the live scoreboards, so an export of an epoch that still
+takes scores can repeat or miss entries whose rank changes while it runs. Export a closed epoch
+for consistent standings.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		epoch, _ := cmd.Flags().GetInt64("epoch")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		selected, _ := cmd.Flags().GetStringArray("scoreboard")
+
+		scoreboards := domain.Metadata{}
+		for _, v := range selected {
+			field, value, ok := strings.Cut(v, ":")
+			if !ok || field == "" || value == "" {
+				return fmt.Errorf("scoreboard must be field:value: %s", v)
+			}
+			scoreboards[field] = value
+		}
+
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		var w io.Writer = cmd.OutOrStdout()
+		var f *os.File
+		if output != "" && output != "-" {
+			f, err = os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create output: %v", err)
+			}
+			w = f
+		}
+		info, err := exportStandings(service, args[0], epoch, scoreboards, format, w)
+		if f != nil {
+			// a failed close can leave a truncated file, so it fails the export
+			cerr := f.Close()
+			if err == nil && cerr != nil {
+				err = fmt.Errorf("failed to close output: %v", cerr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "exported epoch %d of %s\n", info.Epoch, args[0])
+		return nil
+	},
+}
+
+// exportStandings writes the standings of a leaderboard epoch in a format
+func exportStandings(service ports.LeaderboardsService, name string, epoch int64, scoreboards domain.Metadata, format string, w io.Writer) (domain.EpochInfo, error) {
+	out, err := export.NewStandingsWriter(format, w)
+	if err != nil {
+		return domain.EpochInfo{}, err
+	}
+	return service.ExportStandings(name, epoch, scoreboards, out)
+}
+
+func init() {
+	exportCmd.Flags().Int64("epoch", 0, "Epoch to export, the current epoch when not positive")
+	exportCmd.Flags().String("format", domain.ExportCSV, "Output format, csv or ndjson")
+	exportCmd.Flags().StringArray("scoreboard", nil, "Scoreboard to export as field:value or field:*, repeatable")
+	exportCmd.Flags().StringP("output", "o", "", "Output file, stdout when empty")
+	rootCmd.AddCommand(exportCmd)
+}
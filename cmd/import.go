@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/posilva/simpleboards/internal/adapters/input/importer"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/spf13/cobra"
+)
+
+// importCmd loads historical scores into a leaderboard epoch
+var importCmd = &cobra.Command{
+	Use:   "import <leaderboard> <file>",
+	Short: "Import scores from CSV or NDJSON into a leaderboard epoch",
+	Long: `Import scores into a leaderboard epoch and its scoreboards. CSV files need a header with the
+entry_id and score columns, a metadata column holds a JSON object and every other column is a
+metadata field. NDJSON lines are objects with the entry_id, score and metadata fields.
+
+In apply mode the scores are applied with the function of the leaderboard, in set mode they
+replace the stored scores. The progress is kept in the checkpoint file so an import that stopped
+is resumed by running it again, and the report of the invalid and failed records is printed at
+the end`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		epoch, _ := cmd.Flags().GetInt64("epoch")
+		format, _ := cmd.Flags().GetString("format")
+		mode, _ := cmd.Flags().GetString("mode")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		checkpointPath, _ := cmd.Flags().GetString("checkpoint")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		reportPath, _ := cmd.Flags().GetString("report")
+
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(args[1]), ".")
+		}
+		f, err := os.Open(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to open input: %v", err)
+		}
+		defer f.Close()
+		in, err := importer.NewRecordReader(format, f)
+		if err != nil {
+			return err
+		}
+		var checkpoint ports.ImportCheckpoint
+		if checkpointPath != "" {
+			checkpoint = importer.NewFileCheckpoint(checkpointPath)
+		}
+
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		report, err := service.ImportScores(args[0], in, domain.ImportOptions{
+			Epoch:       epoch,
+			Mode:        domain.ImportMode(mode),
+			Concurrency: concurrency,
+			ChunkSize:   chunkSize,
+			DryRun:      dryRun,
+		}, checkpoint)
+		if report.Leaderboard != "" {
+			werr := writeReport(cmd.OutOrStdout(), reportPath, report)
+			if werr != nil && err == nil {
+				err = werr
+			}
+		}
+		return err
+	},
+}
+
+// writeReport writes the import report to a file or to the output when the path is empty
+func writeReport(out io.Writer, path string, report domain.ImportReport) error {
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func init() {
+	importCmd.Flags().Int64("epoch", 0, "Epoch to import into, the current epoch when not positive")
+	importCmd.Flags().String("format", "", "Input format, csv or ndjson, from the file extension when empty")
+	importCmd.Flags().String("mode", string(domain.ImportApply), "Import mode, apply or set")
+	importCmd.Flags().Int("concurrency", 8, "Number of workers writing the records")
+	importCmd.Flags().Int("chunk-size", 1000, "Number of records written between checkpoints")
+	importCmd.Flags().String("checkpoint", "", "Checkpoint file to resume the import from")
+	importCmd.Flags().Bool("dry-run", false, "Validate the records without writing them")
+	importCmd.Flags().String("report", "", "Report file, stdout when empty")
+	rootCmd.AddCommand(importCmd)
+}
@@ -19,8 +19,8 @@ var rootCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		app.Run()
 	},
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return viper.BindPFlag("local", cmd.Flags().Lookup("local"))
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return viper.BindPFlag("local", cmd.Root().PersistentFlags().Lookup("local"))
 	},
 }
 
@@ -42,5 +42,5 @@ func init() {
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	rootCmd.Flags().BoolP("local", "l", false, "Run the service locally against using docker compose")
+	rootCmd.PersistentFlags().BoolP("local", "l", false, "Run the service locally against using docker compose")
 }
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/posilva/simpleboards/cmd/simpleboards/app"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/spf13/cobra"
+)
+
+// scoresCmd groups the commands to inspect and fix the standings
+var scoresCmd = &cobra.Command{
+	Use:   "scores",
+	Short: "Inspect and fix the standings of leaderboards",
+}
+
+// scoresTopCmd shows the top of a scoreboard
+var scoresTopCmd = &cobra.Command{
+	Use:   "top <leaderboard>",
+	Short: "Show the top entries of the global scoreboard or of a scoreboard",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		epoch, _ := cmd.Flags().GetInt64("epoch")
+		limit, _ := cmd.Flags().GetInt("limit")
+		selected, _ := cmd.Flags().GetString("scoreboard")
+		meta, err := parsePairs([]string{selected}, ":")
+		if err != nil || len(meta) > 1 {
+			return fmt.Errorf("scoreboard must be field:value: %s", selected)
+		}
+
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		index, err := scoreboardIndexes(service, args[0], meta)
+		if err != nil {
+			return err
+		}
+		var results []domain.LeaderboardScores
+		var info domain.EpochInfo
+		if epoch <= 0 {
+			results, info, err = service.ListScoresWithMetadata(args[0], meta)
+		} else {
+			results, info, err = service.GetResultsWithMetadata(args[0], epoch, meta)
+		}
+		if err != nil {
+			return err
+		}
+		scores := domain.LeaderboardScores{}
+		if i := index[len(index)-1]; i < len(results) {
+			scores = results[i]
+		}
+		if limit > 0 && len(scores.Scores) > limit {
+			scores.Scores = scores.Scores[:limit]
+		}
+		return printOutput(cmd, map[string]interface{}{"epoch": info.Epoch, "scoreboard": scores}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s (epoch %d)\n", scores.Name, info.Epoch)
+			fmt.Fprintln(w, "RANK\tENTRY\tSCORE")
+			for _, entry := range scores.Scores {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", entry.Rank, entry.EntryID, scoreText(entry.Score, entry.ExactScore))
+			}
+		})
+	},
+}
+
+// scoresRankCmd shows the standings of an entry
+var scoresRankCmd = &cobra.Command{
+	Use:   "rank <leaderboard> <entry>",
+	Short: "Show the standings of an entry in the current epoch",
+	Long: `Show the standings of an entry in the global scoreboard of the current epoch and in the
+scoreboards selected with field:value`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected, _ := cmd.Flags().GetStringArray("scoreboard")
+		meta, err := parsePairs(selected, ":")
+		if err != nil {
+			return err
+		}
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		index, err := scoreboardIndexes(service, args[0], meta)
+		if err != nil {
+			return err
+		}
+		all, info, err := service.GetStandingsWithMetadata(args[1], args[0], meta, true)
+		if err != nil {
+			return err
+		}
+		standings := []domain.LeaderboardStanding{}
+		for _, i := range index {
+			if i < len(all) {
+				standings = append(standings, all[i])
+			}
+		}
+		return printOutput(cmd, map[string]interface{}{"epoch": info.Epoch, "standings": standings}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s (epoch %d)\n", args[1], info.Epoch)
+			fmt.Fprintln(w, "SCOREBOARD\tRANK\tSCORE\tTOTAL\tTOP %")
+			for _, st := range standings {
+				rank := "-"
+				if st.Rank > 0 {
+					rank = fmt.Sprint(st.Rank)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%.2f\n", st.Name, rank, scoreText(st.Score, st.ExactScore), st.Total, st.TopPercent)
+			}
+		})
+	},
+}
+
+// scoresSetCmd replaces the score of an entry
+var scoresSetCmd = &cobra.Command{
+	Use:   "set <leaderboard> <entry> <score>",
+	Short: "Replace the score of an entry in an epoch and its scoreboards",
+	Long: `Replace the score of an entry in an epoch and in the scoreboards of its metadata without
+applying the function of the leaderboard. The given metadata is merged into the stored one`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		epoch, _ := cmd.Flags().GetInt64("epoch")
+		pairs, _ := cmd.Flags().GetStringArray("meta")
+		meta, err := parsePairs(pairs, "=")
+		if err != nil {
+			return err
+		}
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		update, info, err := service.SetScore(args[1], args[0], epoch, args[2], meta)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, map[string]interface{}{"epoch": info.Epoch, "update": update}, func(w io.Writer) {
+			fmt.Fprintf(w, "%s now has %s in %s epoch %d\n", args[1], scoreText(update.Score, update.ExactScore), args[0], info.Epoch)
+		})
+	},
+}
+
+// scoresRemoveCmd removes an entry from an epoch
+var scoresRemoveCmd = &cobra.Command{
+	Use:   "remove <leaderboard> <entry>",
+	Short: "Remove an entry from an epoch and its scoreboards",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		epoch, _ := cmd.Flags().GetInt64("epoch")
+		service, err := app.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to create service instance: %v", err)
+		}
+		removed, info, err := service.RemoveScore(args[1], args[0], epoch)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, map[string]interface{}{"epoch": info.Epoch, "removed_from": removed}, func(w io.Writer) {
+			if len(removed) == 0 {
+				fmt.Fprintf(w, "%s had no score in %s epoch %d\n", args[1], args[0], info.Epoch)
+				return
+			}
+			fmt.Fprintf(w, "%s was removed from %s\n", args[1], strings.Join(removed, ", "))
+		})
+	},
+}
+
+// printOutput writes v as JSON or writes the table
+func printOutput(cmd *cobra.Command, v interface{}, table func(w io.Writer)) error {
+	output, _ := cmd.Flags().GetString("output")
+	switch output {
+	case "json":
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table":
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+	return fmt.Errorf("unsupported output: %s", output)
+}
+
+// scoreboardIndexes returns the sorted positions of the global scoreboard and of the scoreboards
+// of the metadata fields in the results of the service, which follow the configuration
+func scoreboardIndexes(service ports.LeaderboardsService, name string, meta domain.Metadata) ([]int, error) {
+	config, err := service.GetConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	index := []int{0}
+	for field := range meta {
+		found := false
+		for i, sb := range config.Scoreboards {
+			if sb.Field == field {
+				index = append(index, i+1)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s does not have a %s scoreboard", name, field)
+		}
+	}
+	sort.Ints(index)
+	return index, nil
+}
+
+// parsePairs parses key and value pairs, empty pairs are skipped
+func parsePairs(pairs []string, sep string) (domain.Metadata, error) {
+	meta := domain.Metadata{}
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, sep)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("expected key%svalue: %s", sep, pair)
+		}
+		meta[k] = v
+	}
+	return meta, nil
+}
+
+func scoreText(score float64, exact string) string {
+	if exact != "" {
+		return exact
+	}
+	return fmt.Sprint(score)
+}
+
+func init() {
+	scoresCmd.PersistentFlags().String("output", "table", "Output format, table or json")
+	scoresTopCmd.Flags().Int64("epoch", 0, "Epoch to show, the current epoch when not positive")
+	scoresTopCmd.Flags().String("scoreboard", "", "Scoreboard to show as field:value, the global scoreboard when empty")
+	scoresTopCmd.Flags().Int("limit", 10, "Number of entries to show")
+	scoresRankCmd.Flags().StringArray("scoreboard", nil, "Scoreboard to show as field:value, repeatable")
+	scoresSetCmd.Flags().Int64("epoch", 0, "Epoch to change, the current epoch when not positive")
+	scoresSetCmd.Flags().StringArray("meta", nil, "Metadata of the entry as key=value, repeatable")
+	scoresRemoveCmd.Flags().Int64("epoch", 0, "Epoch to change, the current epoch when not positive")
+	scoresCmd.AddCommand(scoresTopCmd, scoresRankCmd, scoresSetCmd, scoresRemoveCmd)
+	rootCmd.AddCommand(scoresCmd)
+}
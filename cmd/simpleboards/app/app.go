@@ -1,66 +1,253 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/gin-gonic/gin"
 	"github.com/posilva/simpleboards/cmd/simpleboards/config"
 	"github.com/posilva/simpleboards/internal/adapters/input/handler"
+	"github.com/posilva/simpleboards/internal/adapters/output/archive"
 	"github.com/posilva/simpleboards/internal/adapters/output/configprovider"
+	"github.com/posilva/simpleboards/internal/adapters/output/events"
+	"github.com/posilva/simpleboards/internal/adapters/output/export"
 	"github.com/posilva/simpleboards/internal/adapters/output/logging"
 	"github.com/posilva/simpleboards/internal/adapters/output/repository"
 	"github.com/posilva/simpleboards/internal/adapters/output/scoreboard"
+	"github.com/posilva/simpleboards/internal/adapters/output/webhook"
+	"github.com/posilva/simpleboards/internal/core/ports"
 	"github.com/posilva/simpleboards/internal/core/services"
 )
 
+// shutdownTimeout is the time the server waits for the requests and the queued events on
+// shutdown
+const shutdownTimeout = 10 * time.Second
+
+// appServices are the services of the server, events is nil when events are not published
+type appServices struct {
+	leaderboards *services.LeaderboardsService
+	prizes       *services.PrizesService
+	archiver     *services.ArchiverService
+	events       *events.AsyncPublisher
+}
+
 func Run() {
 	r := gin.Default()
 
-	service, err := createService()
+	prizeInterval, err := config.GetPrizeInterval()
+	if err != nil {
+		panic(fmt.Errorf("invalid configuration: %v", err))
+	}
+	archiveInterval, err := config.GetArchiveInterval()
+	if err != nil {
+		panic(fmt.Errorf("invalid configuration: %v", err))
+	}
+	svc, err := createServices()
 	if err != nil {
 		panic(fmt.Errorf("failed to create service instance: %v", err))
 	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go svc.prizes.Run(ctx, prizeInterval)
+	if svc.archiver != nil {
+		go svc.archiver.Run(ctx, archiveInterval)
+	}
 
-	httpHandler := handler.NewHTTPHandler(service)
+	httpHandler := handler.NewHTTPHandler(svc.leaderboards, export.NewFormats())
+	prizesHandler := handler.NewPrizesHTTPHandler(svc.prizes)
 	r.GET("/", httpHandler.Handle)
 	api := r.Group("api/v1")
 
 	api.PUT("/score/:leaderboard", httpHandler.HandlePutScore)
 	api.GET("/scores/:leaderboard", httpHandler.HandleGetScores)
+	api.GET("/rank/:leaderboard/:entry", httpHandler.HandleGetStanding)
+	api.GET("/stats/:leaderboard", httpHandler.HandleGetStats)
+	api.GET("/leaderboards/:leaderboard/epoch", httpHandler.HandleGetEpoch)
+	api.PUT("/friends/:entry", httpHandler.HandlePutFriends)
+	api.GET("/friends/:leaderboard/:entry", httpHandler.HandleGetFriendsScores)
+	api.POST("/friends/:leaderboard/:entry", httpHandler.HandlePostFriendsScores)
+	api.GET("/prizes/:entry", prizesHandler.HandleGetPrizes)
+	api.POST("/prizes/:entry/:leaderboard/:epoch/claim", prizesHandler.HandleClaimPrize)
 
-	err = r.Run(config.GetAddr())
-	if err != nil {
+	admin := api.Group("/admin")
+	admin.POST("/leaderboards/:leaderboard/migrate-epochs", httpHandler.HandleMigrateEpochs)
+	admin.POST("/leaderboards/:leaderboard/advance-epoch", httpHandler.HandleAdvanceEpoch)
+	admin.POST("/leaderboards/:leaderboard/lifecycle", httpHandler.HandleTransitionLifecycle)
+	admin.GET("/leaderboards/:leaderboard/lifecycle/audit", httpHandler.HandleGetLifecycleAudit)
+	admin.GET("/leaderboards/:leaderboard/export", httpHandler.HandleExport)
+	admin.GET("/config/status", httpHandler.HandleGetConfigStatus)
+	admin.GET("/prizes/dead-letters", prizesHandler.HandleGetDeadLetters)
+	admin.POST("/prizes/:leaderboard/:epoch/redeliver", prizesHandler.HandleRedeliver)
+
+	server := &http.Server{Addr: config.GetAddr(), Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		panic(fmt.Errorf("failed to start the server %v", err))
 	}
+	// the queued events are published before the server stops, the others stay in the outbox
+	if svc.events != nil {
+		err = svc.events.Close(shutdownTimeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close the event publisher: %v\n", err)
+		}
+	}
+}
 
+// NewService creates the leaderboards service with the same configuration as the server
+func NewService() (ports.LeaderboardsService, error) {
+	svc, err := createServices()
+	if err != nil {
+		return nil, err
+	}
+	return svc.leaderboards, nil
 }
 
-func createService() (*services.LeaderboardsService, error) {
-	var cfg aws.Config
+// NewConfigsService creates the service managing the stored leaderboard configurations
+func NewConfigsService() (*services.ConfigsService, error) {
+	repo, _, err := createRepository()
+	if err != nil {
+		return nil, err
+	}
+	return services.NewConfigsService(repo), nil
+}
+
+// NewSchemaManager creates the manager of the dynamodb table layout
+func NewSchemaManager() *repository.SchemaManager {
+	return repository.NewSchemaManager(dynamodb.NewFromConfig(awsConfig()), config.GetDynamoDBTableName())
+}
+
+// awsConfig returns the aws configuration, the local mode notice goes to stderr so the commands
+// can write their output to stdout
+func awsConfig() aws.Config {
 	if config.IsLocal() {
-		fmt.Println("Running in local mode")
-		cfg = repository.DefaultLocalAWSClientConfig()
-	} else {
-		cfg = *aws.NewConfig()
+		fmt.Fprintln(os.Stderr, "Running in local mode")
+		return repository.DefaultLocalAWSClientConfig()
 	}
-	
+	return *aws.NewConfig()
+}
+
+// createRepository creates the dynamodb repository
+func createRepository() (*repository.DynamoDBRepository, repository.DynamoDBSettings, error) {
 	settings := repository.DynamoDBSettings{
-		Client: dynamodb.NewFromConfig(cfg),
+		Client: dynamodb.NewFromConfig(awsConfig()),
 		Logger: logging.NewSimpleLogger(),
 		Table:  config.GetDynamoDBTableName(),
 	}
 
 	repo, err := repository.NewDynamoDBRepository(settings)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create dynamodb repository: %v", err)
+		return nil, settings, fmt.Errorf("failed to create dynamodb repository: %v", err)
+	}
+	return repo, settings, nil
+}
+
+// createServices creates the leaderboards, prizes and archiver services, the archiver is nil
+// when epochs are not archived
+func createServices() (appServices, error) {
+	repo, settings, err := createRepository()
+	if err != nil {
+		return appServices{}, err
 	}
 
-	configProvider := configprovider.NewDynamoConfigProvider(repo, settings.Logger)
+	configProvider, err := createConfigProvider(repo, settings.Logger)
+	if err != nil {
+		return appServices{}, fmt.Errorf("failed to create config provider: %v", err)
+	}
 
 	scoreboard, err := scoreboard.NewRedisScoreboard(config.GetRedisAddr())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create redis scoreboard: %v", err)
+		return appServices{}, fmt.Errorf("failed to create redis scoreboard: %v", err)
+	}
+	publisher, err := createEventPublisher(repo, settings.Logger)
+	if err != nil {
+		return appServices{}, fmt.Errorf("failed to create event publisher: %v", err)
+	}
+	store, err := createArchiveStore(repo)
+	if err != nil {
+		return appServices{}, fmt.Errorf("failed to create archive store: %v", err)
+	}
+	// a nil publisher is not passed as a non nil interface
+	var eventPublisher ports.EventPublisher
+	if publisher != nil {
+		eventPublisher = publisher
+	}
+	svc := appServices{events: publisher}
+	svc.prizes = services.NewPrizesService(repo, scoreboard, configProvider, webhook.NewHTTPNotifier(nil), config.GetPrizeWebhook(), eventPublisher, settings.Logger)
+	if store != nil {
+		svc.archiver = services.NewArchiverService(repo, scoreboard, configProvider, store, settings.Logger)
+	}
+	svc.leaderboards = services.NewLeaderboardsServiceWithOptions(repo, scoreboard, configProvider, services.LeaderboardsOptions{
+		Events:  eventPublisher,
+		Limiter: scoreboard,
+		Archive: store,
+		Logger:  settings.Logger,
+	})
+	return svc, nil
+}
+
+// createConfigProvider returns the configured provider of the leaderboard configurations
+func createConfigProvider(repo *repository.DynamoDBRepository, logger ports.Logger) (ports.ConfigProvider, error) {
+	switch kind := config.GetConfigProvider(); kind {
+	case "", "dynamodb":
+		return configprovider.NewDynamoConfigProvider(repo, logger), nil
+	case "file":
+		return configprovider.NewFileConfigProvider(config.GetConfigDir(), logger)
+	default:
+		return nil, fmt.Errorf("unknown config provider: %v", kind)
+	}
+}
+
+// createArchiveStore returns the configured store of the epoch archives, nil when epochs are not
+// archived
+func createArchiveStore(repo *repository.DynamoDBRepository) (ports.ArchiveStore, error) {
+	switch kind := config.GetArchiveStore(); kind {
+	case "none":
+		return nil, nil
+	case "", "dynamodb":
+		return repo, nil
+	case "file":
+		return archive.NewFileArchiveStore(config.GetArchiveDir()), nil
+	default:
+		return nil, fmt.Errorf("unknown archive store: %v", kind)
+	}
+}
+
+// createEventPublisher returns the configured event publisher, nil when events are not published.
+// The events are stored in the outbox of the repository and published off the request path by an
+// async publisher
+func createEventPublisher(repo *repository.DynamoDBRepository, logger ports.Logger) (*events.AsyncPublisher, error) {
+	var publisher ports.EventPublisher
+	var err error
+	switch kind := config.GetEventsPublisher(); kind {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		publisher = events.NewStdoutPublisher()
+	case "memory":
+		publisher = events.NewMemoryPublisher()
+	case "redis":
+		publisher, err = events.NewRedisStreamPublisher(config.GetRedisAddr(), config.GetEventsStream())
+	case "nats":
+		publisher, err = events.NewNATSPublisher(config.GetNATSURL(), config.GetEventsStream(), config.GetEventsSubject())
+	default:
+		return nil, fmt.Errorf("unknown event publisher: %v", kind)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return services.NewLeaderboardsService(repo, scoreboard, configProvider), nil
+	return events.NewAsyncPublisher(publisher, repo, events.DefaultQueueSize, logger), nil
 }
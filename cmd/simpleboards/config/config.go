@@ -1,6 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/spf13/viper"
 )
 
@@ -8,6 +12,22 @@ const (
 	httpAddr     = "ADDR"
 	ddbTablename = "DYNAMODB_TABLE_NAME"
 	redisAddr    = "REDIS_ADDR"
+	// global webhook of the prizes of the leaderboards without one
+	prizeWebhookURL    = "PRIZE_WEBHOOK_URL"
+	prizeWebhookSecret = "PRIZE_WEBHOOK_SECRET"
+	prizeInterval      = "PRIZE_INTERVAL"
+	// publisher of the score and epoch events: none, stdout, memory, redis or nats
+	eventsPublisher = "EVENTS_PUBLISHER"
+	eventsStream    = "EVENTS_STREAM"
+	eventsSubject   = "EVENTS_SUBJECT"
+	natsURL         = "NATS_URL"
+	// store of the final standings of closed epochs: dynamodb, file or none
+	archiveStore    = "ARCHIVE_STORE"
+	archiveDir      = "ARCHIVE_DIR"
+	archiveInterval = "ARCHIVE_INTERVAL"
+	// provider of the leaderboard configurations: dynamodb or file
+	configProvider = "CONFIG_PROVIDER"
+	configDir      = "CONFIG_DIR"
 )
 
 func init() {
@@ -25,6 +45,16 @@ func init() {
 	viper.SetDefault(httpAddr, ":8808")
 	viper.SetDefault(redisAddr, "localhost:6379")
 	viper.SetDefault(ddbTablename, "sgs-gbl-dev-leaderboards")
+	viper.SetDefault(prizeInterval, time.Minute)
+	viper.SetDefault(eventsPublisher, "none")
+	viper.SetDefault(eventsStream, "simpleboards-events")
+	viper.SetDefault(eventsSubject, "simpleboards")
+	viper.SetDefault(natsURL, "nats://localhost:4222")
+	viper.SetDefault(archiveStore, "dynamodb")
+	viper.SetDefault(archiveDir, "archives")
+	viper.SetDefault(archiveInterval, time.Minute)
+	viper.SetDefault(configProvider, "dynamodb")
+	viper.SetDefault(configDir, "configs")
 }
 
 // GetAddr returns the http server addresss
@@ -41,6 +71,76 @@ func GetRedisAddr() string {
 	return viper.GetString(redisAddr)
 }
 
+// GetPrizeWebhook returns the global webhook of the prizes, nil when no url is set
+func GetPrizeWebhook() *domain.WebhookConfig {
+	url := viper.GetString(prizeWebhookURL)
+	if url == "" {
+		return nil
+	}
+	return &domain.WebhookConfig{URL: url, Secret: viper.GetString(prizeWebhookSecret)}
+}
+
+// GetPrizeInterval returns the interval prizes are awarded and delivered at, it fails when the
+// interval is not positive
+func GetPrizeInterval() (time.Duration, error) {
+	return getInterval(prizeInterval)
+}
+
+// GetEventsPublisher returns the publisher of the score and epoch events
+func GetEventsPublisher() string {
+	return viper.GetString(eventsPublisher)
+}
+
+// GetEventsStream returns the redis or nats stream the events are published to
+func GetEventsStream() string {
+	return viper.GetString(eventsStream)
+}
+
+// GetEventsSubject returns the nats subject prefix of the events
+func GetEventsSubject() string {
+	return viper.GetString(eventsSubject)
+}
+
+// GetNATSURL returns the url of the nats server
+func GetNATSURL() string {
+	return viper.GetString(natsURL)
+}
+
+// GetArchiveStore returns the store of the final standings of closed epochs
+func GetArchiveStore() string {
+	return viper.GetString(archiveStore)
+}
+
+// GetArchiveDir returns the directory of the file archive store
+func GetArchiveDir() string {
+	return viper.GetString(archiveDir)
+}
+
+// GetArchiveInterval returns the interval closed epochs are archived at, it fails when the
+// interval is not positive
+func GetArchiveInterval() (time.Duration, error) {
+	return getInterval(archiveInterval)
+}
+
+// getInterval returns the duration of a key, which must be positive to run a ticker
+func getInterval(key string) (time.Duration, error) {
+	interval := viper.GetDuration(key)
+	if interval <= 0 {
+		return 0, fmt.Errorf("%v must be a positive duration: %v", key, viper.GetString(key))
+	}
+	return interval, nil
+}
+
+// GetConfigProvider returns the provider of the leaderboard configurations
+func GetConfigProvider() string {
+	return viper.GetString(configProvider)
+}
+
+// GetConfigDir returns the directory of the file configuration provider
+func GetConfigDir() string {
+	return viper.GetString(configDir)
+}
+
 func IsLocal() bool {
 	return viper.GetBool("local")
 }
@@ -1,22 +1,29 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/core/ports"
 )
 
 // HTTPHandler is the HTTP Handler
 type HTTPHandler struct {
 	service ports.LeaderboardsService
+	formats ports.StandingsFormats
 }
 
 // NewHTTPHandler creates a new HTTP Handler
-func NewHTTPHandler(srv ports.LeaderboardsService) *HTTPHandler {
+func NewHTTPHandler(srv ports.LeaderboardsService, formats ports.StandingsFormats) *HTTPHandler {
 	return &HTTPHandler{
 		service: srv,
+		formats: formats,
 	}
 }
 
@@ -34,22 +41,331 @@ func (h *HTTPHandler) HandlePutScore(ctx *gin.Context) {
 		_ = ctx.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
-	value, err := h.service.ReportScoreWithMetadata(b.Entry, name, float64(b.Score), b.Metadata)
+	var value domain.ReportScoreOutput
+	if len(b.Components) > 0 {
+		value, err = h.service.ReportComponentsWithMetadata(b.Entry, name, b.Components, b.Metadata)
+	} else {
+		score := b.Score.String()
+		if score == "" {
+			score = "0"
+		}
+		value, err = h.service.ReportDecimalScoreWithMetadata(b.Entry, name, score, b.Metadata)
+	}
 	if err != nil {
-		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+		abortWithServiceError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"new_score": value.Update.Score,
-		"epoch":     value.Epoch,
-		"done":      value.Update.Done,
-		"count":     value.Update.Counter,
-	})
+	res := gin.H{
+		"new_score":  value.Update.Score,
+		"done":       value.Update.Done,
+		"count":      value.Update.Counter,
+		"components": value.Update.Components,
+	}
+	if value.Update.ExactScore != "" {
+		res["exact_score"] = value.Update.ExactScore
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(res, value.Epoch))
 }
 
 // HandleGetScores handles the GET /scores/:leaderboard endpoint
 func (h *HTTPHandler) HandleGetScores(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	value, epoch, err := h.service.ListScoresWithMetadata(name, metadataFromQuery(ctx))
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(gin.H{"scores": value}, epoch))
+}
+
+// HandleGetStanding handles the GET /rank/:leaderboard/:entry endpoint
+func (h *HTTPHandler) HandleGetStanding(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	entry := ctx.Param("entry")
+	nextBand, err := strconv.ParseBool(ctx.DefaultQuery("next_band", "false"))
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, epoch, err := h.service.GetStandingsWithMetadata(entry, name, metadataFromQuery(ctx), nextBand)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(gin.H{"standings": value}, epoch))
+}
+
+// HandleGetConfigStatus handles the GET /admin/config/status endpoint
+func (h *HTTPHandler) HandleGetConfigStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.service.GetConfigStatus())
+}
+
+// HandleGetEpoch handles the GET /leaderboards/:leaderboard/epoch endpoint
+func (h *HTTPHandler) HandleGetEpoch(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	epoch, err := strconv.ParseInt(ctx.DefaultQuery("epoch", "0"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, err := h.service.GetEpochInfo(name, epoch)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(gin.H{}, value))
+}
+
+// HandleGetStats handles the GET /stats/:leaderboard endpoint
+func (h *HTTPHandler) HandleGetStats(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	epoch, err := strconv.ParseInt(ctx.DefaultQuery("epoch", "0"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	var buckets []float64
+	if v := ctx.Query("buckets"); v != "" {
+		for _, b := range strings.Split(v, ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+			if err != nil {
+				_ = ctx.AbortWithError(http.StatusBadRequest, err)
+				return
+			}
+			buckets = append(buckets, f)
+		}
+	}
+	value, err := h.service.GetStats(name, epoch, buckets)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, value)
+}
+
+// HandleAdvanceEpoch handles the POST /admin/leaderboards/:leaderboard/advance-epoch endpoint
+func (h *HTTPHandler) HandleAdvanceEpoch(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	value, err := h.service.AdvanceEpoch(name)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(gin.H{}, value))
+}
+
+// HandleTransitionLifecycle handles the POST /admin/leaderboards/:leaderboard/lifecycle endpoint
+func (h *HTTPHandler) HandleTransitionLifecycle(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	var b TransitionLifecycle
+	err := ctx.BindJSON(&b)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, err := h.service.TransitionLifecycle(name, b.State, b.ActivateAt, b.Actor, b.Reason)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"lifecycle": value})
+}
+
+// HandleGetLifecycleAudit handles the GET /admin/leaderboards/:leaderboard/lifecycle/audit endpoint
+func (h *HTTPHandler) HandleGetLifecycleAudit(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	value, err := h.service.GetLifecycleAudit(name)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"audit": value})
+}
+
+// HandleMigrateEpochs handles the POST /admin/leaderboards/:leaderboard/migrate-epochs endpoint
+func (h *HTTPHandler) HandleMigrateEpochs(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	var b MigrateEpochs
+	err := ctx.BindJSON(&b)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, err := h.service.MigrateLegacyEpochs(name, b.LegacyEpochs)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"migrations": value})
+}
+
+// HandleGetFriendsScores handles the GET /friends/:leaderboard/:entry endpoint using the stored friend list
+func (h *HTTPHandler) HandleGetFriendsScores(ctx *gin.Context) {
+	epoch, err := strconv.ParseInt(ctx.DefaultQuery("epoch", "0"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	h.friendsScores(ctx, epoch, nil)
+}
+
+// HandlePostFriendsScores handles the POST /friends/:leaderboard/:entry endpoint using the given friend list
+func (h *HTTPHandler) HandlePostFriendsScores(ctx *gin.Context) {
+	var b FriendsScores
+	err := ctx.BindJSON(&b)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	h.friendsScores(ctx, b.Epoch, b.Friends)
+}
+
+// HandlePutFriends handles the PUT /friends/:entry endpoint
+func (h *HTTPHandler) HandlePutFriends(ctx *gin.Context) {
+	entry := ctx.Param("entry")
+	var b PutFriends
+	err := ctx.BindJSON(&b)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	err = h.service.SetFriends(entry, b.Friends)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "OK"})
+}
+
+func (h *HTTPHandler) friendsScores(ctx *gin.Context, epoch int64, friends []string) {
+	name := ctx.Param("leaderboard")
+	entry := ctx.Param("entry")
+	value, info, err := h.service.GetFriendsScores(entry, name, epoch, friends)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, withEpochInfo(gin.H{"scores": value}, info))
+}
+
+// HandleExport handles the GET /admin/leaderboards/:leaderboard/export endpoint, the standings
+// are streamed so the headers are only sent with the first row and errors before it are still
+// returned as JSON
+func (h *HTTPHandler) HandleExport(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	epoch, err := strconv.ParseInt(ctx.DefaultQuery("epoch", "0"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	scoreboards := domain.Metadata{}
+	for _, v := range ctx.QueryArray("scoreboard") {
+		field, value, ok := strings.Cut(v, ":")
+		if !ok || field == "" || value == "" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "scoreboard must be field:value"})
+			return
+		}
+		scoreboards[field] = value
+	}
+	format := ctx.DefaultQuery("format", domain.ExportCSV)
+	response := &exportResponse{ctx: ctx, contentType: h.formats.ContentType(format), filename: fmt.Sprintf("%s-%d.%s", name, epoch, format)}
+	out, err := h.formats.NewWriter(format, response)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	_, err = h.service.ExportStandings(name, epoch, scoreboards, out)
+	if err != nil {
+		if response.started {
+			// the status was already sent, the truncated body is all the client gets
+			_ = ctx.Error(err)
+			return
+		}
+		abortWithServiceError(ctx, err)
+		return
+	}
+	if !response.started {
+		response.writeHeader()
+	}
+}
+
+// exportResponse writes the headers of an export with its first write
+type exportResponse struct {
+	ctx         *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (r *exportResponse) writeHeader() {
+	r.ctx.Header("Content-Type", r.contentType)
+	r.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.filename))
+	r.ctx.Status(http.StatusOK)
+	r.ctx.Writer.WriteHeaderNow()
+	r.started = true
+}
+
+// Write implements io.Writer
+func (r *exportResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.writeHeader()
+	}
+	return r.ctx.Writer.Write(p)
+}
+
+// Flush sends the written rows to the client
+func (r *exportResponse) Flush() {
+	if r.started {
+		r.ctx.Writer.Flush()
+	}
+}
+
+// abortWithServiceError aborts with the status of the errors that are caused by the request
+// and with an internal server error otherwise
+func abortWithServiceError(ctx *gin.Context, err error) {
+	var closed *domain.LeaderboardClosedError
+	var state *domain.LeaderboardStateError
+	var transition *domain.LifecycleTransitionError
+	var invalid *domain.InvalidScoreError
+	var prizes *domain.PrizeDeliveryNotFoundError
+	var prize *domain.PrizeNotFoundError
+	var claimed *domain.PrizeClaimedError
+	var scoreboard *domain.ScoreboardNotFoundError
+	switch {
+	case errors.As(err, &prizes), errors.As(err, &prize):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &invalid), errors.As(err, &scoreboard):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &closed), errors.As(err, &state):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.As(err, &transition), errors.As(err, &claimed):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
+
+// withEpochInfo adds the epoch timing fields to a response
+func withEpochInfo(h gin.H, info domain.EpochInfo) gin.H {
+	h["epoch"] = info.Epoch
+	h["epoch_start"] = optionalTime(info.Start)
+	h["epoch_end"] = optionalTime(info.End)
+	h["next_reset"] = optionalTime(info.NextReset)
+	h["server_time"] = info.ServerTime
+	return h
+}
+
+// optionalTime returns nil for the zero time of epochs without start, end or next reset
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// metadataFromQuery collects the query parameters with the meta_ prefix
+func metadataFromQuery(ctx *gin.Context) map[string]string {
 	meta := make(map[string]string)
 	query := ctx.Request.URL.Query()
 	for k, v := range query {
@@ -57,11 +373,5 @@ func (h *HTTPHandler) HandleGetScores(ctx *gin.Context) {
 			meta[k[5:]] = v[0]
 		}
 	}
-	name := ctx.Param("leaderboard")
-	value, epoch, err := h.service.ListScoresWithMetadata(name, meta)
-	if err != nil {
-		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-	ctx.JSON(http.StatusOK, gin.H{"scores": value, "epoch": epoch})
+	return meta
 }
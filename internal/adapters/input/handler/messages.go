@@ -1,10 +1,42 @@
 package handler
 
-import "github.com/posilva/simpleboards/internal/core/domain"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
 
 // PutScore ...
 type PutScore struct {
-	Entry    string          `json:"entry"`
-	Score    float64         `json:"score"`
-	Metadata domain.Metadata `json:"metadata"`
+	Entry string `json:"entry"`
+	// Score is read as a decimal so integer and fixed-point scores are not rounded, it may be
+	// given as a JSON number or string
+	Score      json.Number     `json:"score"`
+	Metadata   domain.Metadata `json:"metadata"`
+	Components []float64       `json:"components,omitempty"`
+}
+
+// FriendsScores ...
+type FriendsScores struct {
+	Friends []string `json:"friends"`
+	Epoch   int64    `json:"epoch"`
+}
+
+// PutFriends ...
+type PutFriends struct {
+	Friends []string `json:"friends"`
+}
+
+// MigrateEpochs ...
+type MigrateEpochs struct {
+	LegacyEpochs []int64 `json:"legacy_epochs"`
+}
+
+// TransitionLifecycle ...
+type TransitionLifecycle struct {
+	State      domain.LeaderboardState `json:"state" binding:"required"`
+	ActivateAt *time.Time              `json:"activate_at"`
+	Actor      string                  `json:"actor" binding:"required"`
+	Reason     string                  `json:"reason"`
 }
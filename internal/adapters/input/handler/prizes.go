@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+// PrizesHTTPHandler is the HTTP Handler of the prize deliveries
+type PrizesHTTPHandler struct {
+	service ports.PrizesService
+}
+
+// NewPrizesHTTPHandler creates a new prizes HTTP Handler
+func NewPrizesHTTPHandler(srv ports.PrizesService) *PrizesHTTPHandler {
+	return &PrizesHTTPHandler{
+		service: srv,
+	}
+}
+
+// HandleGetDeadLetters handles the GET /admin/prizes/dead-letters endpoint
+func (h *PrizesHTTPHandler) HandleGetDeadLetters(ctx *gin.Context) {
+	value, err := h.service.GetDeadLetters()
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deliveries": value})
+}
+
+// HandleRedeliver handles the POST /admin/prizes/:leaderboard/:epoch/redeliver endpoint
+func (h *PrizesHTTPHandler) HandleRedeliver(ctx *gin.Context) {
+	name := ctx.Param("leaderboard")
+	epoch, err := strconv.ParseInt(ctx.Param("epoch"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, err := h.service.Redeliver(name, epoch)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"delivery": value})
+}
+
+// HandleGetPrizes handles the GET /prizes/:entry endpoint listing the unclaimed prizes
+func (h *PrizesHTTPHandler) HandleGetPrizes(ctx *gin.Context) {
+	entry := ctx.Param("entry")
+	value, err := h.service.GetUnclaimedPrizes(entry)
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"prizes": value})
+}
+
+// HandleClaimPrize handles the POST /prizes/:entry/:leaderboard/:epoch/claim endpoint, retries
+// with the same Idempotency-Key header return the claimed prize
+func (h *PrizesHTTPHandler) HandleClaimPrize(ctx *gin.Context) {
+	entry := ctx.Param("entry")
+	name := ctx.Param("leaderboard")
+	epoch, err := strconv.ParseInt(ctx.Param("epoch"), 10, 64)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	value, err := h.service.ClaimPrize(entry, name, epoch, ctx.GetHeader("Idempotency-Key"))
+	if err != nil {
+		abortWithServiceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"prize": value})
+}
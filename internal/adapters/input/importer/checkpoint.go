@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+// FileCheckpoint implements the ImportCheckpoint interface keeping the report of an import in a
+// JSON file
+type FileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint creates a new checkpoint in path
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+// Load reads the report of the checkpoint, it returns false when there is no checkpoint
+func (c *FileCheckpoint) Load() (domain.ImportReport, bool, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.ImportReport{}, false, nil
+	}
+	if err != nil {
+		return domain.ImportReport{}, false, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	var report domain.ImportReport
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		return domain.ImportReport{}, false, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	return report, true, nil
+}
+
+// Save writes the report to a temporary file which is renamed, so a stopped import never leaves
+// a partial checkpoint
+func (c *FileCheckpoint) Save(report domain.ImportReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %v", err)
+	}
+	tmp := c.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	err = os.Rename(tmp, c.path)
+	if err != nil {
+		return fmt.Errorf("failed to rename checkpoint: %v", err)
+	}
+	return nil
+}
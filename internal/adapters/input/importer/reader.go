@@ -0,0 +1,169 @@
+// Package importer is RecordReader and ImportCheckpoint interface implementations for the score
+// imports
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+const (
+	// FormatCSV reads records from CSV with a header row
+	FormatCSV = "csv"
+	// FormatNDJSON reads records from a JSON object per line
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	entryColumn    = "entry_id"
+	scoreColumn    = "score"
+	metadataColumn = "metadata"
+	// maxLineSize is the size of the longest NDJSON line
+	maxLineSize = 1024 * 1024
+)
+
+// NewRecordReader creates the record reader of a format
+func NewRecordReader(format string, r io.Reader) (ports.RecordReader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r), nil
+	case FormatNDJSON:
+		return NewNDJSONReader(r), nil
+	}
+	return nil, fmt.Errorf("unsupported import format: %s", format)
+}
+
+// CSVReader implements the RecordReader interface reading CSV with a header row, the entry_id
+// and score columns are required, a metadata column holds a JSON object and every other column
+// is a metadata field
+type CSVReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+// NewCSVReader creates a new CSV record reader
+func NewCSVReader(r io.Reader) *CSVReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &CSVReader{r: reader}
+}
+
+// Read reads a record, rows which can not be parsed are returned with Err
+func (c *CSVReader) Read() (domain.ImportRecord, error) {
+	if c.header == nil {
+		header, err := c.r.Read()
+		if err != nil {
+			return domain.ImportRecord{}, fmt.Errorf("failed to read header: %w", err)
+		}
+		c.header = append([]string{}, header...)
+		if !contains(c.header, entryColumn) || !contains(c.header, scoreColumn) {
+			return domain.ImportRecord{}, fmt.Errorf("header must have the %s and %s columns", entryColumn, scoreColumn)
+		}
+	}
+	row, err := c.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return domain.ImportRecord{Line: int64(parseErr.Line), Err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return domain.ImportRecord{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+	record := domain.ImportRecord{Line: int64(line), Metadata: domain.Metadata{}}
+	if len(row) != len(c.header) {
+		record.Err = fmt.Errorf("row has %d fields instead of %d", len(row), len(c.header))
+		return record, nil
+	}
+	for i, column := range c.header {
+		switch column {
+		case entryColumn:
+			record.EntryID = row[i]
+		case scoreColumn:
+			record.Score = row[i]
+		case metadataColumn:
+			if row[i] != "" {
+				err = json.Unmarshal([]byte(row[i]), &record.Metadata)
+				if err != nil {
+					record.Err = fmt.Errorf("metadata must be a JSON object: %v", err)
+				}
+			}
+		default:
+			if row[i] != "" {
+				record.Metadata[column] = row[i]
+			}
+		}
+	}
+	return record, nil
+}
+
+// NDJSONReader implements the RecordReader interface reading a JSON object per line with the
+// entry_id, score and metadata fields, scores may be numbers or decimal strings
+type NDJSONReader struct {
+	s    *bufio.Scanner
+	line int64
+}
+
+// NewNDJSONReader creates a new NDJSON record reader
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &NDJSONReader{s: s}
+}
+
+type ndjsonRecord struct {
+	EntryID  string          `json:"entry_id"`
+	Score    json.RawMessage `json:"score"`
+	Metadata domain.Metadata `json:"metadata"`
+}
+
+// Read reads a record skipping empty lines, lines which can not be parsed are returned with Err
+func (n *NDJSONReader) Read() (domain.ImportRecord, error) {
+	for n.s.Scan() {
+		n.line++
+		data := bytes.TrimSpace(n.s.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		record := domain.ImportRecord{Line: n.line}
+		var v ndjsonRecord
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			record.Err = fmt.Errorf("invalid JSON: %v", err)
+			return record, nil
+		}
+		record.EntryID = v.EntryID
+		record.Metadata = v.Metadata
+		record.Score = string(v.Score)
+		if len(v.Score) > 0 && v.Score[0] == '"' {
+			record.Score, err = strconv.Unquote(string(v.Score))
+			if err != nil {
+				record.Err = fmt.Errorf("invalid score: %v", err)
+			}
+		}
+		return record, nil
+	}
+	err := n.s.Err()
+	if err != nil {
+		return domain.ImportRecord{}, err
+	}
+	return domain.ImportRecord{}, io.EOF
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, format string, input string) []domain.ImportRecord {
+	r, err := NewRecordReader(format, strings.NewReader(input))
+	assert.NoError(t, err)
+	var records []domain.ImportRecord
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	records := readAll(t, FormatCSV, "entry_id,score,country,metadata\n"+
+		"p1,10.5,pt,\"{\"\"league\"\":\"\"gold\"\"}\"\n"+
+		"p2,3\n"+
+		"p3,7,,\n")
+	assert.Len(t, records, 3)
+	assert.Equal(t, domain.ImportRecord{Line: 2, EntryID: "p1", Score: "10.5", Metadata: domain.Metadata{"country": "pt", "league": "gold"}}, records[0])
+	assert.Equal(t, int64(3), records[1].Line)
+	assert.Error(t, records[1].Err)
+	assert.Equal(t, domain.ImportRecord{Line: 4, EntryID: "p3", Score: "7", Metadata: domain.Metadata{}}, records[2])
+
+	_, err := NewCSVReader(strings.NewReader("entry,points\n")).Read()
+	assert.Error(t, err)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	records := readAll(t, FormatNDJSON, `{"entry_id":"p1","score":10.50,"metadata":{"country":"pt"}}
+
+{"entry_id":"p2","score":"9007199254740993"}
+{"entry_id":
+`)
+	assert.Len(t, records, 3)
+	assert.Equal(t, domain.ImportRecord{Line: 1, EntryID: "p1", Score: "10.50", Metadata: domain.Metadata{"country": "pt"}}, records[0])
+	assert.Equal(t, domain.ImportRecord{Line: 3, EntryID: "p2", Score: "9007199254740993"}, records[1])
+	assert.Equal(t, int64(4), records[2].Line)
+	assert.Error(t, records[2].Err)
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	checkpoint := NewFileCheckpoint(filepath.Join(t.TempDir(), "import.json"))
+
+	_, found, err := checkpoint.Load()
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	report := domain.ImportReport{Leaderboard: "weekly", Epoch: 3, Mode: domain.ImportSet, Records: 1000, Applied: 998, Invalid: 2,
+		Issues: []domain.ImportIssue{{Line: 7, Error: "entry is missing"}}}
+	assert.NoError(t, checkpoint.Save(report))
+	v, found, err := checkpoint.Load()
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, report, v)
+}
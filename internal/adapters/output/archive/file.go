@@ -0,0 +1,120 @@
+// Package archive is ArchiveStore interface implementations other than the DynamoDB repository
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+// FileArchiveStore implements the ArchiveStore interface keeping an archive per file in
+// <dir>/<leaderboard>/<epoch>.json, it is meant for local use
+type FileArchiveStore struct {
+	dir string
+}
+
+// NewFileArchiveStore creates a new filesystem archive store in dir
+func NewFileArchiveStore(dir string) *FileArchiveStore {
+	return &FileArchiveStore{dir: dir}
+}
+
+// SaveArchive writes the archive to a temporary file which is renamed, so readers never see a
+// partial archive
+func (s *FileArchiveStore) SaveArchive(archive domain.EpochArchive) error {
+	path := s.path(archive.Leaderboard, archive.Epoch)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create archive directory: %v", err)
+	}
+	data, err := json.Marshal(archive)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive: %v", err)
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %v", err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed to rename archive: %v", err)
+	}
+	return nil
+}
+
+// GetArchive reads the archive of a leaderboard epoch and keeps the top n standings of the named
+// scoreboards, it returns false when it was not archived
+func (s *FileArchiveStore) GetArchive(name string, epoch int64, scoreboards []string, n int64) (domain.EpochArchive, bool, error) {
+	data, err := os.ReadFile(s.path(name, epoch))
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.EpochArchive{}, false, nil
+	}
+	if err != nil {
+		return domain.EpochArchive{}, false, fmt.Errorf("failed to read archive: %v", err)
+	}
+	var archive domain.EpochArchive
+	err = json.Unmarshal(data, &archive)
+	if err != nil {
+		return domain.EpochArchive{}, false, fmt.Errorf("failed to parse archive: %v", err)
+	}
+	read := []domain.LeaderboardScores{}
+	for _, sb := range scoreboards {
+		standings, ok := archive.Scoreboard(sb)
+		if !ok {
+			continue
+		}
+		if int64(len(standings.Scores)) > n {
+			standings.Scores = standings.Scores[:n]
+		}
+		read = append(read, standings)
+	}
+	archive.Scoreboards = read
+	return archive, true, nil
+}
+
+// GetArchivedEpoch returns the newest archived epoch of a leaderboard, it returns false when no
+// epoch was archived
+func (s *FileArchiveStore) GetArchivedEpoch(name string) (int64, bool, error) {
+	files, err := os.ReadDir(filepath.Dir(s.path(name, 0)))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to list archives: %v", err)
+	}
+	var last int64
+	found := false
+	for _, file := range files {
+		epoch, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), ".json"), 10, 64)
+		if err != nil || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		if !found || epoch > last {
+			last, found = epoch, true
+		}
+	}
+	return last, found, nil
+}
+
+// HasArchive returns true when the archive file of a leaderboard epoch exists
+func (s *FileArchiveStore) HasArchive(name string, epoch int64) (bool, error) {
+	_, err := os.Stat(s.path(name, epoch))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat archive: %v", err)
+	}
+	return true, nil
+}
+
+func (s *FileArchiveStore) path(name string, epoch int64) string {
+	return filepath.Join(s.dir, url.PathEscape(strings.ToLower(name)), strconv.FormatInt(epoch, 10)+".json")
+}
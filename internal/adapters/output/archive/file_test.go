@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileArchiveStore(t *testing.T) {
+	store := NewFileArchiveStore(t.TempDir())
+
+	_, found, err := store.GetArchive("Weekly", 3, []string{"weekly::3"}, 10)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = store.GetArchivedEpoch("Weekly")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	found, err = store.HasArchive("Weekly", 3)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	archive := domain.EpochArchive{
+		Leaderboard: "Weekly",
+		Epoch:       3,
+		ArchivedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Scoreboards: []domain.LeaderboardScores{
+			{Name: "weekly::3", Scores: []domain.LeaderboardEntry{{EntryID: "p1", Score: 10, Rank: 1}, {EntryID: "p2", Score: 5, Rank: 2}}},
+			{Name: "weekly::country::pt::3", Scores: []domain.LeaderboardEntry{{EntryID: "p1", Score: 10, Rank: 1}}},
+		},
+	}
+	assert.NoError(t, store.SaveArchive(archive))
+	found, err = store.HasArchive("weekly", 3)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	v, found, err := store.GetArchive("weekly", 3, []string{"weekly::3", "weekly::country::pt::3"}, 10)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, archive, v)
+
+	// only the top n standings of the named scoreboards which were archived are read
+	v, found, err = store.GetArchive("weekly", 3, []string{"weekly::country::es::3", "weekly::3"}, 1)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []domain.LeaderboardScores{{Name: "weekly::3", Scores: archive.Scoreboards[0].Scores[:1]}}, v.Scoreboards)
+
+	archive.Epoch = 12
+	assert.NoError(t, store.SaveArchive(archive))
+	epoch, found, err := store.GetArchivedEpoch("WEEKLY")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(12), epoch)
+}
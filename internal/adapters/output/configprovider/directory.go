@@ -0,0 +1,165 @@
+package configprovider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+// reloadDelay groups the changes of the files written together in a single reload
+const reloadDelay = 200 * time.Millisecond
+
+// FileConfigProvider provides the leaderboard configurations of the YAML and JSON files of a
+// directory, the files are watched and reloaded when they change
+type FileConfigProvider struct {
+	lock    sync.RWMutex
+	dir     string
+	state   configState
+	watcher *fsnotify.Watcher
+	logger  ports.Logger
+}
+
+// NewFileConfigProvider loads the configurations of a directory and watches it for changes, it
+// fails when the first load is not valid
+func NewFileConfigProvider(dir string, logger ports.Logger) (*FileConfigProvider, error) {
+	cp := &FileConfigProvider{
+		dir:    dir,
+		logger: logger,
+	}
+	cfgMap, err := LoadConfigDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	cp.state.load(cfgMap, nil)
+
+	cp.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config directory watcher: %v", err)
+	}
+	err = cp.watcher.Add(dir)
+	if err != nil {
+		cp.watcher.Close()
+		return nil, fmt.Errorf("failed to watch config directory %v: %v", dir, err)
+	}
+	go cp.watch()
+	return cp, nil
+}
+
+// Refresh reloads the configurations of the directory, the last good configurations are kept
+// when a file is not valid
+func (cp *FileConfigProvider) Refresh() {
+	cfgMap, err := LoadConfigDir(cp.dir)
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if err != nil {
+		cp.logger.Error("failed to reload configuration, keeping the last good one: %v", err)
+		cp.state.err = err
+		return
+	}
+	cp.logger.Debug("Refreshing configuration: %v", cfgMap)
+	cp.state.load(cfgMap, nil)
+}
+
+// Provide configurations for the leaderboards
+func (cp *FileConfigProvider) Provide() (domain.LeaderboardsConfigMap, error) {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+	return cp.state.current, nil
+}
+
+// Status returns the version of the loaded configurations and the error of the last reload
+func (cp *FileConfigProvider) Status() domain.ConfigStatus {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+	return cp.state.status()
+}
+
+// Close stops watching the directory
+func (cp *FileConfigProvider) Close() error {
+	return cp.watcher.Close()
+}
+
+// watch reloads the configurations once the directory stops changing, any change triggers a
+// reload because kubernetes config maps are updated by swapping the hidden ..data symlink
+func (cp *FileConfigProvider) watch() {
+	var timer *time.Timer
+	for {
+		select {
+		case _, ok := <-cp.watcher.Events:
+			if !ok {
+				return
+			}
+			if timer == nil {
+				timer = time.AfterFunc(reloadDelay, cp.Refresh)
+			} else {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-cp.watcher.Errors:
+			if !ok {
+				return
+			}
+			cp.logger.Error("failed to watch config directory %v: %v", cp.dir, err)
+		}
+	}
+}
+
+// LoadConfigDir reads, validates and compiles the configurations of the YAML and JSON files of a
+// directory
+func LoadConfigDir(dir string) (domain.LeaderboardsConfigMap, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %v", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && isConfigFile(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var configs []domain.LeaderboardConfig
+	for _, name := range names {
+		v, err := ReadConfigFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("file %v: %v", name, err)
+		}
+		configs = append(configs, v...)
+	}
+	err = domain.ValidateConfigs(configs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate configuration: %v", err)
+	}
+
+	cfgMap := make(domain.LeaderboardsConfigMap, len(configs))
+	for _, config := range configs {
+		config, err = compileConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("configuration '%v': %v", config.Name, err)
+		}
+		cfgMap[config.Name] = config
+	}
+	return cfgMap, nil
+}
+
+// isConfigFile returns true for the YAML and JSON files which are loaded, hidden files like
+// editor swap files and the config map data directories are ignored
+func isConfigFile(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
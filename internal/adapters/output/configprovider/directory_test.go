@@ -0,0 +1,117 @@
+package configprovider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const hourlyYAML = `
+leaderboards:
+  - name: hourly
+    function: 3
+    reset:
+      reset_type: 1
+`
+
+const dailyJSON = `{"leaderboards": [{"name": "daily", "function": 1, "reset": {"reset_type": 2}}]}`
+
+func writeConfig(t *testing.T, dir string, name string, data string) {
+	// the file is renamed into place like editors and config maps do
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	assert.NoError(t, os.WriteFile(tmp, []byte(data), 0o644))
+	assert.NoError(t, os.Rename(tmp, filepath.Join(dir, name)))
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "hourly.yaml", hourlyYAML)
+	writeConfig(t, dir, "daily.json", dailyJSON)
+	writeConfig(t, dir, "notes.txt", "not a config")
+
+	cfgMap, err := LoadConfigDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, cfgMap, 2)
+	assert.Equal(t, domain.Sum, cfgMap["hourly"].Function)
+	assert.NotNil(t, cfgMap["hourly"].CronExpression)
+	assert.Equal(t, domain.Max, cfgMap["daily"].Function)
+
+	// a leaderboard is defined once
+	writeConfig(t, dir, "copy.yml", hourlyYAML)
+	_, err = LoadConfigDir(dir)
+	assert.ErrorContains(t, err, "duplicated")
+
+	_, err = LoadConfigDir(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestFileConfigProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "hourly.yaml", hourlyYAML)
+
+	cp, err := NewFileConfigProvider(dir, logging.NewSimpleLogger())
+	assert.NoError(t, err)
+	defer cp.Close()
+
+	cfgMap, err := cp.Provide()
+	assert.NoError(t, err)
+	assert.Len(t, cfgMap, 1)
+
+	// new files are loaded
+	writeConfig(t, dir, "daily.json", dailyJSON)
+	assert.Eventually(t, func() bool {
+		cfgMap, _ := cp.Provide()
+		return len(cfgMap) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// an invalid file keeps the last good configuration
+	writeConfig(t, dir, "daily.json", `{"leaderboards": [{"name": "daily", "reset": {"reset_type": 99}}]}`)
+	time.Sleep(3 * reloadDelay)
+	cfgMap, err = cp.Provide()
+	assert.NoError(t, err)
+	assert.Len(t, cfgMap, 2)
+	assert.Equal(t, domain.Max, cfgMap["daily"].Function)
+	assert.Contains(t, cp.Status().Error, "daily")
+
+	// removed files are unloaded
+	assert.NoError(t, os.Remove(filepath.Join(dir, "daily.json")))
+	assert.Eventually(t, func() bool {
+		cfgMap, _ := cp.Provide()
+		return len(cfgMap) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, err = NewFileConfigProvider(filepath.Join(dir, "missing"), logging.NewSimpleLogger())
+	assert.Error(t, err)
+}
+
+func TestFileConfigProvider_ConfigMapSwap(t *testing.T) {
+	// kubernetes mounts config maps as symlinks to the hidden ..data directory, which is swapped
+	// atomically on updates
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "..v1", "configs.yaml"), []byte(hourlyYAML), 0o644))
+	assert.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	assert.NoError(t, os.Symlink(filepath.Join("..data", "configs.yaml"), filepath.Join(dir, "configs.yaml")))
+
+	cp, err := NewFileConfigProvider(dir, logging.NewSimpleLogger())
+	assert.NoError(t, err)
+	defer cp.Close()
+	cfgMap, _ := cp.Provide()
+	assert.Equal(t, domain.Sum, cfgMap["hourly"].Function)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "..v2"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "..v2", "configs.yaml"), []byte(strings.Replace(hourlyYAML, "function: 3", "function: 1", 1)), 0o644))
+	assert.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	assert.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	assert.Eventually(t, func() bool {
+		cfgMap, _ := cp.Provide()
+		return cfgMap["hourly"].Function == domain.Max
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -16,47 +16,53 @@ const (
 )
 
 type DynamoConfigProvider[T domain.LeaderboardsConfigMap] struct {
-	lock          sync.RWMutex
-	configGetter  ports.ConfigGetter
-	currentConfig domain.LeaderboardsConfigMap
-	err           error
-	scheduler     *services.Scheduler
-	logger        ports.Logger
+	lock         sync.RWMutex
+	configGetter ports.ConfigGetter
+	state        configState
+	scheduler    *services.Scheduler
+	logger       ports.Logger
 }
 
 func NewDynamoConfigProvider(configGetter ports.ConfigGetter, logger ports.Logger) *DynamoConfigProvider[domain.LeaderboardsConfigMap] {
 	cp := DynamoConfigProvider[domain.LeaderboardsConfigMap]{
-		err:           errors.New("config not initialized"),
-		currentConfig: nil,
-		logger:        logger,
-		configGetter:  configGetter,
+		state:        configState{err: errors.New("config not initialized")},
+		logger:       logger,
+		configGetter: configGetter,
 	}
 
 	cp.scheduler = services.NewScheduler(refreshIntervalSecs, cp.Refresh)
 	return &cp
 }
 
+// Refresh loads the stored configurations, a leaderboard which fails to load is quarantined with
+// its error and keeps its last good configuration so the other leaderboards are still updated
 func (cp *DynamoConfigProvider[T]) Refresh() {
-	cp.lock.Lock()
-	defer cp.lock.Unlock()
 	cfgMap, err := cp.configGetter.GetConfig()
 	cp.logger.Debug("Refreshing configuration: %v", cfgMap)
+
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
 	if err != nil {
 		cp.logger.Error("failed to get configuration: %v", err)
+		cp.state.err = fmt.Errorf("failed to get configuration: %v", err)
 		return
 	}
 
+	loaded := make(domain.LeaderboardsConfigMap, len(cfgMap))
+	broken := map[string]error{}
 	for name, config := range cfgMap {
-		ce, err := domain.NewCronExpression(config.ResetExpression)
+		config, err := compileConfig(config)
 		if err != nil {
-			cp.logger.Error("failed to update cron expression (%v) in configuration '%v': %v", config.ResetExpression, name, err)
-			return
+			cp.logger.Error("failed to load configuration '%v': %v", name, err)
+			broken[name] = err
+			if last, ok := cp.state.current[name]; ok {
+				loaded[name] = last
+			}
+			continue
 		}
-		config.CronExpression = ce
-		cfgMap[name] = config
+		loaded[name] = config
 	}
-
-	cp.currentConfig = cfgMap
+	cp.state.load(loaded, broken)
 }
 
 // Provide configurations for the leaderboard:s
@@ -64,8 +70,15 @@ func (cp *DynamoConfigProvider[T]) Provide() (domain.LeaderboardsConfigMap, erro
 	cp.lock.RLock()
 	defer cp.lock.RUnlock()
 
-	if cp.currentConfig != nil {
-		return cp.currentConfig, nil
+	if cp.state.current != nil {
+		return cp.state.current, nil
 	}
-	return cp.currentConfig, fmt.Errorf("configuration is not valid: %v", cp.err)
+	return cp.state.current, fmt.Errorf("configuration is not valid: %v", cp.state.err)
+}
+
+// Status returns the version of the loaded configurations and the leaderboards which failed to load
+func (cp *DynamoConfigProvider[T]) Status() domain.ConfigStatus {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+	return cp.state.status()
 }
@@ -1,11 +1,15 @@
 package configprovider
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
+	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/core/services"
+	"github.com/posilva/simpleboards/internal/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +37,69 @@ func TestDynamoDBConfigProvider(t *testing.T) {
 	time.Sleep(1100 * time.Millisecond)
 	assert.Equal(t, 2, counter.Get())
 }
+
+type testConfigGetter struct {
+	configs domain.LeaderboardsConfigMap
+	err     error
+}
+
+func (g *testConfigGetter) GetConfig() (domain.LeaderboardsConfigMap, error) {
+	return g.configs, g.err
+}
+
+func TestDynamoConfigProvider_RefreshIsolatesBrokenConfigs(t *testing.T) {
+	broken := testutil.NewLeaderboardConfigWithFunctionReset("broken", domain.LeaderboardResetType(99), domain.Sum)
+	getter := &testConfigGetter{configs: domain.LeaderboardsConfigMap{
+		"hourly": testutil.NewLeaderboardConfigWithFunctionReset("hourly", domain.Hourly, domain.Sum),
+		"broken": broken,
+	}}
+	cp := &DynamoConfigProvider[domain.LeaderboardsConfigMap]{
+		configGetter: getter,
+		logger:       logging.NewSimpleLogger(),
+		state:        configState{err: errors.New("config not initialized")},
+	}
+	_, err := cp.Provide()
+	assert.Error(t, err)
+
+	// the valid leaderboards are loaded on the first refresh
+	cp.Refresh()
+	cfgMap, err := cp.Provide()
+	assert.NoError(t, err)
+	assert.Len(t, cfgMap, 1)
+	status := cp.Status()
+	assert.NotEmpty(t, status.Version)
+	assert.Equal(t, []string{"hourly"}, status.Leaderboards)
+	assert.Len(t, status.Broken, 1)
+	assert.Equal(t, "broken", status.Broken[0].Name)
+	assert.False(t, status.Broken[0].Serving)
+
+	// a leaderboard which breaks keeps its last good configuration
+	version := status.Version
+	getter.configs["hourly"] = testutil.NewLeaderboardConfigWithFunctionReset("hourly", domain.LeaderboardResetType(99), domain.Max)
+	cp.Refresh()
+	cfgMap, err = cp.Provide()
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Sum, cfgMap["hourly"].Function)
+	status = cp.Status()
+	assert.Equal(t, version, status.Version)
+	assert.Len(t, status.Broken, 2)
+	assert.True(t, status.Broken[1].Serving)
+
+	// fixed leaderboards are loaded and change the version
+	broken.ResetExpression.Type = domain.Daily
+	getter.configs["broken"] = broken
+	getter.configs["hourly"] = testutil.NewLeaderboardConfigWithFunctionReset("hourly", domain.Hourly, domain.Max)
+	cp.Refresh()
+	status = cp.Status()
+	assert.NotEqual(t, version, status.Version)
+	assert.Equal(t, []string{"broken", "hourly"}, status.Leaderboards)
+	assert.Empty(t, status.Broken)
+
+	// the loaded configurations are kept when they cannot be read
+	getter.err = errors.New("unavailable")
+	cp.Refresh()
+	cfgMap, err = cp.Provide()
+	assert.NoError(t, err)
+	assert.Len(t, cfgMap, 2)
+	assert.Contains(t, cp.Status().Error, "unavailable")
+}
@@ -0,0 +1,119 @@
+package configprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the file of leaderboard configurations kept as code, the configurations have the
+// fields of the stored JSON
+type configFile struct {
+	Leaderboards []domain.LeaderboardConfig `json:"leaderboards"`
+}
+
+// ReadConfigFile reads the leaderboard configurations of a YAML or JSON file
+func ReadConfigFile(path string) ([]domain.LeaderboardConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	return ParseConfigFile(data)
+}
+
+// ParseConfigFile parses the leaderboard configurations of a YAML or JSON document
+func ParseConfigFile(data []byte) ([]domain.LeaderboardConfig, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	v, err := nodeValue(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	var file configFile
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	return file.Leaderboards, nil
+}
+
+// MarshalConfigFile returns the YAML config file of the configurations, secrets are redacted
+// because the files are kept along with the code and applying a redacted secret keeps the
+// stored one
+func MarshalConfigFile(configs []domain.LeaderboardConfig) ([]byte, error) {
+	redacted := make([]domain.LeaderboardConfig, len(configs))
+	for i, config := range configs {
+		redacted[i] = config.Redacted()
+	}
+	raw, err := json.Marshal(configFile{Leaderboards: redacted})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configs: %v", err)
+	}
+	var v interface{}
+	err = json.Unmarshal(raw, &v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configs: %v", err)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configs: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// nodeValue returns the JSON value of a YAML node, scalars which are not numbers, booleans or
+// null are kept as strings so times and dates are read as they are written
+func nodeValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return nodeValue(n.Content[0])
+	case yaml.AliasNode:
+		return nodeValue(n.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := nodeValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := nodeValue(c)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		return s, nil
+	}
+	switch n.ShortTag() {
+	case "!!int", "!!float", "!!bool", "!!null":
+		var v interface{}
+		err := n.Decode(&v)
+		return v, err
+	}
+	return n.Value, nil
+}
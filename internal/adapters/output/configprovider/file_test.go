@@ -0,0 +1,57 @@
+package configprovider
+
+import (
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const configYAML = `
+leaderboards:
+  - name: weekly
+    function: 3
+    reset:
+      reset_type: 3
+      anchor: 2024-01-01
+    prizes_table:
+      table:
+        - {rank_from: 1, rank_to: 3, action: gold}
+    scoreboards:
+      - {type: 1, field: country}
+`
+
+func TestParseConfigFile(t *testing.T) {
+	configs, err := ParseConfigFile([]byte(configYAML))
+	assert.NoError(t, err)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, "weekly", configs[0].Name)
+	assert.Equal(t, domain.Sum, configs[0].Function)
+	assert.Equal(t, domain.Weekly, configs[0].ResetExpression.Type)
+	// dates are kept as they are written
+	assert.Equal(t, "2024-01-01", configs[0].ResetExpression.Anchor)
+	assert.Equal(t, "gold", configs[0].PrizeTable.Table[0].Action)
+	assert.Equal(t, []domain.LeaderboardScoreBoardConfig{{Type: domain.Country, Field: "country"}}, configs[0].Scoreboards)
+
+	// exported files are read back
+	data, err := MarshalConfigFile(configs)
+	assert.NoError(t, err)
+	v, err := ParseConfigFile(data)
+	assert.NoError(t, err)
+	assert.Equal(t, configs, v)
+
+	_, err = ParseConfigFile([]byte("leaderboard:\n  - name: weekly\n"))
+	assert.Error(t, err)
+	_, err = ParseConfigFile([]byte("leaderboards: [\n"))
+	assert.Error(t, err)
+
+	// secrets are not exported
+	configs[0].Webhook = &domain.WebhookConfig{URL: "http://prizes", Secret: "hmac"}
+	data, err = MarshalConfigFile(configs)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hmac")
+	v, err = ParseConfigFile(data)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RedactedSecret, v[0].Webhook.Secret)
+	assert.Equal(t, "hmac", configs[0].Webhook.Secret)
+}
@@ -0,0 +1,83 @@
+package configprovider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+// configState is the loaded configurations of a provider and the errors of the last refresh
+type configState struct {
+	current  domain.LeaderboardsConfigMap
+	version  string
+	loadedAt time.Time
+	// broken holds the errors of the leaderboards which failed to load
+	broken map[string]error
+	err    error
+}
+
+// load replaces the loaded configurations, the version changes when they change
+func (s *configState) load(cfgMap domain.LeaderboardsConfigMap, broken map[string]error) {
+	version := configVersion(cfgMap)
+	if s.current == nil || version != s.version {
+		s.version = version
+		s.loadedAt = time.Now().UTC()
+	}
+	s.current = cfgMap
+	s.broken = broken
+	s.err = nil
+}
+
+func (s *configState) status() domain.ConfigStatus {
+	status := domain.ConfigStatus{
+		Version:      s.version,
+		LoadedAt:     s.loadedAt,
+		Leaderboards: make([]string, 0, len(s.current)),
+		Broken:       make([]domain.BrokenConfig, 0, len(s.broken)),
+	}
+	for name := range s.current {
+		status.Leaderboards = append(status.Leaderboards, name)
+	}
+	sort.Strings(status.Leaderboards)
+	for name, err := range s.broken {
+		_, serving := s.current[name]
+		status.Broken = append(status.Broken, domain.BrokenConfig{Name: name, Error: err.Error(), Serving: serving})
+	}
+	sort.Slice(status.Broken, func(i, j int) bool {
+		return status.Broken[i].Name < status.Broken[j].Name
+	})
+	if s.err != nil {
+		status.Error = s.err.Error()
+	}
+	return status
+}
+
+// compileConfig validates a configuration and compiles its cron expression
+func compileConfig(config domain.LeaderboardConfig) (domain.LeaderboardConfig, error) {
+	err := config.Validate()
+	if err != nil {
+		return config, fmt.Errorf("failed to validate configuration: %v", err)
+	}
+	ce, err := domain.NewCronExpression(config.ResetExpression)
+	if err != nil {
+		return config, fmt.Errorf("failed to update cron expression (%v): %v", config.ResetExpression, err)
+	}
+	config.CronExpression = ce
+	return config, nil
+}
+
+// configVersion returns the hash of the JSON of the configurations, the map keys are sorted so
+// the same configurations have the same version
+func configVersion(cfgMap domain.LeaderboardsConfigMap) string {
+	data, err := json.Marshal(cfgMap)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+const (
+	// DefaultQueueSize is the number of publishes the async publisher holds
+	DefaultQueueSize = 1024
+	// retryDelay is the delay before the first retry, it doubles on each retry up to maxRetryDelay
+	retryDelay    = 100 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+	// outboxGracePeriod is the age of the stored events which are published from the outbox, the
+	// younger ones are still being published by the server which stored them
+	outboxGracePeriod = 30 * time.Second
+	// outboxPollInterval is the interval the outbox is read at
+	outboxPollInterval = 10 * time.Second
+	// outboxPageSize is the number of events read from the outbox at once
+	outboxPageSize = 100
+)
+
+// AsyncPublisher implements the EventPublisher interface storing the events in an outbox and
+// relaying them to the event bus from a background worker, so a slow or unavailable bus does not
+// block the requests.
+//
+// Delivery is at least once. The events are durable once Publish returns and are only deleted
+// from the outbox after the bus acknowledges them, a failed publish is retried until it is
+// acknowledged or the publisher is closed. The events left in the outbox, by a full queue, a
+// stop or another server, are published from the outbox once they are older than
+// outboxGracePeriod, so consumers see an event more than once and dedupe them by ID
+type AsyncPublisher struct {
+	publisher ports.EventPublisher
+	outbox    ports.EventOutbox
+	log       ports.Logger
+	lock      sync.RWMutex
+	closed    bool
+	queue     chan []domain.Event
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+// NewAsyncPublisher creates a new publisher that stores the events in outbox and queues up to
+// size publishes for publisher
+func NewAsyncPublisher(publisher ports.EventPublisher, outbox ports.EventOutbox, size int, log ports.Logger) *AsyncPublisher {
+	p := &AsyncPublisher{
+		publisher: publisher,
+		outbox:    outbox,
+		log:       log,
+		queue:     make(chan []domain.Event, size),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish stores the events in the outbox and queues them, it fails when they are not stored.
+// The events which are not queued, because the queue is full or the publisher is closed, are
+// published from the outbox
+func (p *AsyncPublisher) Publish(events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	err := p.outbox.SaveEvents(events...)
+	if err != nil {
+		return fmt.Errorf("failed to store %d events: %v", len(events), err)
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.closed {
+		return nil
+	}
+	select {
+	case p.queue <- events:
+	default:
+	}
+	return nil
+}
+
+// Close stops queueing and waits until the queued events are published or timeout elapses, the
+// events which are not published stay in the outbox
+func (p *AsyncPublisher) Close(timeout time.Duration) error {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.lock.Unlock()
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(timeout):
+		p.stopOnce.Do(func() { close(p.stop) })
+		return fmt.Errorf("failed to publish the queued events in %v", timeout)
+	}
+}
+
+// run publishes the queued events and the events left in the outbox until the queue is closed
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case events, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			p.relay(events)
+		case now := <-ticker.C:
+			p.relayStored(now)
+		}
+	}
+}
+
+// relayStored publishes the events stored in the outbox before the grace period
+func (p *AsyncPublisher) relayStored(now time.Time) {
+	for {
+		events, err := p.outbox.GetPendingEvents(now.Add(-outboxGracePeriod), outboxPageSize)
+		if err != nil {
+			p.logError("failed to read the outbox: %v", err)
+			return
+		}
+		if len(events) == 0 || !p.relay(events) || len(events) < outboxPageSize {
+			return
+		}
+	}
+}
+
+// relay publishes the events until the bus acknowledges them and deletes them from the outbox,
+// the events keep their ID on retries so consumers dedupe them. It returns false when the events
+// stay in the outbox because the publisher was stopped or they were not deleted
+func (p *AsyncPublisher) relay(events []domain.Event) bool {
+	delay := retryDelay
+	for {
+		err := p.publisher.Publish(events...)
+		if err == nil {
+			break
+		}
+		p.logError("failed to publish %d events: %v", len(events), err)
+		select {
+		case <-p.stop:
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRetryDelay)
+	}
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	err := p.outbox.DeleteEvents(ids...)
+	if err != nil {
+		p.logError("failed to delete %d published events: %v", len(ids), err)
+		return false
+	}
+	return true
+}
+
+func (p *AsyncPublisher) logError(msg string, v ...interface{}) {
+	if p.log != nil {
+		_ = p.log.Error(msg, v...)
+	}
+}
@@ -0,0 +1,135 @@
+// Package events is EventPublisher interface implementations.
+//
+// Delivery is at least once. The score.accepted event of a score is stored in the outbox in the
+// same write as the score, and the events raised once the scoreboards are updated are stored in
+// the outbox before the score is acknowledged, replacing the stored score.accepted event with
+// the one holding the rank. The AsyncPublisher relays the stored events to the bus and deletes
+// them once the bus acknowledges them, retrying failed publishes, and publishes the events left
+// in the outbox by a stopped server. Consumers see an event more than once and dedupe them by
+// ID. When a server stops between the write of a score and the update of the scoreboards, only
+// the score.accepted event of the score is published, with the reported score and without rank
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+// MemoryPublisher implements the EventPublisher interface keeping the events in memory, it is
+// meant for local use and tests
+type MemoryPublisher struct {
+	lock   sync.Mutex
+	events []domain.Event
+}
+
+// NewMemoryPublisher creates a new in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish keeps the events
+func (p *MemoryPublisher) Publish(events ...domain.Event) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns the published events from the oldest
+func (p *MemoryPublisher) Events() []domain.Event {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]domain.Event{}, p.events...)
+}
+
+// MemoryOutbox implements the EventOutbox interface keeping the events in memory, it is meant
+// for tests since the events are lost when the process stops
+type MemoryOutbox struct {
+	lock   sync.Mutex
+	events map[string]storedEvent
+}
+
+type storedEvent struct {
+	event    domain.Event
+	storedAt time.Time
+}
+
+// NewMemoryOutbox creates a new in-memory outbox
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{events: map[string]storedEvent{}}
+}
+
+// SaveEvents keeps the events replacing the ones with the same ID
+func (o *MemoryOutbox) SaveEvents(events ...domain.Event) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	now := time.Now()
+	for _, e := range events {
+		o.events[e.ID] = storedEvent{event: e, storedAt: now}
+	}
+	return nil
+}
+
+// GetPendingEvents returns up to limit events kept before a time in ID order
+func (o *MemoryOutbox) GetPendingEvents(before time.Time, limit int) ([]domain.Event, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	events := []domain.Event{}
+	for _, stored := range o.events {
+		if stored.storedAt.Before(before) {
+			events = append(events, stored.event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// DeleteEvents removes the events
+func (o *MemoryOutbox) DeleteEvents(ids ...string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	for _, id := range ids {
+		delete(o.events, id)
+	}
+	return nil
+}
+
+// WriterPublisher implements the EventPublisher interface writing the events as JSON lines
+type WriterPublisher struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterPublisher creates a new publisher that writes to w
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewStdoutPublisher creates a new publisher that writes to the standard output
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+// Publish writes a line per event
+func (p *WriterPublisher) Publish(events ...domain.Event) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	enc := json.NewEncoder(p.w)
+	for _, e := range events {
+		err := enc.Encode(e)
+		if err != nil {
+			return fmt.Errorf("failed to write event: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/redis/rueidis"
+	mock "github.com/redis/rueidis/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMemoryPublisher(t *testing.T) {
+	p := NewMemoryPublisher()
+	e := domain.Event{ID: "1", Type: domain.ScoreAccepted, Leaderboard: "weekly", EntryID: "p1", Score: 10}
+	assert.NoError(t, p.Publish(e))
+	assert.NoError(t, p.Publish())
+	assert.Equal(t, []domain.Event{e}, p.Events())
+}
+
+// failingPublisher fails the first publishes
+type failingPublisher struct {
+	MemoryPublisher
+	failures int
+	ids      []string
+}
+
+func (p *failingPublisher) Publish(events ...domain.Event) error {
+	p.lock.Lock()
+	p.ids = append(p.ids, events[0].ID)
+	p.failures--
+	failed := p.failures >= 0
+	p.lock.Unlock()
+	if failed {
+		return fmt.Errorf("unavailable")
+	}
+	return p.MemoryPublisher.Publish(events...)
+}
+
+func TestAsyncPublisher(t *testing.T) {
+	target := &failingPublisher{failures: 2}
+	outbox := NewMemoryOutbox()
+	p := NewAsyncPublisher(target, outbox, 1, nil)
+
+	// failed publishes are retried with the same events until they are acknowledged, and the
+	// events are deleted from the outbox once published
+	e := domain.Event{ID: "1", Type: domain.ScoreAccepted, Leaderboard: "weekly", EntryID: "p1", Score: 10}
+	assert.NoError(t, p.Publish(e))
+	assert.NoError(t, p.Close(5*time.Second))
+	assert.Equal(t, []domain.Event{e}, target.Events())
+	assert.Equal(t, []string{"1", "1", "1"}, target.ids)
+	pending, err := outbox.GetPendingEvents(time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// a closed publisher stores the events in the outbox
+	assert.NoError(t, p.Publish(e))
+	pending, err = outbox.GetPendingEvents(time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{e}, pending)
+}
+
+func TestAsyncPublisherOutbox(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	blocked := make(chan struct{})
+	p := NewAsyncPublisher(&blockingPublisher{blocked}, outbox, 1, nil)
+
+	// the events of a full queue stay in the outbox
+	e := domain.Event{ID: "1", Type: domain.ScoreAccepted, Leaderboard: "weekly", EntryID: "p1", Score: 10}
+	assert.NoError(t, p.Publish(e))
+	assert.Eventually(t, func() bool { return len(p.queue) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, p.Publish(domain.Event{ID: "2"}))
+	assert.NoError(t, p.Publish(domain.Event{ID: "3"}))
+	close(blocked)
+	assert.NoError(t, p.Close(5*time.Second))
+	pending, err := outbox.GetPendingEvents(time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{{ID: "3"}}, pending)
+
+	// the events left in the outbox are published once they are older than the grace period
+	target := NewMemoryPublisher()
+	p = NewAsyncPublisher(target, outbox, 1, nil)
+	p.relayStored(time.Now())
+	assert.Empty(t, target.Events())
+	p.relayStored(time.Now().Add(outboxGracePeriod + time.Second))
+	assert.Equal(t, []domain.Event{{ID: "3"}}, target.Events())
+	assert.NoError(t, p.Close(5*time.Second))
+
+	// the events which are not acknowledged before the timeout stay in the outbox
+	p = NewAsyncPublisher(&failingPublisher{failures: 1000}, outbox, 1, nil)
+	assert.NoError(t, p.Publish(e))
+	assert.Error(t, p.Close(10*time.Millisecond))
+	pending, err = outbox.GetPendingEvents(time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{e}, pending)
+}
+
+// blockingPublisher blocks the publishes until the channel is closed
+type blockingPublisher struct {
+	blocked chan struct{}
+}
+
+func (p *blockingPublisher) Publish(events ...domain.Event) error {
+	<-p.blocked
+	return nil
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, p.Publish(
+		domain.Event{ID: "1", Type: domain.ScoreAccepted, Leaderboard: "weekly", Epoch: 3, EntryID: "p1", Score: 10, At: at},
+		domain.Event{ID: "2", Type: domain.EpochClosed, Leaderboard: "weekly", Epoch: 3, At: at},
+	))
+	assert.Equal(t,
+		`{"id":"1","type":"score.accepted","leaderboard":"weekly","epoch":3,"entry_id":"p1","score":10,"at":"2024-01-01T00:00:00Z"}`+"\n"+
+			`{"id":"2","type":"epoch.closed","leaderboard":"weekly","epoch":3,"at":"2024-01-01T00:00:00Z"}`+"\n",
+		buf.String())
+}
+
+func TestRedisStreamPublisher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	p := NewRedisStreamPublisherWithClient(c, "events")
+	e := domain.Event{ID: "1", Type: domain.RankChanged, Leaderboard: "weekly", EntryID: "p1", Rank: 1, PreviousRank: 2}
+	data, err := json.Marshal(e)
+	assert.NoError(t, err)
+
+	c.EXPECT().DoMulti(context.Background(),
+		mock.Match("XADD", "events", "MAXLEN", "~", "100000", "*", "id", "1", "type", "rank.changed", "event", string(data)),
+	).Return([]rueidis.RedisResult{mock.Result(mock.RedisString("1-0"))})
+	assert.NoError(t, p.Publish(e))
+
+	c.EXPECT().DoMulti(context.Background(), gomock.Any()).Return([]rueidis.RedisResult{mock.ErrorResult(fmt.Errorf("unavailable"))})
+	assert.Error(t, p.Publish(e))
+}
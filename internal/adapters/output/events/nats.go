@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+const publishTimeout = 5 * time.Second
+
+// NATSPublisher implements the EventPublisher interface publishing the events to a JetStream
+// stream on the subject <subject>.<event type>, the event id deduplicates retries in the stream
+// duplicate window
+type NATSPublisher struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// NewNATSPublisher connects to NATS and creates or updates the stream of the subject
+func NewNATSPublisher(url string, stream string, subject string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats '%v': %v", url, err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{subject + ".>"},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream '%v': %v", stream, err)
+	}
+	return &NATSPublisher{conn: conn, js: js, subject: subject}, nil
+}
+
+// Publish publishes the events waiting for the acknowledgement of each one
+func (p *NATSPublisher) Publish(events ...domain.Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %v", err)
+		}
+		_, err = p.js.Publish(ctx, p.subject+"."+string(e.Type), data, jetstream.WithMsgID(e.ID))
+		if err != nil {
+			return fmt.Errorf("failed to publish event: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close drains the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/redis/rueidis"
+)
+
+// defaultStreamMaxLen is the approximate number of events kept in the stream
+const defaultStreamMaxLen = 100000
+
+// RedisStreamPublisher implements the EventPublisher interface adding the events to a Redis
+// stream, each entry holds the id, the type and the JSON event
+type RedisStreamPublisher struct {
+	client rueidis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher creates a new Redis Streams publisher
+func NewRedisStreamPublisher(address string, stream string) (*RedisStreamPublisher, error) {
+	c, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{address}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis host '%v': %v ", address, err)
+	}
+	return NewRedisStreamPublisherWithClient(c, stream), nil
+}
+
+// NewRedisStreamPublisherWithClient creates a new Redis Streams publisher with a client
+func NewRedisStreamPublisherWithClient(client rueidis.Client, stream string) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		client: client,
+		stream: stream,
+		maxLen: defaultStreamMaxLen,
+	}
+}
+
+// Publish adds the events to the stream
+func (p *RedisStreamPublisher) Publish(events ...domain.Event) error {
+	cmds := make(rueidis.Commands, 0, len(events))
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %v", err)
+		}
+		cmds = append(cmds, p.client.B().Xadd().Key(p.stream).
+			Maxlen().Almost().Threshold(strconv.FormatInt(p.maxLen, 10)).
+			Id("*").FieldValue().
+			FieldValue("id", e.ID).
+			FieldValue("type", string(e.Type)).
+			FieldValue("event", string(data)).
+			Build())
+	}
+	for _, r := range p.client.DoMulti(context.Background(), cmds...) {
+		if err := r.Error(); err != nil {
+			return fmt.Errorf("failed to add event to stream: %v", err)
+		}
+	}
+	return nil
+}
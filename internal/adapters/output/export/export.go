@@ -0,0 +1,147 @@
+// Package export is StandingsWriter interface implementations
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+// csvHeader is the header row of the CSV export, the metadata column is a JSON object
+var csvHeader = []string{"scoreboard", "rank", "entry_id", "score", "exact_score", "counter", "metadata"}
+
+// NewStandingsWriter creates the standings writer of a format
+func NewStandingsWriter(format string, w io.Writer) (ports.StandingsWriter, error) {
+	switch format {
+	case domain.ExportCSV:
+		return NewCSVWriter(w), nil
+	case domain.ExportNDJSON:
+		return NewNDJSONWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// Formats implements the StandingsFormats interface with the CSV and NDJSON writers
+type Formats struct{}
+
+// NewFormats creates the export formats
+func NewFormats() *Formats {
+	return &Formats{}
+}
+
+// NewWriter creates the standings writer of a format
+func (f *Formats) NewWriter(format string, w io.Writer) (ports.StandingsWriter, error) {
+	return NewStandingsWriter(format, w)
+}
+
+// ContentType returns the media type of a format
+func (f *Formats) ContentType(format string) string {
+	if format == domain.ExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// CSVWriter implements the StandingsWriter interface writing CSV rows
+type CSVWriter struct {
+	w      *csv.Writer
+	flush  func()
+	header bool
+}
+
+// NewCSVWriter creates a new CSV standings writer, the header is written before the first row
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), flush: flusherOf(w)}
+}
+
+// Write writes a row
+func (c *CSVWriter) Write(row domain.StandingsRow) error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	metadata := ""
+	if len(row.Metadata) > 0 {
+		data, err := json.Marshal(row.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(data)
+	}
+	return c.w.Write([]string{
+		row.Scoreboard,
+		strconv.FormatInt(row.Rank, 10),
+		row.EntryID,
+		strconv.FormatFloat(row.Score, 'f', -1, 64),
+		row.ExactScore,
+		strconv.FormatUint(row.Counter, 10),
+		metadata,
+	})
+}
+
+// Flush writes the buffered rows to the underlying writer, the header is written even when
+// there are no rows
+func (c *CSVWriter) Flush() error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	err = c.w.Error()
+	if err != nil {
+		return err
+	}
+	c.flush()
+	return nil
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(csvHeader)
+}
+
+// NDJSONWriter implements the StandingsWriter interface writing a JSON object per line
+type NDJSONWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	flush func()
+}
+
+// NewNDJSONWriter creates a new NDJSON standings writer
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	buf := bufio.NewWriter(w)
+	return &NDJSONWriter{w: buf, enc: json.NewEncoder(buf), flush: flusherOf(w)}
+}
+
+// Write writes a row
+func (n *NDJSONWriter) Write(row domain.StandingsRow) error {
+	return n.enc.Encode(row)
+}
+
+// Flush writes the buffered rows to the underlying writer
+func (n *NDJSONWriter) Flush() error {
+	err := n.w.Flush()
+	if err != nil {
+		return err
+	}
+	n.flush()
+	return nil
+}
+
+// flusherOf returns the flush of writers which buffer themselves, like HTTP responses, so the
+// rows are streamed on every flush
+func flusherOf(w io.Writer) func() {
+	if f, ok := w.(interface{ Flush() }); ok {
+		return f.Flush
+	}
+	return func() {}
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var rows = []domain.StandingsRow{
+	{Scoreboard: "weekly::3", Rank: 1, EntryID: "p1", Score: 10.5, ExactScore: "10.50", Counter: 2, Metadata: domain.Metadata{"country": "pt"}},
+	{Scoreboard: "weekly::3", Rank: 2, EntryID: "p2", Score: 3, Counter: 1},
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewStandingsWriter(domain.ExportCSV, &buf)
+	assert.NoError(t, err)
+	for _, row := range rows {
+		assert.NoError(t, w.Write(row))
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "scoreboard,rank,entry_id,score,exact_score,counter,metadata\n"+
+		"weekly::3,1,p1,10.5,10.50,2,\"{\"\"country\"\":\"\"pt\"\"}\"\n"+
+		"weekly::3,2,p2,3,,1,\n", buf.String())
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewStandingsWriter(domain.ExportNDJSON, &buf)
+	assert.NoError(t, err)
+	for _, row := range rows {
+		assert.NoError(t, w.Write(row))
+	}
+	assert.Empty(t, buf.String())
+	assert.NoError(t, w.Flush())
+
+	dec := json.NewDecoder(&buf)
+	for _, row := range rows {
+		var v domain.StandingsRow
+		assert.NoError(t, dec.Decode(&v))
+		assert.Equal(t, row, v)
+	}
+	assert.False(t, dec.More())
+}
+
+func TestNewStandingsWriterUnsupported(t *testing.T) {
+	_, err := NewStandingsWriter("xml", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestFormats(t *testing.T) {
+	f := NewFormats()
+	w, err := f.NewWriter(domain.ExportNDJSON, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.IsType(t, &NDJSONWriter{}, w)
+	assert.Equal(t, "application/x-ndjson", f.ContentType(domain.ExportNDJSON))
+	assert.Equal(t, "text/csv", f.ContentType(domain.ExportCSV))
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -24,10 +27,53 @@ const (
 	pkUserPrefix        string = "USR#"
 	skLeaderboardPrefix string = "LBRD#"
 
-	queryTimeout   = 1 * time.Second
-	pkConfigPrefix = "LBRD#CONFIG"
-	skConfigPrefix = "LBRD#NAME#"
-	scoreAttrib    = "score"
+	queryTimeout    = 1 * time.Second
+	migrateTimeout  = 5 * time.Minute
+	pkConfigPrefix  = "LBRD#CONFIG"
+	skConfigPrefix  = "LBRD#NAME#"
+	scoreAttrib     = "score"
+	skFriends       = "FRIENDS"
+	pkStatsPrefix   = "LBRD#STATS"
+	counterAttrib   = "counter"
+	pkEpochPrefix   = "LBRD#EPOCH"
+	epochAttrib     = "epoch"
+	rawAttrib       = "raw"
+	componentsAttr  = "components"
+	reportedAttrib  = "reported_at"
+	pkAuditPrefix   = "LBRD#AUDIT#"
+	configAttrib    = "config"
+	pkPrizesPrefix  = "LBRD#PRIZES"
+	skPrizePrefix   = "PRIZE#"
+	claimedAttrib   = "claimed_at"
+	claimIDAttrib   = "claim_id"
+	stateAttrib     = "state"
+	attemptsAttrib  = "attempts"
+	metadataPrefix  = "md::"
+	pkArchivePrefix = "LBRD#ARCHIVE#"
+	skArchiveHeader = "HEADER"
+	skArchivePart   = "PART#"
+	// pkArchivedEpoch keys the last archived epoch of every leaderboard
+	pkArchivedEpoch = "LBRD#ARCHIVED"
+	// pkPrizeStatePrefix keys a copy of the pending and dead deliveries by their state, so they
+	// are read without the delivered ones
+	pkPrizeStatePrefix = "LBRD#PRIZES#STATE#"
+	pkPrizesClosed     = "LBRD#PRIZES#CLOSED"
+	// submissionShards is the number of counters the submissions of a leaderboard epoch are spread
+	// across, so the counter is not a hot key
+	submissionShards = 10
+	// archivePartEntries is the number of entries of an archive item, which keeps it far from
+	// the dynamodb item size limit
+	archivePartEntries = 1000
+	// maxBatchGetKeys is the number of keys dynamodb reads in a batch
+	maxBatchGetKeys = 100
+	// maxBatchWriteItems is the number of items dynamodb writes in a batch
+	maxBatchWriteItems = 25
+	// unprocessedBackoff is the delay cap before the first resubmission of the unprocessed items
+	// of a batch, doubled on every attempt up to maxUnprocessedBackoff
+	unprocessedBackoff    = 50 * time.Millisecond
+	maxUnprocessedBackoff = 5 * time.Second
+	// auditTimeLayout keeps the audit sort keys in chronological order
+	auditTimeLayout = "2006-01-02T15:04:05.000000000Z"
 )
 
 // DDBConfigItem ...
@@ -39,10 +85,96 @@ type DDBConfigItem struct {
 
 // LeaderboardEntryRecord represents a dynamodb table record
 type LeaderboardEntryRecord struct {
-	PK      string  `dynamodbav:"pk" json:"pk"`
-	SK      string  `dynamodbav:"sk" json:"sk"`
-	Score   float64 `dynamodbav:"score" json:"score"`
-	Counter uint64  `dynamodbav:"counter" json:"counter"`
+	PK         string    `dynamodbav:"pk" json:"pk"`
+	SK         string    `dynamodbav:"sk" json:"sk"`
+	Score      float64   `dynamodbav:"score" json:"score"`
+	Counter    uint64    `dynamodbav:"counter" json:"counter"`
+	Components []float64 `dynamodbav:"components,omitempty" json:"components,omitempty"`
+}
+
+// ExactEntryRecord represents a dynamodb table record with an exact decimal score
+type ExactEntryRecord struct {
+	PK      string                `dynamodbav:"pk" json:"pk"`
+	SK      string                `dynamodbav:"sk" json:"sk"`
+	Score   attributevalue.Number `dynamodbav:"score" json:"score"`
+	Counter uint64                `dynamodbav:"counter" json:"counter"`
+}
+
+// StoredEntryRecord represents a dynamodb table record of an entry as imports read and write it
+type StoredEntryRecord struct {
+	PK         string                `dynamodbav:"pk"`
+	SK         string                `dynamodbav:"sk"`
+	Score      attributevalue.Number `dynamodbav:"score"`
+	Counter    uint64                `dynamodbav:"counter"`
+	Raw        float64               `dynamodbav:"raw,omitempty"`
+	ReportedAt int64                 `dynamodbav:"reported_at,omitempty"`
+	ImportID   string                `dynamodbav:"import_id,omitempty"`
+	ImportLine int64                 `dynamodbav:"import_line,omitempty"`
+}
+
+// FriendsRecord represents the friend list of an entry
+type FriendsRecord struct {
+	PK      string   `dynamodbav:"pk"`
+	SK      string   `dynamodbav:"sk"`
+	Friends []string `dynamodbav:"friends"`
+}
+
+// EpochRecord represents the current epoch of a manually reset leaderboard
+type EpochRecord struct {
+	PK    string `dynamodbav:"pk"`
+	SK    string `dynamodbav:"sk"`
+	Epoch int64  `dynamodbav:"epoch"`
+}
+
+// LifecycleAuditRecord represents a lifecycle transition of a leaderboard
+type LifecycleAuditRecord struct {
+	PK         string     `dynamodbav:"pk"`
+	SK         string     `dynamodbav:"sk"`
+	Name       string     `dynamodbav:"name"`
+	From       string     `dynamodbav:"from"`
+	To         string     `dynamodbav:"to"`
+	ActivateAt *time.Time `dynamodbav:"activate_at,omitempty"`
+	Actor      string     `dynamodbav:"actor"`
+	Reason     string     `dynamodbav:"reason"`
+	At         time.Time  `dynamodbav:"at"`
+}
+
+// PrizeDeliveryRecord represents the delivery of the award batch of a leaderboard epoch
+type PrizeDeliveryRecord struct {
+	PK          string    `dynamodbav:"pk"`
+	SK          string    `dynamodbav:"sk"`
+	State       string    `dynamodbav:"state"`
+	Attempts    int       `dynamodbav:"attempts"`
+	NextAttempt time.Time `dynamodbav:"next_attempt"`
+	LastError   string    `dynamodbav:"last_error"`
+	UpdatedAt   time.Time `dynamodbav:"updated_at"`
+	Batch       string    `dynamodbav:"batch"`
+}
+
+// EntryPrizeRecord represents a prize awarded to an entry in a leaderboard epoch
+type EntryPrizeRecord struct {
+	PK          string     `dynamodbav:"pk"`
+	SK          string     `dynamodbav:"sk"`
+	Leaderboard string     `dynamodbav:"leaderboard"`
+	Epoch       int64      `dynamodbav:"epoch"`
+	Rank        int64      `dynamodbav:"rank"`
+	Score       float64    `dynamodbav:"score"`
+	ExactScore  string     `dynamodbav:"exact_score,omitempty"`
+	Action      string     `dynamodbav:"action"`
+	AwardedAt   time.Time  `dynamodbav:"awarded_at"`
+	ClaimedAt   *time.Time `dynamodbav:"claimed_at,omitempty"`
+	ClaimID     string     `dynamodbav:"claim_id,omitempty"`
+}
+
+// ArchiveRecord represents a part of the archived standings of a leaderboard epoch, the header
+// is written after the parts and marks the archive as complete
+type ArchiveRecord struct {
+	PK         string    `dynamodbav:"pk"`
+	SK         string    `dynamodbav:"sk"`
+	Scoreboard string    `dynamodbav:"scoreboard,omitempty"`
+	Scores     string    `dynamodbav:"scores,omitempty"`
+	Parts      int       `dynamodbav:"parts,omitempty"`
+	ArchivedAt time.Time `dynamodbav:"archived_at"`
 }
 
 // DynamoDBRepository implements Repository interface for DynamoDB
@@ -50,6 +182,8 @@ type DynamoDBRepository struct {
 	log       ports.Logger
 	client    DynamoDBClient
 	tableName string
+	// outbox holds the events stored along with the scores, see WithOutbox
+	outbox []domain.Event
 }
 
 // NewDynamoDBRepository creates a new DynamoDB repository
@@ -96,7 +230,7 @@ func (r *DynamoDBRepository) AddWithMetadata(entry string, leaderboard string, v
 		ConditionExpression: expr.Condition(),
 	}
 
-	output, err := r.client.UpdateItem(context.Background(), &input)
+	output, err := r.updateEntry(context.Background(), &input)
 	if err != nil {
 		return domain.ScoreUpdate{}, fmt.Errorf("failed to update item: %w", err)
 	}
@@ -112,7 +246,7 @@ func (r *DynamoDBRepository) AddWithMetadata(entry string, leaderboard string, v
 }
 
 func addMetadataPrefix(k string) string {
-	return "md::" + k
+	return metadataPrefix + k
 }
 
 // MaxWithMetadata ...
@@ -156,7 +290,70 @@ func (r *DynamoDBRepository) MaxWithMetadata(entry string, leaderboard string, v
 		UpdateExpression: expr.Update(),
 	}
 
-	output, err := r.client.UpdateItem(context.Background(), &input)
+	output, err := r.updateEntry(context.Background(), &input)
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return domain.ScoreUpdate{Done: false}, nil
+		}
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to update item: %w", err)
+	}
+	s := LeaderboardEntryRecord{}
+	err = attributevalue.UnmarshalMap(output.Attributes, &s)
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to process output: %w", err)
+	}
+
+	return domain.ScoreUpdate{Score: s.Score, Done: true, Counter: s.Counter}, nil
+}
+
+// DecayWithMetadata stores a raw score and the time it was reported when its normalised value is
+// not less than the stored one, which keeps the score with the greatest decayed value
+func (r *DynamoDBRepository) DecayWithMetadata(entry string, leaderboard string, value domain.DecayedScore, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	builder := expression.NewBuilder()
+	update := expression.Set(
+		expression.Name(scoreAttrib),
+		expression.Value(value.Score),
+	).Set(
+		expression.Name(rawAttrib),
+		expression.Value(value.Raw),
+	).Set(
+		expression.Name(reportedAttrib),
+		expression.Value(value.At.Unix()),
+	).Add(
+		expression.Name("counter"),
+		expression.Value(1),
+	)
+
+	condBuilder := expression.Name(scoreAttrib).
+		LessThanEqual(expression.Value(value.Score)).
+		Or(expression.Name(scoreAttrib).AttributeNotExists())
+
+	if meta != nil {
+		update = r.updateWithMetadata(meta, update)
+		cb := r.builderFromMetadata(meta)
+		condBuilder = condBuilder.And(cb)
+	}
+
+	expr, err := builder.WithUpdate(update).WithCondition(condBuilder).Build()
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to build update expression: %w", err)
+	}
+
+	input := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+		ConditionExpression:       expr.Condition(),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+		UpdateExpression: expr.Update(),
+	}
+
+	output, err := r.updateEntry(context.Background(), &input)
 	if err != nil {
 		var ccfe *types.ConditionalCheckFailedException
 		if errors.As(err, &ccfe) {
@@ -173,6 +370,413 @@ func (r *DynamoDBRepository) MaxWithMetadata(entry string, leaderboard string, v
 	return domain.ScoreUpdate{Score: s.Score, Done: true, Counter: s.Counter}, nil
 }
 
+// CompositeWithMetadata stores the components of a composite score applying the function to the
+// packed score, only the max and last functions are supported
+func (r *DynamoDBRepository) CompositeWithMetadata(entry string, leaderboard string, value domain.CompositeScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	builder := expression.NewBuilder()
+	update := expression.Set(
+		expression.Name(scoreAttrib),
+		expression.Value(value.Score),
+	).Set(
+		expression.Name(componentsAttr),
+		expression.Value(value.Components),
+	).Add(
+		expression.Name("counter"),
+		expression.Value(1),
+	)
+
+	var condBuilder *expression.ConditionBuilder
+	switch function {
+	case domain.Max:
+		c := expression.Name(scoreAttrib).
+			LessThanEqual(expression.Value(value.Score)).
+			Or(expression.Name(scoreAttrib).AttributeNotExists())
+		condBuilder = &c
+	case domain.Last:
+	default:
+		return domain.ScoreUpdate{}, fmt.Errorf("function %v is not supported for composite scores", function)
+	}
+
+	if meta != nil {
+		update = r.updateWithMetadata(meta, update)
+		cb := r.builderFromMetadata(meta)
+		if condBuilder != nil {
+			cb = condBuilder.And(cb)
+		}
+		condBuilder = &cb
+	}
+
+	builder = builder.WithUpdate(update)
+	if condBuilder != nil {
+		builder = builder.WithCondition(*condBuilder)
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to build update expression: %w", err)
+	}
+
+	input := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+		ConditionExpression:       expr.Condition(),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+		UpdateExpression: expr.Update(),
+	}
+
+	output, err := r.updateEntry(context.Background(), &input)
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return domain.ScoreUpdate{Done: false}, nil
+		}
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to update item: %w", err)
+	}
+	s := LeaderboardEntryRecord{}
+	err = attributevalue.UnmarshalMap(output.Attributes, &s)
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to process output: %w", err)
+	}
+
+	return domain.ScoreUpdate{Score: s.Score, Done: true, Counter: s.Counter, Components: s.Components}, nil
+}
+
+// ExactWithMetadata stores a decimal score applying the function with the exact arithmetic of
+// dynamodb numbers, the returned update holds the stored decimal. Sums past the int64 range of
+// units are not done
+func (r *DynamoDBRepository) ExactWithMetadata(entry string, leaderboard string, value domain.ExactScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	builder := expression.NewBuilder()
+	number := expression.Value(attributevalue.Number(value.Value))
+	update := expression.Set(expression.Name(scoreAttrib), number).
+		Add(expression.Name("counter"), expression.Value(1))
+
+	var condBuilder *expression.ConditionBuilder
+	switch function {
+	case domain.Sum:
+		update = expression.Add(expression.Name(scoreAttrib), number).
+			Add(expression.Name("counter"), expression.Value(1))
+		// a sum that would leave the int64 range of units is not stored
+		lower, upper := value.SumBounds()
+		c := expression.Name(scoreAttrib).
+			Between(expression.Value(attributevalue.Number(lower)), expression.Value(attributevalue.Number(upper))).
+			Or(expression.Name(scoreAttrib).AttributeNotExists())
+		condBuilder = &c
+	case domain.Max:
+		c := expression.Name(scoreAttrib).
+			LessThanEqual(number).
+			Or(expression.Name(scoreAttrib).AttributeNotExists())
+		condBuilder = &c
+	case domain.Min:
+		c := expression.Name(scoreAttrib).
+			GreaterThanEqual(number).
+			Or(expression.Name(scoreAttrib).AttributeNotExists())
+		condBuilder = &c
+	case domain.Last:
+	default:
+		return domain.ScoreUpdate{}, fmt.Errorf("function %v is not supported for exact scores", function)
+	}
+
+	if meta != nil {
+		update = r.updateWithMetadata(meta, update)
+		cb := r.builderFromMetadata(meta)
+		if condBuilder != nil {
+			cb = condBuilder.And(cb)
+		}
+		condBuilder = &cb
+	}
+
+	builder = builder.WithUpdate(update)
+	if condBuilder != nil {
+		builder = builder.WithCondition(*condBuilder)
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to build update expression: %w", err)
+	}
+
+	input := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+		ConditionExpression:       expr.Condition(),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+		UpdateExpression: expr.Update(),
+	}
+
+	output, err := r.updateEntry(context.Background(), &input)
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return domain.ScoreUpdate{Done: false}, nil
+		}
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to update item: %w", err)
+	}
+	s := ExactEntryRecord{}
+	err = attributevalue.UnmarshalMap(output.Attributes, &s)
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to process output: %w", err)
+	}
+	score, err := s.Score.Float64()
+	if err != nil {
+		return domain.ScoreUpdate{}, fmt.Errorf("failed to parse score: %w", err)
+	}
+
+	return domain.ScoreUpdate{Score: score, ExactScore: s.Score.String(), Done: true, Counter: s.Counter}, nil
+}
+
+// GetExactScores returns the stored decimal scores of entries in a leaderboard epoch, entries
+// without a score are not returned
+func (r *DynamoDBRepository) GetExactScores(leaderboard string, entries []string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get exact scores timeout"))
+	defer cancel()
+
+	scores := make(map[string]string, len(entries))
+	err := r.batchGetEntries(ctx, leaderboard, entries, func(item map[string]types.AttributeValue) error {
+		s := ExactEntryRecord{}
+		err := attributevalue.UnmarshalMap(item, &s)
+		if err != nil {
+			return fmt.Errorf("failed to process output: %w", err)
+		}
+		scores[strings.TrimPrefix(s.PK, pkUserPrefix)] = s.Score.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// GetEntries returns the stored records of entries in a leaderboard epoch, entries without a
+// record are skipped
+func (r *DynamoDBRepository) GetEntries(leaderboard string, entries []string) (map[string]domain.StoredEntry, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get entries timeout"))
+	defer cancel()
+
+	stored := make(map[string]domain.StoredEntry, len(entries))
+	err := r.batchGetEntries(ctx, leaderboard, entries, func(item map[string]types.AttributeValue) error {
+		s := StoredEntryRecord{}
+		err := attributevalue.UnmarshalMap(item, &s)
+		if err != nil {
+			return fmt.Errorf("failed to process output: %w", err)
+		}
+		entry := domain.StoredEntry{
+			Score:      s.Score.String(),
+			Counter:    s.Counter,
+			Metadata:   domain.Metadata{},
+			Raw:        s.Raw,
+			ReportedAt: s.ReportedAt,
+			ImportID:   s.ImportID,
+			ImportLine: s.ImportLine,
+		}
+		for k, v := range item {
+			if sv, ok := v.(*types.AttributeValueMemberS); ok && strings.HasPrefix(k, metadataPrefix) {
+				entry.Metadata[strings.TrimPrefix(k, metadataPrefix)] = sv.Value
+			}
+		}
+		stored[strings.TrimPrefix(s.PK, pkUserPrefix)] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// PutEntries stores the records of entries in a leaderboard epoch replacing the stored ones, it
+// is meant for imports
+func (r *DynamoDBRepository) PutEntries(leaderboard string, entries map[string]domain.StoredEntry) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), migrateTimeout, errors.New("put entries timeout"))
+	defer cancel()
+
+	requests := make([]types.WriteRequest, 0, len(entries))
+	for entry, stored := range entries {
+		item, err := storedEntryItem(leaderboard, entry, stored)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	return r.batchWrite(ctx, requests)
+}
+
+// batchWrite writes the requests in batches of maxBatchWriteItems, retrying the unprocessed ones
+func (r *DynamoDBRepository) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		items := map[string][]types.WriteRequest{
+			r.tableName: requests[start:min(start+maxBatchWriteItems, len(requests))],
+		}
+		for attempt := 0; len(items) > 0; attempt++ {
+			err := waitUnprocessed(ctx, attempt)
+			if err != nil {
+				return err
+			}
+			output, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: items})
+			if err != nil {
+				return fmt.Errorf("failed to batch write items: %w", err)
+			}
+			items = output.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+// ReplaceEntries stores the records of entries in a leaderboard epoch in transactions of up to
+// maxBatchWriteItems records, a record is replaced only when its counter is still the counter in
+// counters, zero for entries without record. It returns the entries of the transactions that
+// were cancelled because a record changed, which were not stored
+func (r *DynamoDBRepository) ReplaceEntries(leaderboard string, entries map[string]domain.StoredEntry, counters map[string]uint64) ([]string, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), migrateTimeout, errors.New("replace entries timeout"))
+	defer cancel()
+
+	ids := make([]string, 0, len(entries))
+	for entry := range entries {
+		ids = append(ids, entry)
+	}
+	slices.Sort(ids)
+	changed := []string{}
+	for start := 0; start < len(ids); start += maxBatchWriteItems {
+		batch := ids[start:min(start+maxBatchWriteItems, len(ids))]
+		items := make([]types.TransactWriteItem, 0, len(batch))
+		for _, entry := range batch {
+			item, err := storedEntryItem(leaderboard, entry, entries[entry])
+			if err != nil {
+				return changed, err
+			}
+			cond := expression.AttributeNotExists(expression.Name(hashKeyName))
+			if counters[entry] > 0 {
+				cond = expression.Name("counter").Equal(expression.Value(counters[entry]))
+			}
+			expr, err := expression.NewBuilder().WithCondition(cond).Build()
+			if err != nil {
+				return changed, fmt.Errorf("failed to build condition expression: %w", err)
+			}
+			items = append(items, types.TransactWriteItem{Put: &types.Put{
+				TableName:                 aws.String(r.tableName),
+				Item:                      item,
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			}})
+		}
+		_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err != nil {
+			var tce *types.TransactionCanceledException
+			if errors.As(err, &tce) {
+				changed = append(changed, batch...)
+				continue
+			}
+			return changed, fmt.Errorf("failed to write items: %w", err)
+		}
+	}
+	return changed, nil
+}
+
+// storedEntryItem returns the item of the record of an entry in a leaderboard epoch
+func storedEntryItem(leaderboard string, entry string, stored domain.StoredEntry) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(StoredEntryRecord{
+		PK:         pkValue(entry),
+		SK:         skValue(leaderboard),
+		Score:      attributevalue.Number(stored.Score),
+		Counter:    stored.Counter,
+		Raw:        stored.Raw,
+		ReportedAt: stored.ReportedAt,
+		ImportID:   stored.ImportID,
+		ImportLine: stored.ImportLine,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record: %w", err)
+	}
+	for k, v := range stored.Metadata {
+		item[addMetadataPrefix(k)] = &types.AttributeValueMemberS{Value: v}
+	}
+	return item, nil
+}
+
+// DeleteEntry deletes the record of an entry in a leaderboard epoch, it returns false when there
+// was no record
+func (r *DynamoDBRepository) DeleteEntry(entry string, leaderboard string) (bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("delete entry timeout"))
+	defer cancel()
+
+	output, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete item: %w", err)
+	}
+	return len(output.Attributes) > 0, nil
+}
+
+// batchGetEntries reads the records of entries in a leaderboard epoch in batches
+func (r *DynamoDBRepository) batchGetEntries(ctx context.Context, leaderboard string, entries []string, fn func(item map[string]types.AttributeValue) error) error {
+	keys := make([]map[string]types.AttributeValue, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		})
+	}
+	return r.batchGetKeys(ctx, keys, fn)
+}
+
+// batchGetKeys reads the items of keys in batches
+func (r *DynamoDBRepository) batchGetKeys(ctx context.Context, keys []map[string]types.AttributeValue, fn func(item map[string]types.AttributeValue) error) error {
+	for start := 0; start < len(keys); start += maxBatchGetKeys {
+		requests := map[string]types.KeysAndAttributes{
+			r.tableName: {Keys: keys[start:min(start+maxBatchGetKeys, len(keys))], ConsistentRead: aws.Bool(true)},
+		}
+		for attempt := 0; len(requests) > 0; attempt++ {
+			err := waitUnprocessed(ctx, attempt)
+			if err != nil {
+				return err
+			}
+			output, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+			if err != nil {
+				return fmt.Errorf("failed to batch get items: %w", err)
+			}
+			for _, item := range output.Responses[r.tableName] {
+				err = fn(item)
+				if err != nil {
+					return err
+				}
+			}
+			requests = output.UnprocessedKeys
+		}
+	}
+	return nil
+}
+
+// waitUnprocessed waits before a resubmission of the unprocessed items of a batch, a random delay
+// up to a cap that doubles on every attempt so a throttled table is not hit in a tight loop. The
+// first attempt is not delayed
+func waitUnprocessed(ctx context.Context, attempt int) error {
+	if attempt == 0 {
+		return nil
+	}
+	limit := min(unprocessedBackoff<<min(attempt-1, 16), maxUnprocessedBackoff)
+	timer := time.NewTimer(rand.N(limit) + 1)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to retry unprocessed items: %w", context.Cause(ctx))
+	}
+}
+
 // MinWithMetadata ...
 func (r *DynamoDBRepository) MinWithMetadata(entry string, leaderboard string, value float64, meta domain.Metadata) (domain.ScoreUpdate, error) {
 	builder := expression.NewBuilder()
@@ -212,7 +816,7 @@ func (r *DynamoDBRepository) MinWithMetadata(entry string, leaderboard string, v
 		},
 		UpdateExpression: expr.Update(),
 	}
-	output, err := r.client.UpdateItem(context.Background(), &input)
+	output, err := r.updateEntry(context.Background(), &input)
 	if err != nil {
 		var ccfe *types.ConditionalCheckFailedException
 		if errors.As(err, &ccfe) {
@@ -310,7 +914,7 @@ func (r *DynamoDBRepository) LastWithMetadata(entry string, leaderboard string,
 		ConditionExpression: expr.Condition(),
 	}
 
-	output, err := r.client.UpdateItem(context.Background(), &input)
+	output, err := r.updateEntry(context.Background(), &input)
 	if err != nil {
 		return domain.ScoreUpdate{}, fmt.Errorf("failed to update item: %w", err)
 	}
@@ -372,7 +976,7 @@ func (r *DynamoDBRepository) ResetLock(leaderboard string, epoch int, duration t
 
 // Update configuration
 func (r *DynamoDBRepository) Update(name string, config domain.LeaderboardConfig) error {
-	ctx, cancel := context.WithTimeoutCause(context.Background(), 1*time.Second, errors.New("get configuration timeout"))
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 1*time.Second, errors.New("update configuration timeout"))
 	defer cancel()
 	skValue := fmt.Sprintf("%s%s", skConfigPrefix, name)
 
@@ -385,10 +989,9 @@ func (r *DynamoDBRepository) Update(name string, config domain.LeaderboardConfig
 		SK:     skValue,
 		Config: string(cfg),
 	}
-	fmt.Println("XXX", string(cfg), config)
 	item, err := attributevalue.MarshalMap(configItem)
 	if err != nil {
-		panic(fmt.Sprintf("failed to marshalMap: %v ", err))
+		return fmt.Errorf("failed to marshal configuration item: %v", err)
 	}
 	input := dynamodb.PutItemInput{
 		TableName:    aws.String(r.tableName),
@@ -403,26 +1006,1150 @@ func (r *DynamoDBRepository) Update(name string, config domain.LeaderboardConfig
 	return nil
 }
 
-// Add ...
-func (r *DynamoDBRepository) Add(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
-	return r.AddWithMetadata(entry, leaderboard, value, nil)
-}
+// TransitionLifecycle stores the lifecycle of a leaderboard configuration along with the audit of
+// the transition, it fails when the stored state is not the one the transition starts from
+func (r *DynamoDBRepository) TransitionLifecycle(name string, lifecycle domain.LeaderboardLifecycle, audit domain.LifecycleAudit) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("transition lifecycle timeout"))
+	defer cancel()
 
-// Min ...
-func (r *DynamoDBRepository) Min(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
-	return r.MinWithMetadata(entry, leaderboard, value, nil)
-}
+	it, config, err := r.getConfigItem(ctx, name)
+	if err != nil {
+		return err
+	}
+	if state := config.Lifecycle.StateAt(audit.At); state != audit.From {
+		return fmt.Errorf("lifecycle of %v changed concurrently: stored state is %v", name, state)
+	}
 
-// Max ...
-func (r *DynamoDBRepository) Max(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
-	return r.MaxWithMetadata(entry, leaderboard, value, nil)
-}
+	config.Lifecycle = lifecycle
+	cfg, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	configItem, err := attributevalue.MarshalMap(DDBConfigItem{PK: pkConfigPrefix, SK: it.SK, Config: string(cfg)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration item: %w", err)
+	}
+	auditItem, err := lifecycleAuditItem(audit)
+	if err != nil {
+		return err
+	}
+
+	// the configuration is only replaced if it was not changed since it was read
+	unchanged, err := expression.NewBuilder().WithCondition(
+		expression.Name(configAttrib).Equal(expression.Value(it.Config)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                 aws.String(r.tableName),
+				Item:                      configItem,
+				ConditionExpression:       unchanged.Condition(),
+				ExpressionAttributeNames:  unchanged.Names(),
+				ExpressionAttributeValues: unchanged.Values(),
+			}},
+			{Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      auditItem,
+			}},
+		},
+	})
+	if err != nil {
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return fmt.Errorf("lifecycle of %v changed concurrently: %w", name, err)
+		}
+		return fmt.Errorf("failed to write items: %w", err)
+	}
+	return nil
+}
+
+// CreateConfig stores a new leaderboard configuration along with the audit of its initial
+// lifecycle, it fails when the configuration is already stored
+func (r *DynamoDBRepository) CreateConfig(config domain.LeaderboardConfig, audit domain.LifecycleAudit) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("create configuration timeout"))
+	defer cancel()
+
+	cfg, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	configItem, err := attributevalue.MarshalMap(DDBConfigItem{PK: pkConfigPrefix, SK: skConfigPrefix + config.Name, Config: string(cfg)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration item: %w", err)
+	}
+	auditItem, err := lifecycleAuditItem(audit)
+	if err != nil {
+		return err
+	}
+	absent, err := expression.NewBuilder().WithCondition(
+		expression.AttributeNotExists(expression.Name(hashKeyName)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                 aws.String(r.tableName),
+				Item:                      configItem,
+				ConditionExpression:       absent.Condition(),
+				ExpressionAttributeNames:  absent.Names(),
+				ExpressionAttributeValues: absent.Values(),
+			}},
+			{Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      auditItem,
+			}},
+		},
+	})
+	if err != nil {
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			return fmt.Errorf("leaderboard config %v was created concurrently: %w", config.Name, err)
+		}
+		return fmt.Errorf("failed to write items: %w", err)
+	}
+	return nil
+}
+
+// ReplaceConfig stores a leaderboard configuration, it fails when the stored lifecycle is not the
+// one read or the configuration changes while it is replaced
+func (r *DynamoDBRepository) ReplaceConfig(config domain.LeaderboardConfig, read domain.LeaderboardLifecycle) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("replace configuration timeout"))
+	defer cancel()
+
+	it, stored, err := r.getConfigItem(ctx, config.Name)
+	if err != nil {
+		return err
+	}
+	before, err := json.Marshal(stored.Lifecycle)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lifecycle: %w", err)
+	}
+	after, err := json.Marshal(read)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lifecycle: %w", err)
+	}
+	if string(before) != string(after) {
+		return fmt.Errorf("lifecycle of %v changed concurrently: stored state is %v", config.Name, stored.Lifecycle.State)
+	}
+
+	cfg, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	item, err := attributevalue.MarshalMap(DDBConfigItem{PK: pkConfigPrefix, SK: it.SK, Config: string(cfg)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration item: %w", err)
+	}
+	// the configuration is only replaced if it was not changed since it was read
+	unchanged, err := expression.NewBuilder().WithCondition(
+		expression.Name(configAttrib).Equal(expression.Value(it.Config)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(r.tableName),
+		Item:                      item,
+		ConditionExpression:       unchanged.Condition(),
+		ExpressionAttributeNames:  unchanged.Names(),
+		ExpressionAttributeValues: unchanged.Values(),
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return fmt.Errorf("configuration of %v changed concurrently: %w", config.Name, err)
+		}
+		return fmt.Errorf("failed to put item: %w", err)
+	}
+	return nil
+}
+
+// getConfigItem reads the stored item of a leaderboard configuration and its configuration
+func (r *DynamoDBRepository) getConfigItem(ctx context.Context, name string) (DDBConfigItem, domain.LeaderboardConfig, error) {
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkConfigPrefix},
+			sortKeyName: &types.AttributeValueMemberS{Value: skConfigPrefix + name},
+		},
+	})
+	if err != nil {
+		return DDBConfigItem{}, domain.LeaderboardConfig{}, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return DDBConfigItem{}, domain.LeaderboardConfig{}, fmt.Errorf("leaderboard config not found: %v", name)
+	}
+	var it DDBConfigItem
+	err = attributevalue.UnmarshalMap(output.Item, &it)
+	if err != nil {
+		return DDBConfigItem{}, domain.LeaderboardConfig{}, fmt.Errorf("failed to process output: %w", err)
+	}
+	var config domain.LeaderboardConfig
+	err = json.Unmarshal([]byte(it.Config), &config)
+	if err != nil {
+		return DDBConfigItem{}, domain.LeaderboardConfig{}, fmt.Errorf("failed to parse Json config for '%v': %w", name, err)
+	}
+	return it, config, nil
+}
+
+// lifecycleAuditItem returns the item of the audit of a lifecycle transition
+func lifecycleAuditItem(audit domain.LifecycleAudit) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(LifecycleAuditRecord{
+		PK:         pkAuditPrefix + audit.Name,
+		SK:         audit.At.UTC().Format(auditTimeLayout),
+		Name:       audit.Name,
+		From:       string(audit.From),
+		To:         string(audit.To),
+		ActivateAt: audit.ActivateAt,
+		Actor:      audit.Actor,
+		Reason:     audit.Reason,
+		At:         audit.At,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit item: %w", err)
+	}
+	return item, nil
+}
+
+// GetLifecycleAudit returns the lifecycle transitions of a leaderboard from the oldest
+func (r *DynamoDBRepository) GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get lifecycle audit timeout"))
+	defer cancel()
+
+	expr, err := expression.NewBuilder().WithKeyCondition(
+		expression.Key(hashKeyName).Equal(expression.Value(pkAuditPrefix + name)),
+	).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+	input := dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	audit := []domain.LifecycleAudit{}
+	for {
+		output, err := r.client.Query(ctx, &input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query database: %w", err)
+		}
+		for _, item := range output.Items {
+			var rec LifecycleAuditRecord
+			err = attributevalue.UnmarshalMap(item, &rec)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process output: %w", err)
+			}
+			audit = append(audit, domain.LifecycleAudit{
+				Name:       rec.Name,
+				From:       domain.LeaderboardState(rec.From),
+				To:         domain.LeaderboardState(rec.To),
+				ActivateAt: rec.ActivateAt,
+				Actor:      rec.Actor,
+				Reason:     rec.Reason,
+				At:         rec.At,
+			})
+		}
+		if output.LastEvaluatedKey == nil {
+			return audit, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// CreatePrizeDelivery stores the delivery of an award batch unless the epoch already has one, it
+// returns false when the delivery exists
+func (r *DynamoDBRepository) CreatePrizeDelivery(delivery domain.PrizeDelivery) (bool, error) {
+	cond := expression.AttributeNotExists(expression.Name(hashKeyName))
+	return r.putPrizeDelivery(delivery, cond)
+}
+
+// UpdatePrizeDelivery replaces the delivery of an award batch when its stored attempts are the
+// given ones, it returns false when the delivery was changed concurrently
+func (r *DynamoDBRepository) UpdatePrizeDelivery(delivery domain.PrizeDelivery, attempts int) (bool, error) {
+	cond := expression.Name(attemptsAttrib).Equal(expression.Value(attempts))
+	return r.putPrizeDelivery(delivery, cond)
+}
+
+func (r *DynamoDBRepository) putPrizeDelivery(delivery domain.PrizeDelivery, cond expression.ConditionBuilder) (bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("put prize delivery timeout"))
+	defer cancel()
+
+	item, err := prizeDeliveryItem(pkPrizesPrefix, delivery)
+	if err != nil {
+		return false, err
+	}
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	items := []types.TransactWriteItem{
+		{Put: &types.Put{
+			TableName:                 aws.String(r.tableName),
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}},
+	}
+	// the copy keyed by state moves with the delivery
+	for _, state := range indexedDeliveryStates {
+		if state != delivery.State {
+			items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					hashKeyName: &types.AttributeValueMemberS{Value: pkPrizeStatePrefix + string(state)},
+					sortKeyName: item[sortKeyName],
+				},
+			}})
+			continue
+		}
+		indexed, err := prizeDeliveryItem(pkPrizeStatePrefix+string(state), delivery)
+		if err != nil {
+			return false, err
+		}
+		items = append(items, types.TransactWriteItem{Put: &types.Put{
+			TableName: aws.String(r.tableName),
+			Item:      indexed,
+		}})
+	}
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) && len(tce.CancellationReasons) > 0 && aws.ToString(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to write items: %w", err)
+	}
+	return true, nil
+}
+
+// indexedDeliveryStates are the states of the deliveries copied to a partition of their state
+var indexedDeliveryStates = []domain.DeliveryState{domain.DeliveryPending, domain.DeliveryDead}
+
+func prizeDeliveryItem(pk string, delivery domain.PrizeDelivery) (map[string]types.AttributeValue, error) {
+	batch, err := json.Marshal(delivery.Batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal award batch: %w", err)
+	}
+	item, err := attributevalue.MarshalMap(PrizeDeliveryRecord{
+		PK:          pk,
+		SK:          skValue(getNameWithEpoch(delivery.Batch.Leaderboard, delivery.Batch.Epoch)),
+		State:       string(delivery.State),
+		Attempts:    delivery.Attempts,
+		NextAttempt: delivery.NextAttempt,
+		LastError:   delivery.LastError,
+		UpdatedAt:   delivery.UpdatedAt,
+		Batch:       string(batch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal prize delivery: %w", err)
+	}
+	return item, nil
+}
+
+// GetPrizeDelivery returns the delivery of the award batch of a leaderboard epoch, it returns
+// false when the epoch does not have one
+func (r *DynamoDBRepository) GetPrizeDelivery(leaderboard string, epoch int64) (domain.PrizeDelivery, bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get prize delivery timeout"))
+	defer cancel()
+
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkPrizesPrefix},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(getNameWithEpoch(leaderboard, epoch))},
+		},
+	})
+	if err != nil {
+		return domain.PrizeDelivery{}, false, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return domain.PrizeDelivery{}, false, nil
+	}
+	delivery, err := prizeDeliveryFromItem(output.Item)
+	if err != nil {
+		return domain.PrizeDelivery{}, false, err
+	}
+	return delivery, true, nil
+}
+
+// GetPrizeDeliveries returns the deliveries of award batches in the pending or dead state from
+// the partition of the state
+func (r *DynamoDBRepository) GetPrizeDeliveries(state domain.DeliveryState) ([]domain.PrizeDelivery, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get prize deliveries timeout"))
+	defer cancel()
+
+	if !slices.Contains(indexedDeliveryStates, state) {
+		return nil, fmt.Errorf("prize deliveries in state %v are not indexed", state)
+	}
+	expr, err := expression.NewBuilder().WithKeyCondition(
+		expression.Key(hashKeyName).Equal(expression.Value(pkPrizeStatePrefix + string(state))),
+	).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+	input := dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		ConsistentRead:            aws.Bool(true),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	deliveries := []domain.PrizeDelivery{}
+	for {
+		output, err := r.client.Query(ctx, &input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query database: %w", err)
+		}
+		for _, item := range output.Items {
+			delivery, err := prizeDeliveryFromItem(item)
+			if err != nil {
+				return nil, err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		if output.LastEvaluatedKey == nil {
+			return deliveries, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+func prizeDeliveryFromItem(item map[string]types.AttributeValue) (domain.PrizeDelivery, error) {
+	var rec PrizeDeliveryRecord
+	err := attributevalue.UnmarshalMap(item, &rec)
+	if err != nil {
+		return domain.PrizeDelivery{}, fmt.Errorf("failed to process output: %w", err)
+	}
+	delivery := domain.PrizeDelivery{
+		State:       domain.DeliveryState(rec.State),
+		Attempts:    rec.Attempts,
+		NextAttempt: rec.NextAttempt,
+		LastError:   rec.LastError,
+		UpdatedAt:   rec.UpdatedAt,
+	}
+	err = json.Unmarshal([]byte(rec.Batch), &delivery.Batch)
+	if err != nil {
+		return domain.PrizeDelivery{}, fmt.Errorf("failed to parse award batch of '%v': %w", rec.SK, err)
+	}
+	return delivery, nil
+}
+
+// GetClosedEpoch returns the last epoch of a leaderboard whose prizes were awarded, it returns
+// false when no epoch was awarded
+func (r *DynamoDBRepository) GetClosedEpoch(leaderboard string) (int64, bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get closed epoch timeout"))
+	defer cancel()
+	return r.getEpoch(ctx, closedEpochKey(leaderboard))
+}
+
+// getEpoch reads the epoch of an epoch record, it returns false when there is no record
+func (r *DynamoDBRepository) getEpoch(ctx context.Context, key map[string]types.AttributeValue) (int64, bool, error) {
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key:            key,
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return 0, false, nil
+	}
+	e := EpochRecord{}
+	err = attributevalue.UnmarshalMap(output.Item, &e)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to process output: %w", err)
+	}
+	return e.Epoch, true, nil
+}
+
+// SetClosedEpoch records the last epoch of a leaderboard whose prizes were awarded, an older epoch
+// does not replace a newer one
+func (r *DynamoDBRepository) SetClosedEpoch(leaderboard string, epoch int64) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("set closed epoch timeout"))
+	defer cancel()
+	return r.raiseEpoch(ctx, closedEpochKey(leaderboard), epoch)
+}
+
+// closedEpochKey returns the key of the last epoch of a leaderboard whose prizes were awarded
+func closedEpochKey(leaderboard string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		hashKeyName: &types.AttributeValueMemberS{Value: pkPrizesClosed},
+		sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+	}
+}
+
+// raiseEpoch stores the epoch of an epoch record unless it holds a newer one
+func (r *DynamoDBRepository) raiseEpoch(ctx context.Context, key map[string]types.AttributeValue, epoch int64) error {
+	name := expression.Name(epochAttrib)
+	expr, err := expression.NewBuilder().WithUpdate(
+		expression.Set(name, expression.Value(epoch)),
+	).WithCondition(
+		expression.Or(expression.AttributeNotExists(name), name.LessThan(expression.Value(epoch))),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build update expression: %w", err)
+	}
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		Key:                       key,
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return nil
+		}
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+	return nil
+}
+
+// CreateEntryPrizes stores the awards of a batch as prizes of the entries, prizes that already
+// exist are kept so claims are not reset
+func (r *DynamoDBRepository) CreateEntryPrizes(batch domain.AwardBatch) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), migrateTimeout, errors.New("create entry prizes timeout"))
+	defer cancel()
+
+	expr, err := expression.NewBuilder().WithCondition(
+		expression.AttributeNotExists(expression.Name(hashKeyName)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	for _, award := range batch.Awards {
+		item, err := attributevalue.MarshalMap(EntryPrizeRecord{
+			PK:          pkValue(award.EntryID),
+			SK:          skPrizePrefix + getNameWithEpoch(batch.Leaderboard, batch.Epoch),
+			Leaderboard: batch.Leaderboard,
+			Epoch:       batch.Epoch,
+			Rank:        award.Rank,
+			Score:       award.Score,
+			ExactScore:  award.ExactScore,
+			Action:      award.Action,
+			AwardedAt:   batch.ClosedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal entry prize: %w", err)
+		}
+		_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 aws.String(r.tableName),
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		})
+		if err != nil {
+			var ccfe *types.ConditionalCheckFailedException
+			if errors.As(err, &ccfe) {
+				continue
+			}
+			return fmt.Errorf("failed to put item: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUnclaimedPrizes returns the prizes of an entry that were not claimed
+func (r *DynamoDBRepository) GetUnclaimedPrizes(entry string) ([]domain.EntryPrize, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get unclaimed prizes timeout"))
+	defer cancel()
+
+	expr, err := expression.NewBuilder().WithKeyCondition(
+		expression.Key(hashKeyName).Equal(expression.Value(pkValue(entry))).
+			And(expression.Key(sortKeyName).BeginsWith(skPrizePrefix)),
+	).WithFilter(
+		expression.Name(claimedAttrib).AttributeNotExists(),
+	).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression: %w", err)
+	}
+	input := dynamodb.QueryInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	prizes := []domain.EntryPrize{}
+	for {
+		output, err := r.client.Query(ctx, &input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query database: %w", err)
+		}
+		for _, item := range output.Items {
+			prize, err := entryPrizeFromItem(entry, item)
+			if err != nil {
+				return nil, err
+			}
+			prizes = append(prizes, prize)
+		}
+		if output.LastEvaluatedKey == nil {
+			return prizes, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// GetEntryPrize returns the prize of an entry in a leaderboard epoch, it returns false when the
+// entry does not have one
+func (r *DynamoDBRepository) GetEntryPrize(entry string, leaderboard string, epoch int64) (domain.EntryPrize, bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get entry prize timeout"))
+	defer cancel()
+
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key:            entryPrizeKey(entry, leaderboard, epoch),
+	})
+	if err != nil {
+		return domain.EntryPrize{}, false, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return domain.EntryPrize{}, false, nil
+	}
+	prize, err := entryPrizeFromItem(entry, output.Item)
+	if err != nil {
+		return domain.EntryPrize{}, false, err
+	}
+	return prize, true, nil
+}
+
+// ClaimPrize marks the prize of an entry in a leaderboard epoch as claimed, it returns false
+// when the prize does not exist or was already claimed
+func (r *DynamoDBRepository) ClaimPrize(entry string, leaderboard string, epoch int64, claimID string, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("claim prize timeout"))
+	defer cancel()
+
+	claimedAt, err := attributevalue.Marshal(at)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal claim time: %w", err)
+	}
+	expr, err := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name(claimedAttrib), expression.Value(claimedAt)).
+			Set(expression.Name(claimIDAttrib), expression.Value(claimID)),
+	).WithCondition(
+		expression.AttributeExists(expression.Name(hashKeyName)).
+			And(expression.AttributeNotExists(expression.Name(claimedAttrib))),
+	).Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build update expression: %w", err)
+	}
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       entryPrizeKey(entry, leaderboard, epoch),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to update item: %w", err)
+	}
+	return true, nil
+}
+
+func entryPrizeKey(entry string, leaderboard string, epoch int64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+		sortKeyName: &types.AttributeValueMemberS{Value: skPrizePrefix + getNameWithEpoch(leaderboard, epoch)},
+	}
+}
+
+func entryPrizeFromItem(entry string, item map[string]types.AttributeValue) (domain.EntryPrize, error) {
+	var rec EntryPrizeRecord
+	err := attributevalue.UnmarshalMap(item, &rec)
+	if err != nil {
+		return domain.EntryPrize{}, fmt.Errorf("failed to process output: %w", err)
+	}
+	return domain.EntryPrize{
+		PrizeAward: domain.PrizeAward{
+			EntryID:    entry,
+			Rank:       rec.Rank,
+			Score:      rec.Score,
+			ExactScore: rec.ExactScore,
+			Action:     rec.Action,
+			Epoch:      rec.Epoch,
+		},
+		Leaderboard: rec.Leaderboard,
+		AwardedAt:   rec.AwardedAt,
+		ClaimedAt:   rec.ClaimedAt,
+		ClaimID:     rec.ClaimID,
+	}, nil
+}
+
+// Add ...
+func (r *DynamoDBRepository) Add(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
+	return r.AddWithMetadata(entry, leaderboard, value, nil)
+}
+
+// Min ...
+func (r *DynamoDBRepository) Min(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
+	return r.MinWithMetadata(entry, leaderboard, value, nil)
+}
+
+// Max ...
+func (r *DynamoDBRepository) Max(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
+	return r.MaxWithMetadata(entry, leaderboard, value, nil)
+}
 
 // Last ...
 func (r *DynamoDBRepository) Last(entry string, leaderboard string, value float64) (domain.ScoreUpdate, error) {
 	return r.LastWithMetadata(entry, leaderboard, value, nil)
 }
 
+// GetFriends returns the stored friend list of an entry
+func (r *DynamoDBRepository) GetFriends(entry string) ([]string, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get friends timeout"))
+	defer cancel()
+
+	input := dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skFriends},
+		},
+	}
+
+	output, err := r.client.GetItem(ctx, &input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	var record FriendsRecord
+	err = attributevalue.UnmarshalMap(output.Item, &record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process output: %w", err)
+	}
+	return record.Friends, nil
+}
+
+// SetFriends replaces the stored friend list of an entry
+func (r *DynamoDBRepository) SetFriends(entry string, friends []string) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("set friends timeout"))
+	defer cancel()
+
+	item, err := attributevalue.MarshalMap(FriendsRecord{
+		PK:      pkValue(entry),
+		SK:      skFriends,
+		Friends: friends,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal friends: %w", err)
+	}
+
+	input := dynamodb.PutItemInput{
+		TableName:    aws.String(r.tableName),
+		Item:         item,
+		ReturnValues: types.ReturnValueNone,
+	}
+	_, err = r.client.PutItem(ctx, &input)
+	if err != nil {
+		return fmt.Errorf("failed to put item: %w", err)
+	}
+	return nil
+}
+
+// IncrementSubmissions increments the total of accepted submissions of a leaderboard epoch in one
+// of its counter shards
+func (r *DynamoDBRepository) IncrementSubmissions(leaderboard string) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("increment submissions timeout"))
+	defer cancel()
+
+	expr, err := expression.NewBuilder().WithUpdate(
+		expression.Add(expression.Name(counterAttrib), expression.Value(1)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build update expression: %w", err)
+	}
+	input := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueNone,
+		Key:                       submissionsKey(leaderboard, rand.IntN(submissionShards)),
+		UpdateExpression:          expr.Update(),
+	}
+	_, err = r.client.UpdateItem(ctx, &input)
+	if err != nil {
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+	return nil
+}
+
+// GetSubmissions returns the total of accepted submissions of a leaderboard epoch, the sum of its
+// counter shards and of the counter written before the counter was sharded
+func (r *DynamoDBRepository) GetSubmissions(leaderboard string) (uint64, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get submissions timeout"))
+	defer cancel()
+
+	keys := []map[string]types.AttributeValue{submissionsKey(leaderboard, -1)}
+	for shard := 0; shard < submissionShards; shard++ {
+		keys = append(keys, submissionsKey(leaderboard, shard))
+	}
+	requests := map[string]types.KeysAndAttributes{
+		r.tableName: {Keys: keys},
+	}
+	total := uint64(0)
+	for attempt := 0; len(requests) > 0; attempt++ {
+		err := waitUnprocessed(ctx, attempt)
+		if err != nil {
+			return 0, err
+		}
+		output, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+		if err != nil {
+			return 0, fmt.Errorf("failed to batch get items: %w", err)
+		}
+		for _, item := range output.Responses[r.tableName] {
+			s := LeaderboardEntryRecord{}
+			err = attributevalue.UnmarshalMap(item, &s)
+			if err != nil {
+				return 0, fmt.Errorf("failed to process output: %w", err)
+			}
+			total += s.Counter
+		}
+		requests = output.UnprocessedKeys
+	}
+	return total, nil
+}
+
+// submissionsKey returns the key of a counter shard of the submissions of a leaderboard epoch,
+// a negative shard is the counter written before the counter was sharded
+func submissionsKey(leaderboard string, shard int) map[string]types.AttributeValue {
+	pk := pkStatsPrefix
+	if shard >= 0 {
+		pk = fmt.Sprintf("%s#%d", pkStatsPrefix, shard)
+	}
+	return map[string]types.AttributeValue{
+		hashKeyName: &types.AttributeValueMemberS{Value: pk},
+		sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+	}
+}
+
+// GetEpoch returns the current epoch of a manually reset leaderboard, starting at 1
+func (r *DynamoDBRepository) GetEpoch(leaderboard string) (int64, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get epoch timeout"))
+	defer cancel()
+
+	input := dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkEpochPrefix},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+	}
+	output, err := r.client.GetItem(ctx, &input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get item: %w", err)
+	}
+	if output.Item == nil {
+		return 1, nil
+	}
+
+	e := EpochRecord{}
+	err = attributevalue.UnmarshalMap(output.Item, &e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to process output: %w", err)
+	}
+	return e.Epoch, nil
+}
+
+// AdvanceEpoch atomically increments the current epoch of a manually reset leaderboard
+func (r *DynamoDBRepository) AdvanceEpoch(leaderboard string) (int64, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("advance epoch timeout"))
+	defer cancel()
+
+	name := expression.Name(epochAttrib)
+	expr, err := expression.NewBuilder().WithUpdate(
+		expression.Set(name, expression.Plus(name.IfNotExists(expression.Value(1)), expression.Value(1))),
+	).Build()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build update expression: %w", err)
+	}
+	input := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkEpochPrefix},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(leaderboard)},
+		},
+		UpdateExpression: expr.Update(),
+	}
+	output, err := r.client.UpdateItem(ctx, &input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update item: %w", err)
+	}
+
+	e := EpochRecord{}
+	err = attributevalue.UnmarshalMap(output.Attributes, &e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to process output: %w", err)
+	}
+	return e.Epoch, nil
+}
+
+// MigrateEntries moves the records of entries and the submission counters of a leaderboard epoch
+// to another, records that already exist in the destination are kept in the origin and reported
+// as conflicts
+func (r *DynamoDBRepository) MigrateEntries(from string, to string, entries []string) (int, int, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), migrateTimeout, errors.New("migrate entries timeout"))
+	defer cancel()
+
+	keys := make([]map[string]types.AttributeValue, 0, len(entries)+submissionShards+1)
+	for _, entry := range entries {
+		keys = append(keys, map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkValue(entry)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skValue(from)},
+		})
+	}
+	keys = append(keys, submissionsKey(from, -1))
+	for shard := 0; shard < submissionShards; shard++ {
+		keys = append(keys, submissionsKey(from, shard))
+	}
+
+	moved, conflicts := 0, 0
+	err := r.batchGetKeys(ctx, keys, func(item map[string]types.AttributeValue) error {
+		ok, err := r.moveItem(ctx, item, skValue(to))
+		if err != nil {
+			return err
+		}
+		if ok {
+			moved++
+		} else {
+			conflicts++
+		}
+		return nil
+	})
+	return moved, conflicts, err
+}
+
+// moveItem copies an item to a new sort key when it does not exist and deletes the original
+func (r *DynamoDBRepository) moveItem(ctx context.Context, item map[string]types.AttributeValue, sk string) (bool, error) {
+	key := map[string]types.AttributeValue{
+		hashKeyName: item[hashKeyName],
+		sortKeyName: item[sortKeyName],
+	}
+	moved := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		moved[k] = v
+	}
+	moved[sortKeyName] = &types.AttributeValueMemberS{Value: sk}
+
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeNotExists(expression.Name(hashKeyName))).Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(r.tableName),
+		Item:                     moved,
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	})
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to put item: %w", err)
+	}
+
+	_, err = r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       key,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete item: %w", err)
+	}
+	return true, nil
+}
+
+// SaveArchive implements the ArchiveStore interface storing the standings of every scoreboard in
+// parts of up to archivePartEntries entries keyed by the scoreboard, the header is stored last so
+// a partial archive is never read and the last archived epoch is recorded after it
+func (r *DynamoDBRepository) SaveArchive(archive domain.EpochArchive) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), migrateTimeout, errors.New("save archive timeout"))
+	defer cancel()
+
+	pk := pkArchivePrefix + getNameWithEpoch(archive.Leaderboard, archive.Epoch)
+	parts := 0
+	for _, sb := range archive.Scoreboards {
+		for start := 0; start == 0 || start < len(sb.Scores); start += archivePartEntries {
+			scores, err := json.Marshal(sb.Scores[start:min(start+archivePartEntries, len(sb.Scores))])
+			if err != nil {
+				return fmt.Errorf("failed to marshal scores: %w", err)
+			}
+			err = r.putArchiveRecord(ctx, ArchiveRecord{
+				PK:         pk,
+				SK:         archivePartKey(sb.Name, start/archivePartEntries),
+				Scoreboard: sb.Name,
+				Scores:     string(scores),
+				ArchivedAt: archive.ArchivedAt,
+			})
+			if err != nil {
+				return err
+			}
+			parts++
+		}
+	}
+	err := r.putArchiveRecord(ctx, ArchiveRecord{PK: pk, SK: skArchiveHeader, Parts: parts, ArchivedAt: archive.ArchivedAt})
+	if err != nil {
+		return err
+	}
+	return r.raiseEpoch(ctx, archivedEpochKey(archive.Leaderboard), archive.Epoch)
+}
+
+// archivePartKey returns the sort key of a part of the standings of an archived scoreboard
+func archivePartKey(scoreboard string, part int) string {
+	return fmt.Sprintf("%s%s#%06d", skArchivePart, scoreboard, part)
+}
+
+func (r *DynamoDBRepository) putArchiveRecord(ctx context.Context, rec ArchiveRecord) error {
+	item, err := attributevalue.MarshalMap(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive record: %w", err)
+	}
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put item: %w", err)
+	}
+	return nil
+}
+
+// GetArchive implements the ArchiveStore interface reading in a batch the header and only the
+// parts of the scoreboards that hold their top n entries, it returns false when the epoch was
+// not archived
+func (r *DynamoDBRepository) GetArchive(name string, epoch int64, scoreboards []string, n int64) (domain.EpochArchive, bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get archive timeout"))
+	defer cancel()
+
+	pk := pkArchivePrefix + getNameWithEpoch(name, epoch)
+	key := func(sk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pk},
+			sortKeyName: &types.AttributeValueMemberS{Value: sk},
+		}
+	}
+	parts := max(int((n+archivePartEntries-1)/archivePartEntries), 1)
+	keys := []map[string]types.AttributeValue{key(skArchiveHeader)}
+	for _, sb := range scoreboards {
+		for part := 0; part < parts; part++ {
+			keys = append(keys, key(archivePartKey(sb, part)))
+		}
+	}
+	records := make(map[string]ArchiveRecord, len(keys))
+	err := r.batchGetKeys(ctx, keys, func(item map[string]types.AttributeValue) error {
+		var rec ArchiveRecord
+		err := attributevalue.UnmarshalMap(item, &rec)
+		if err != nil {
+			return fmt.Errorf("failed to process output: %w", err)
+		}
+		records[rec.SK] = rec
+		return nil
+	})
+	if err != nil {
+		return domain.EpochArchive{}, false, err
+	}
+	header, ok := records[skArchiveHeader]
+	if !ok {
+		return domain.EpochArchive{}, false, nil
+	}
+
+	archive := domain.EpochArchive{Leaderboard: name, Epoch: epoch, ArchivedAt: header.ArchivedAt, Scoreboards: []domain.LeaderboardScores{}}
+	for _, sb := range scoreboards {
+		scores := []domain.LeaderboardEntry{}
+		found := false
+		for part := 0; part < parts; part++ {
+			rec, ok := records[archivePartKey(sb, part)]
+			if !ok {
+				break
+			}
+			var entries []domain.LeaderboardEntry
+			err = json.Unmarshal([]byte(rec.Scores), &entries)
+			if err != nil {
+				return domain.EpochArchive{}, false, fmt.Errorf("failed to parse archive part '%v': %w", rec.SK, err)
+			}
+			scores = append(scores, entries...)
+			found = true
+		}
+		if !found {
+			continue
+		}
+		if int64(len(scores)) > n {
+			scores = scores[:n]
+		}
+		archive.Scoreboards = append(archive.Scoreboards, domain.LeaderboardScores{Name: sb, Scores: scores})
+	}
+	return archive, true, nil
+}
+
+// GetArchivedEpoch implements the ArchiveStore interface
+func (r *DynamoDBRepository) GetArchivedEpoch(name string) (int64, bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get archived epoch timeout"))
+	defer cancel()
+	return r.getEpoch(ctx, archivedEpochKey(name))
+}
+
+// archivedEpochKey returns the key of the last archived epoch of a leaderboard
+func archivedEpochKey(name string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		hashKeyName: &types.AttributeValueMemberS{Value: pkArchivedEpoch},
+		sortKeyName: &types.AttributeValueMemberS{Value: skValue(strings.ToLower(name))},
+	}
+}
+
+// HasArchive implements the ArchiveStore interface reading only the header of the archive, which
+// is written once all the parts are stored
+func (r *DynamoDBRepository) HasArchive(name string, epoch int64) (bool, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("has archive timeout"))
+	defer cancel()
+
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(r.tableName),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String(hashKeyName),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkArchivePrefix + getNameWithEpoch(name, epoch)},
+			sortKeyName: &types.AttributeValueMemberS{Value: skArchiveHeader},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get item: %w", err)
+	}
+	return len(output.Item) > 0, nil
+}
+
+func getNameWithEpoch(name string, epoch int64) string {
+	return strings.ToLower(fmt.Sprintf("%s::%d", name, epoch))
+}
+
 func pkValue(value string) string {
 	return fmt.Sprintf("%s%s", pkUserPrefix, value)
 }
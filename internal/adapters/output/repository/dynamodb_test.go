@@ -2,11 +2,16 @@ package repository_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/posilva/simpleboards/internal/adapters/output/repository"
+	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/testutil"
 	testmocks "github.com/posilva/simpleboards/internal/testutil/mocks"
 	"github.com/stretchr/testify/assert"
@@ -137,3 +142,704 @@ func TestDynamoDBRepository_Last(t *testing.T) {
 
 	assert.Equal(t, uint64(score2), uint64(v1.Score))
 }
+
+func TestDynamoDBRepository_Exact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	attributes := make(map[string]types.AttributeValue)
+	attributes["score"] = &types.AttributeValueMemberN{Value: "9223372036854775806"}
+
+	client.EXPECT().UpdateItem(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Contains(t, *input.UpdateExpression, "ADD")
+			// the total is kept in the int64 range of units
+			assert.Contains(t, *input.ConditionExpression, "BETWEEN")
+			bounds := []string{}
+			for _, v := range input.ExpressionAttributeValues {
+				if n, ok := v.(*types.AttributeValueMemberN); ok {
+					bounds = append(bounds, n.Value)
+				}
+			}
+			assert.Contains(t, bounds, "9223372036854775806")
+			assert.Contains(t, bounds, "-9223372036854775809")
+			return &dynamodb.UpdateItemOutput{Attributes: attributes}, nil
+		})
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	entry := testutil.NewID()
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+
+	v, err := r.ExactWithMetadata(entry, leaderboard, domain.ExactScore{Value: "1", Score: 1}, domain.Sum, nil)
+	assert.NoError(t, err)
+	assert.True(t, v.Done)
+	assert.Equal(t, "9223372036854775806", v.ExactScore)
+
+	client.EXPECT().UpdateItem(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Contains(t, *input.ConditionExpression, "BETWEEN")
+			assert.Contains(t, input.ExpressionAttributeValues, ":1")
+			return nil, &types.ConditionalCheckFailedException{}
+		})
+	v, err = r.ExactWithMetadata(entry, leaderboard, domain.ExactScore{Value: "9223372036854775807", Score: 9223372036854775807}, domain.Sum, nil)
+	assert.NoError(t, err)
+	assert.False(t, v.Done)
+
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{
+			settings.Table: {{
+				"pk":    &types.AttributeValueMemberS{Value: "USR#" + entry},
+				"sk":    &types.AttributeValueMemberS{Value: "LBRD#" + leaderboard},
+				"score": &types.AttributeValueMemberN{Value: "9223372036854775806"},
+			}},
+		},
+	}, nil)
+	scores, err := r.GetExactScores(leaderboard, []string{entry, testutil.NewID()})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{entry: "9223372036854775806"}, scores)
+}
+
+func TestDynamoDBRepository_GetEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+	first, second := testutil.NewID(), testutil.NewID()
+	record := func(entry string, score string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"pk":          &types.AttributeValueMemberS{Value: "USR#" + entry},
+			"sk":          &types.AttributeValueMemberS{Value: "LBRD#" + leaderboard},
+			"score":       &types.AttributeValueMemberN{Value: score},
+			"counter":     &types.AttributeValueMemberN{Value: "3"},
+			"md::country": &types.AttributeValueMemberS{Value: "pt"},
+			"import_id":   &types.AttributeValueMemberS{Value: "import"},
+			"import_line": &types.AttributeValueMemberN{Value: "7"},
+		}
+	}
+	unprocessed := map[string]types.KeysAndAttributes{settings.Table: {}}
+
+	// unprocessed keys are requested again
+	gomock.InOrder(
+		client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchGetItemOutput{
+			Responses:       map[string][]map[string]types.AttributeValue{settings.Table: {record(first, "10")}},
+			UnprocessedKeys: unprocessed,
+		}, nil),
+		client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				assert.Equal(t, unprocessed, input.RequestItems)
+				return &dynamodb.BatchGetItemOutput{
+					Responses: map[string][]map[string]types.AttributeValue{settings.Table: {record(second, "2.5")}},
+				}, nil
+			}),
+	)
+	entries, err := r.GetEntries(leaderboard, []string{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]domain.StoredEntry{
+		first:  {Score: "10", Counter: 3, Metadata: domain.Metadata{"country": "pt"}, ImportID: "import", ImportLine: 7},
+		second: {Score: "2.5", Counter: 3, Metadata: domain.Metadata{"country": "pt"}, ImportID: "import", ImportLine: 7},
+	}, entries)
+}
+
+func TestDynamoDBRepository_GetEntriesThrottled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	// keys that stay unprocessed are requested again after a growing delay until the timeout
+	unprocessed := map[string]types.KeysAndAttributes{settings.Table: {}}
+	calls := 0
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			calls++
+			return &dynamodb.BatchGetItemOutput{UnprocessedKeys: unprocessed}, nil
+		}).MinTimes(2)
+	_, err = r.GetEntries(testutil.NewUnique(testutil.Name(t)), []string{testutil.NewID()})
+	assert.ErrorContains(t, err, "get entries timeout")
+	assert.Less(t, calls, 50)
+}
+
+func TestDynamoDBRepository_PutEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+	entries := map[string]domain.StoredEntry{}
+	for i := 0; i < 30; i++ {
+		entries[testutil.NewID()] = domain.StoredEntry{Score: "10", Counter: 1, Metadata: domain.Metadata{"country": "pt"}}
+	}
+	unprocessed := map[string][]types.WriteRequest{settings.Table: {{}}}
+
+	// the items are written in batches of 25 and unprocessed items are written again
+	var written int
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems[settings.Table]
+			assert.LessOrEqual(t, len(requests), 25)
+			if written == 0 {
+				written += len(requests)
+				return &dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, nil
+			}
+			if len(requests) == 1 && requests[0].PutRequest == nil {
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			}
+			item := requests[0].PutRequest.Item
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#" + leaderboard}, item["sk"])
+			assert.Equal(t, &types.AttributeValueMemberN{Value: "10"}, item["score"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "pt"}, item["md::country"])
+			written += len(requests)
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		}).Times(3)
+	assert.NoError(t, r.PutEntries(leaderboard, entries))
+	assert.Equal(t, 30, written)
+}
+
+func TestDynamoDBRepository_ReplaceEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+	entries := map[string]domain.StoredEntry{}
+	counters := map[string]uint64{}
+	for i := 0; i < 30; i++ {
+		entry := fmt.Sprintf("p%02d", i)
+		entries[entry] = domain.StoredEntry{Score: "10", Counter: 2, Metadata: domain.Metadata{"country": "pt"}, ImportID: "import", ImportLine: int64(i + 2)}
+		counters[entry] = uint64(i % 2)
+	}
+
+	// the records are written in transactions of 25 and the entries of a cancelled one are returned
+	gomock.InOrder(
+		client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+				assert.Len(t, input.TransactItems, 25)
+				first, second := input.TransactItems[0].Put, input.TransactItems[1].Put
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "USR#p00"}, first.Item["pk"])
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#" + leaderboard}, first.Item["sk"])
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "import"}, first.Item["import_id"])
+				assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, first.Item["import_line"])
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "pt"}, first.Item["md::country"])
+				assert.Contains(t, *first.ConditionExpression, "attribute_not_exists")
+				assert.Contains(t, *second.ConditionExpression, "=")
+				return &dynamodb.TransactWriteItemsOutput{}, nil
+			}),
+		client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+				assert.Len(t, input.TransactItems, 5)
+				return nil, &types.TransactionCanceledException{}
+			}),
+	)
+	changed, err := r.ReplaceEntries(leaderboard, entries, counters)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p25", "p26", "p27", "p28", "p29"}, changed)
+
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+	_, err = r.ReplaceEntries(leaderboard, entries, counters)
+	assert.Error(t, err)
+}
+
+func TestDynamoDBRepository_DeleteEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	r, err := repository.NewDynamoDBRepository(testutil.NewMockDefaultDynamoDBSettings(client))
+	assert.NoError(t, err)
+
+	entry := testutil.NewID()
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+	client.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "USR#" + entry}, input.Key["pk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#" + leaderboard}, input.Key["sk"])
+			return &dynamodb.DeleteItemOutput{Attributes: map[string]types.AttributeValue{"score": &types.AttributeValueMemberN{Value: "1"}}}, nil
+		})
+	client.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	deleted, err := r.DeleteEntry(entry, leaderboard)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = r.DeleteEntry(entry, leaderboard)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestDynamoDBRepository_ClaimPrize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "PRIZE#weekly::3"}, input.Key["sk"])
+			assert.Contains(t, *input.ConditionExpression, "attribute_not_exists")
+			return &dynamodb.UpdateItemOutput{}, nil
+		})
+	claimed, err := r.ClaimPrize("p1", "Weekly", 3, "key-1", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).Return(nil, &types.ConditionalCheckFailedException{})
+	claimed, err = r.ClaimPrize("p1", "Weekly", 3, "key-1", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestDynamoDBRepository_Submissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	// the submissions are counted in shards
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Regexp(t, `^LBRD#STATS#\d+$`, input.Key["pk"].(*types.AttributeValueMemberS).Value)
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#weekly::3"}, input.Key["sk"])
+			return &dynamodb.UpdateItemOutput{}, nil
+		})
+	assert.NoError(t, r.IncrementSubmissions("weekly::3"))
+
+	// the total sums the shards and the counter written before the sharding
+	counter := func(v string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"counter": &types.AttributeValueMemberN{Value: v}}
+	}
+	unprocessed := map[string]types.KeysAndAttributes{"table": {}}
+	gomock.InOrder(
+		client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				keys := input.RequestItems[settings.Table].Keys
+				assert.Len(t, keys, 11)
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#STATS"}, keys[0]["pk"])
+				return &dynamodb.BatchGetItemOutput{
+					Responses:       map[string][]map[string]types.AttributeValue{settings.Table: {counter("3"), counter("4")}},
+					UnprocessedKeys: unprocessed,
+				}, nil
+			}),
+		client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchGetItemOutput{
+			Responses: map[string][]map[string]types.AttributeValue{settings.Table: {counter("5")}},
+		}, nil),
+	)
+	total, err := r.GetSubmissions("weekly::3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), total)
+}
+
+func TestDynamoDBRepository_PrizeDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	// the delivery is copied to the partition of its state and removed from the others
+	delivery := domain.PrizeDelivery{
+		Batch: domain.AwardBatch{ID: "weekly::3", Leaderboard: "Weekly", Epoch: 3},
+		State: domain.DeliveryPending,
+	}
+	var indexed map[string]types.AttributeValue
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			assert.Len(t, input.TransactItems, 3)
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#PRIZES"}, input.TransactItems[0].Put.Item["pk"])
+			assert.NotNil(t, input.TransactItems[0].Put.ConditionExpression)
+			indexed = input.TransactItems[1].Put.Item
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#PRIZES#STATE#pending"}, indexed["pk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#PRIZES#STATE#dead"}, input.TransactItems[2].Delete.Key["pk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#weekly::3"}, input.TransactItems[2].Delete.Key["sk"])
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		})
+	created, err := r.CreatePrizeDelivery(delivery)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).Return(nil, &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")}},
+	})
+	created, err = r.CreatePrizeDelivery(delivery)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	// the deliveries in a state are read from its partition
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			assert.Nil(t, input.FilterExpression)
+			assert.Contains(t, input.ExpressionAttributeValues, ":0")
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#PRIZES#STATE#pending"}, input.ExpressionAttributeValues[":0"])
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{indexed}}, nil
+		})
+	deliveries, err := r.GetPrizeDeliveries(domain.DeliveryPending)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, delivery.Batch, deliveries[0].Batch)
+
+	_, err = r.GetPrizeDeliveries(domain.DeliveryDelivered)
+	assert.Error(t, err)
+}
+
+func TestDynamoDBRepository_ClosedEpoch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	_, found, err := r.GetClosedEpoch("Weekly")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{"epoch": &types.AttributeValueMemberN{Value: "7"}},
+	}, nil)
+	epoch, found, err := r.GetClosedEpoch("Weekly")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(7), epoch)
+
+	// an older epoch does not replace a newer one
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#Weekly"}, input.Key["sk"])
+			assert.NotNil(t, input.ConditionExpression)
+			return nil, &types.ConditionalCheckFailedException{}
+		})
+	assert.NoError(t, r.SetClosedEpoch("Weekly", 6))
+}
+
+func TestDynamoDBRepository_Archive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	global := make([]domain.LeaderboardEntry, 2001)
+	for i := range global {
+		global[i] = domain.LeaderboardEntry{EntryID: testutil.NewID(), Score: float64(len(global) - i), Rank: int64(i + 1)}
+	}
+	archive := domain.EpochArchive{
+		Leaderboard: "Weekly",
+		Epoch:       3,
+		ArchivedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Scoreboards: []domain.LeaderboardScores{
+			{Name: "weekly::3", Scores: global},
+			{Name: "weekly::country::pt::3", Scores: []domain.LeaderboardEntry{}},
+		},
+	}
+
+	// the parts are keyed by scoreboard and stored before the header, the archived epoch is
+	// raised last
+	items := []map[string]types.AttributeValue{}
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#ARCHIVE#weekly::3"}, input.Item["pk"])
+			items = append(items, input.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		}).Times(5)
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Equal(t, 4, len(items)-1)
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#weekly"}, input.Key["sk"])
+			return nil, &types.ConditionalCheckFailedException{}
+		})
+	assert.NoError(t, r.SaveArchive(archive))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "PART#weekly::3#000002"}, items[2]["sk"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "PART#weekly::country::pt::3#000000"}, items[3]["sk"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "HEADER"}, items[4]["sk"])
+
+	stored := map[string]map[string]types.AttributeValue{}
+	for _, item := range items {
+		stored[item["sk"].(*types.AttributeValueMemberS).Value] = item
+	}
+	batchGet := func(keys *[]string) func(context.Context, *dynamodb.BatchGetItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+		return func(_ context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			output := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+			for _, key := range input.RequestItems[settings.Table].Keys {
+				sk := key["sk"].(*types.AttributeValueMemberS).Value
+				*keys = append(*keys, sk)
+				if item, ok := stored[sk]; ok {
+					output.Responses[settings.Table] = append(output.Responses[settings.Table], item)
+				}
+			}
+			return output, nil
+		}
+	}
+
+	// only the header and the parts of the top entries of the requested scoreboards are read
+	var keys []string
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(batchGet(&keys))
+	v, found, err := r.GetArchive("Weekly", 3, []string{"weekly::country::es::3", "weekly::3", "weekly::country::pt::3"}, 10)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.ElementsMatch(t, []string{"HEADER", "PART#weekly::country::es::3#000000", "PART#weekly::3#000000", "PART#weekly::country::pt::3#000000"}, keys)
+	assert.Equal(t, domain.EpochArchive{
+		Leaderboard: "Weekly",
+		Epoch:       3,
+		ArchivedAt:  archive.ArchivedAt,
+		Scoreboards: []domain.LeaderboardScores{{Name: "weekly::3", Scores: global[:10]}, archive.Scoreboards[1]},
+	}, v)
+
+	keys = nil
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(batchGet(&keys))
+	v, found, err = r.GetArchive("Weekly", 3, []string{"weekly::3", "weekly::country::pt::3"}, 3000)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, keys, 7)
+	assert.Equal(t, archive, v)
+
+	// archives without the header are incomplete
+	delete(stored, "HEADER")
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(batchGet(&keys))
+	_, found, err = r.GetArchive("Weekly", 3, []string{"weekly::3"}, 10)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{"epoch": &types.AttributeValueMemberN{Value: "3"}},
+	}, nil)
+	epoch, found, err := r.GetArchivedEpoch("Weekly")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), epoch)
+
+	// only the header is read to check an archive exists
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "HEADER"}, input.Key["sk"])
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{"pk": items[4]["pk"]}}, nil
+		})
+	found, err = r.HasArchive("Weekly", 3)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	found, err = r.HasArchive("Weekly", 4)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestDynamoDBRepository_GetFriends(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	friends := []string{testutil.NewID(), testutil.NewID()}
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(
+		&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"friends": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+					&types.AttributeValueMemberS{Value: friends[0]},
+					&types.AttributeValueMemberS{Value: friends[1]},
+				}},
+			},
+		}, nil)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	v, err := r.GetFriends(testutil.NewID())
+	assert.NoError(t, err)
+	assert.Equal(t, friends, v)
+
+	v, err = r.GetFriends(testutil.NewID())
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestDynamoDBRepository_AdvanceEpoch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#EPOCH"}, input.Key["pk"])
+			assert.Equal(t, types.ReturnValueUpdatedNew, input.ReturnValues)
+			return &dynamodb.UpdateItemOutput{
+				Attributes: map[string]types.AttributeValue{
+					"epoch": &types.AttributeValueMemberN{Value: "2"},
+				},
+			}, nil
+		})
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	v, err := r.GetEpoch("lb")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+
+	v, err = r.AdvanceEpoch("lb")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), v)
+}
+
+func TestDynamoDBRepository_TransitionLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	stored := `{"name":"lb","lifecycle":{"state":"draft"}}`
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"pk":     &types.AttributeValueMemberS{Value: "LBRD#CONFIG"},
+			"sk":     &types.AttributeValueMemberS{Value: "LBRD#NAME#lb"},
+			"config": &types.AttributeValueMemberS{Value: stored},
+		},
+	}, nil).Times(2)
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			assert.Len(t, input.TransactItems, 2)
+			assert.Contains(t, input.TransactItems[0].Put.ExpressionAttributeValues, ":0")
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#AUDIT#lb"}, input.TransactItems[1].Put.Item["pk"])
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		})
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	err = r.TransitionLifecycle("lb", domain.LeaderboardLifecycle{State: domain.Active, ActivatedAt: &now},
+		domain.LifecycleAudit{Name: "lb", From: domain.Draft, To: domain.Active, Actor: "ops", At: now})
+	assert.NoError(t, err)
+
+	err = r.TransitionLifecycle("lb", domain.LeaderboardLifecycle{State: domain.Closed},
+		domain.LifecycleAudit{Name: "lb", From: domain.Paused, To: domain.Closed, Actor: "ops", At: now})
+	assert.Error(t, err)
+}
+
+func TestDynamoDBRepository_ReplaceConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	stored := `{"name":"lb","lifecycle":{"state":"draft"}}`
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"pk":     &types.AttributeValueMemberS{Value: "LBRD#CONFIG"},
+			"sk":     &types.AttributeValueMemberS{Value: "LBRD#NAME#lb"},
+			"config": &types.AttributeValueMemberS{Value: stored},
+		},
+	}, nil).Times(2)
+	// the configuration is replaced only while it is the one read
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: stored}, input.ExpressionAttributeValues[":0"])
+			return &dynamodb.PutItemOutput{}, nil
+		})
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	config := domain.LeaderboardConfig{Name: "lb", Function: domain.Max}
+	config.Lifecycle = domain.LeaderboardLifecycle{State: domain.Draft}
+	assert.NoError(t, r.ReplaceConfig(config, config.Lifecycle))
+
+	// a lifecycle transition made after the read is not overwritten
+	err = r.ReplaceConfig(config, domain.LeaderboardLifecycle{State: domain.Active})
+	assert.ErrorContains(t, err, "changed concurrently")
+}
+
+func TestDynamoDBRepository_CreateConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			assert.Len(t, input.TransactItems, 2)
+			assert.Contains(t, *input.TransactItems[0].Put.ConditionExpression, "attribute_not_exists")
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#NAME#lb"}, input.TransactItems[0].Put.Item["sk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#AUDIT#lb"}, input.TransactItems[1].Put.Item["pk"])
+			return nil, &types.TransactionCanceledException{}
+		})
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	err = r.CreateConfig(domain.LeaderboardConfig{Name: "lb"}, domain.LifecycleAudit{Name: "lb", To: domain.Active, Actor: "ops", At: now})
+	assert.ErrorContains(t, err, "created concurrently")
+}
+
+func TestDynamoDBRepository_MigrateEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+
+	item := func(pk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"pk":    &types.AttributeValueMemberS{Value: pk},
+			"sk":    &types.AttributeValueMemberS{Value: "LBRD#lb::1"},
+			"score": &types.AttributeValueMemberN{Value: "10"},
+		}
+	}
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			keys := input.RequestItems[testutil.DynamoDBLocalTableName].Keys
+			assert.Len(t, keys, 13)
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "USR#a"}, keys[0]["pk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#STATS"}, keys[2]["pk"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#lb::1"}, keys[2]["sk"])
+			return &dynamodb.BatchGetItemOutput{
+				Responses:       map[string][]map[string]types.AttributeValue{testutil.DynamoDBLocalTableName: {item("USR#a")}},
+				UnprocessedKeys: map[string]types.KeysAndAttributes{testutil.DynamoDBLocalTableName: {Keys: keys[1:2]}},
+			}, nil
+		})
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{testutil.DynamoDBLocalTableName: {item("USR#b")}},
+	}, nil)
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "LBRD#lb::2"}, input.Item["sk"])
+			return &dynamodb.PutItemOutput{}, nil
+		})
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(nil, &types.ConditionalCheckFailedException{})
+	client.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	moved, conflicts, err := r.MigrateEntries("lb::1", "lb::2", []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, 1, conflicts)
+}
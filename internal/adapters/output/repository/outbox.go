@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+const (
+	// pkOutboxPrefix keys the events which were not acknowledged by the event bus
+	pkOutboxPrefix = "LBRD#OUTBOX#"
+	storedAtAttrib = "stored_at"
+	// outboxShards is the number of partitions the outbox is spread across, so every score does
+	// not write to the same partition
+	outboxShards = 10
+)
+
+// OutboxRecord represents an event kept until the event bus acknowledges it
+type OutboxRecord struct {
+	PK    string `dynamodbav:"pk"`
+	SK    string `dynamodbav:"sk"`
+	Event string `dynamodbav:"event"`
+	// StoredAt is the unix time the event was stored at
+	StoredAt int64 `dynamodbav:"stored_at"`
+}
+
+// WithOutbox returns a repository whose score writes store the events in the outbox in the
+// same transaction as the score, the events are not stored when the score is not
+func (r *DynamoDBRepository) WithOutbox(events ...domain.Event) ports.Repository {
+	outbox := *r
+	outbox.outbox = events
+	return &outbox
+}
+
+// updateEntry applies the update of a score, along with the events of the outbox when the
+// repository has them. Transactions do not return the updated item, so it is read after the
+// transaction and may hold a later update of the entry. A failed condition is returned as a
+// ConditionalCheckFailedException in both cases
+func (r *DynamoDBRepository) updateEntry(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	if len(r.outbox) == 0 {
+		return r.client.UpdateItem(ctx, input)
+	}
+	items := []types.TransactWriteItem{{Update: &types.Update{
+		TableName:                 input.TableName,
+		Key:                       input.Key,
+		UpdateExpression:          input.UpdateExpression,
+		ConditionExpression:       input.ConditionExpression,
+		ExpressionAttributeNames:  input.ExpressionAttributeNames,
+		ExpressionAttributeValues: input.ExpressionAttributeValues,
+	}}}
+	for _, e := range r.outbox {
+		item, err := outboxItem(e, time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, types.TransactWriteItem{Put: &types.Put{
+			TableName: aws.String(r.tableName),
+			Item:      item,
+		}})
+	}
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) && len(tce.CancellationReasons) > 0 && aws.ToString(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return nil, &types.ConditionalCheckFailedException{Message: tce.Message}
+		}
+		return nil, err
+	}
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      input.TableName,
+		ConsistentRead: aws.Bool(true),
+		Key:            input.Key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.UpdateItemOutput{Attributes: output.Item}, nil
+}
+
+// SaveEvents implements the EventOutbox interface, the events replace the stored events with
+// the same ID
+func (r *DynamoDBRepository) SaveEvents(events ...domain.Event) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("save events timeout"))
+	defer cancel()
+
+	requests := make([]types.WriteRequest, 0, len(events))
+	for _, e := range events {
+		item, err := outboxItem(e, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	return r.batchWrite(ctx, requests)
+}
+
+// GetPendingEvents implements the EventOutbox interface reading every shard of the outbox
+func (r *DynamoDBRepository) GetPendingEvents(before time.Time, limit int) ([]domain.Event, error) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("get pending events timeout"))
+	defer cancel()
+
+	events := []domain.Event{}
+	for shard := 0; shard < outboxShards; shard++ {
+		expr, err := expression.NewBuilder().WithKeyCondition(
+			expression.Key(hashKeyName).Equal(expression.Value(outboxShard(shard))),
+		).WithFilter(
+			expression.Name(storedAtAttrib).LessThan(expression.Value(before.Unix())),
+		).Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build expression: %w", err)
+		}
+		input := dynamodb.QueryInput{
+			TableName:                 aws.String(r.tableName),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			KeyConditionExpression:    expr.KeyCondition(),
+			FilterExpression:          expr.Filter(),
+			Limit:                     aws.Int32(int32(limit)),
+		}
+		// the filter applies after the limit, so the pages are read until limit events are found
+		found := 0
+		for found < limit {
+			output, err := r.client.Query(ctx, &input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query database: %w", err)
+			}
+			for _, item := range output.Items {
+				e, err := outboxEventFromItem(item)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, e)
+				found++
+			}
+			if output.LastEvaluatedKey == nil {
+				break
+			}
+			input.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// DeleteEvents implements the EventOutbox interface
+func (r *DynamoDBRepository) DeleteEvents(ids ...string) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), queryTimeout, errors.New("delete events timeout"))
+	defer cancel()
+
+	requests := make([]types.WriteRequest, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: outboxShard(outboxShardOf(id))},
+			sortKeyName: &types.AttributeValueMemberS{Value: id},
+		}}})
+	}
+	return r.batchWrite(ctx, requests)
+}
+
+// outboxItem returns the outbox item of an event, keyed by its ID in a shard of the outbox
+func outboxItem(e domain.Event, storedAt time.Time) (map[string]types.AttributeValue, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	item, err := attributevalue.MarshalMap(OutboxRecord{
+		PK:       outboxShard(outboxShardOf(e.ID)),
+		SK:       e.ID,
+		Event:    string(data),
+		StoredAt: storedAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal outbox record: %w", err)
+	}
+	return item, nil
+}
+
+func outboxEventFromItem(item map[string]types.AttributeValue) (domain.Event, error) {
+	rec := OutboxRecord{}
+	err := attributevalue.UnmarshalMap(item, &rec)
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("failed to process output: %w", err)
+	}
+	var e domain.Event
+	err = json.Unmarshal([]byte(rec.Event), &e)
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("failed to parse event '%v': %w", rec.SK, err)
+	}
+	return e, nil
+}
+
+func outboxShard(shard int) string {
+	return fmt.Sprintf("%s%d", pkOutboxPrefix, shard)
+}
+
+// outboxShardOf returns the shard of an event, which is derived from its ID so the event is
+// replaced and deleted by ID
+func outboxShardOf(id string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() % outboxShards)
+}
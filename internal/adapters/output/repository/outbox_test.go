@@ -0,0 +1,126 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/posilva/simpleboards/internal/adapters/output/repository"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/testutil"
+	testmocks "github.com/posilva/simpleboards/internal/testutil/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDynamoDBRepository_WithOutbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	entry := testutil.NewID()
+	leaderboard := testutil.NewUnique(testutil.Name(t))
+	accepted := domain.Event{ID: "1", Type: domain.ScoreAccepted, Leaderboard: "weekly", EntryID: entry, Score: 10}
+
+	// the event is stored in the same transaction as the score, which is read after it
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			assert.Len(t, input.TransactItems, 2)
+			assert.Contains(t, *input.TransactItems[0].Update.ConditionExpression, "<=")
+			item := input.TransactItems[1].Put.Item
+			assert.True(t, strings.HasPrefix(item["pk"].(*types.AttributeValueMemberS).Value, "LBRD#OUTBOX#"))
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "1"}, item["sk"])
+			assert.Contains(t, item["event"].(*types.AttributeValueMemberS).Value, `"type":"score.accepted"`)
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		})
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			assert.True(t, aws.ToBool(input.ConsistentRead))
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+				"score":   &types.AttributeValueMemberN{Value: "10"},
+				"counter": &types.AttributeValueMemberN{Value: "2"},
+			}}, nil
+		})
+	v, err := r.WithOutbox(accepted).MaxWithMetadata(entry, leaderboard, 10, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ScoreUpdate{Score: 10, Counter: 2, Done: true}, v)
+
+	// the event is not stored when the score is not
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).Return(nil, &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")}},
+	})
+	v, err = r.WithOutbox(accepted).MaxWithMetadata(entry, leaderboard, 5, nil)
+	assert.NoError(t, err)
+	assert.False(t, v.Done)
+
+	// the repository without outbox updates the score alone
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).Return(&dynamodb.UpdateItemOutput{
+		Attributes: map[string]types.AttributeValue{"score": &types.AttributeValueMemberN{Value: "10"}},
+	}, nil)
+	_, err = r.MaxWithMetadata(entry, leaderboard, 10, nil)
+	assert.NoError(t, err)
+}
+
+func TestDynamoDBRepository_Outbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBClient(ctrl)
+	settings := testutil.NewMockDefaultDynamoDBSettings(client)
+	r, err := repository.NewDynamoDBRepository(settings)
+	assert.NoError(t, err)
+
+	events := []domain.Event{{ID: "2", Type: domain.RankChanged}, {ID: "1", Type: domain.ScoreAccepted}}
+	items := map[string]map[string]types.AttributeValue{}
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			for _, request := range input.RequestItems[settings.Table] {
+				items[request.PutRequest.Item["sk"].(*types.AttributeValueMemberS).Value] = request.PutRequest.Item
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		})
+	assert.NoError(t, r.SaveEvents(events...))
+	assert.Len(t, items, 2)
+
+	// every shard is read and the events are returned in ID order
+	shards := map[string]bool{}
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			assert.NotNil(t, input.FilterExpression)
+			output := &dynamodb.QueryOutput{}
+			for _, value := range input.ExpressionAttributeValues {
+				pk, ok := value.(*types.AttributeValueMemberS)
+				if !ok {
+					continue
+				}
+				shards[pk.Value] = true
+				for _, item := range items {
+					if item["pk"].(*types.AttributeValueMemberS).Value == pk.Value {
+						output.Items = append(output.Items, item)
+					}
+				}
+			}
+			return output, nil
+		}).Times(10)
+	pending, err := r.GetPendingEvents(time.Now().Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, shards, 10)
+	assert.Equal(t, []domain.Event{events[1], events[0]}, pending)
+
+	// the events are deleted from the shard they were stored in
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			for _, request := range input.RequestItems[settings.Table] {
+				key := request.DeleteRequest.Key
+				assert.Equal(t, items[key["sk"].(*types.AttributeValueMemberS).Value]["pk"], key["pk"])
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		})
+	assert.NoError(t, r.DeleteEvents("1", "2"))
+}
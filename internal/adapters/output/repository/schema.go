@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+const (
+	pkSchema        = "LBRD#SCHEMA"
+	skSchemaVersion = "VERSION"
+	versionAttrib   = "version"
+	updatedAttrib   = "updated_at"
+	// InvertedIndexName is the index with the sort key as hash key, it queries all the entries
+	// of a leaderboard epoch
+	InvertedIndexName = "sk-pk-index"
+	// TTLAttribute is the time to live attribute, the one enabled by the terraform module
+	TTLAttribute = "Expires"
+	// schemaPollInterval is the interval to check if the table and its indexes are active
+	schemaPollInterval = 2 * time.Second
+)
+
+// SchemaStatus is the version of the layout of a table
+type SchemaStatus struct {
+	Table   string   `json:"table"`
+	Exists  bool     `json:"exists"`
+	Version int      `json:"version"`
+	Latest  int      `json:"latest"`
+	Pending []string `json:"pending,omitempty"`
+}
+
+// schemaMigration is a change of the table layout
+type schemaMigration struct {
+	version     int
+	description string
+	apply       func(m *SchemaManager, ctx context.Context) error
+}
+
+// schemaMigrations are the changes of the table layout and of the stored items in order, each one
+// checks the table before changing it so it can run again when the version was not recorded
+var schemaMigrations = []schemaMigration{
+	{1, "create the table with the pk hash key and the sk range key", (*SchemaManager).createTable},
+	{2, "copy the pending and dead prize deliveries to the partitions of their state", (*SchemaManager).indexPrizeDeliveries},
+	{3, "add the " + InvertedIndexName + " index", (*SchemaManager).createInvertedIndex},
+	{4, "enable the time to live on the " + TTLAttribute + " attribute", (*SchemaManager).enableTimeToLive},
+}
+
+// LatestSchemaVersion returns the version of the table layout this release uses
+func LatestSchemaVersion() int {
+	return schemaMigrations[len(schemaMigrations)-1].version
+}
+
+// SchemaManager creates the table and migrates its layout, the version of the layout is recorded
+// in an item of the table
+type SchemaManager struct {
+	client       DynamoDBSchemaClient
+	tableName    string
+	pollInterval time.Duration
+}
+
+// NewSchemaManager creates a new schema manager of a table
+func NewSchemaManager(client DynamoDBSchemaClient, table string) *SchemaManager {
+	return &SchemaManager{
+		client:       client,
+		tableName:    table,
+		pollInterval: schemaPollInterval,
+	}
+}
+
+// WithPollInterval sets the interval to check if the table and its indexes are active
+func (m *SchemaManager) WithPollInterval(interval time.Duration) *SchemaManager {
+	m.pollInterval = interval
+	return m
+}
+
+// Status returns the version of the table layout and the migrations to apply
+func (m *SchemaManager) Status() (SchemaStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	status := SchemaStatus{Table: m.tableName, Latest: LatestSchemaVersion()}
+	table, err := m.describe(ctx)
+	if err != nil {
+		return status, err
+	}
+	if table != nil {
+		status.Exists = true
+		status.Version, err = m.version(ctx)
+		if err != nil {
+			return status, err
+		}
+	}
+	for _, migration := range schemaMigrations {
+		if migration.version > status.Version {
+			status.Pending = append(status.Pending, migration.description)
+		}
+	}
+	return status, nil
+}
+
+// Init creates the table when it does not exist and applies the pending migrations, it returns
+// the applied migrations
+func (m *SchemaManager) Init() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+	return m.migrate(ctx, true)
+}
+
+// Migrate applies the pending migrations of an existing table, it returns the applied migrations
+func (m *SchemaManager) Migrate() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+	return m.migrate(ctx, false)
+}
+
+func (m *SchemaManager) migrate(ctx context.Context, create bool) ([]string, error) {
+	table, err := m.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version := 0
+	if table == nil && !create {
+		return nil, fmt.Errorf("table %v does not exist, it is created with db init", m.tableName)
+	}
+	if table != nil {
+		version, err = m.version(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return nil, fmt.Errorf("table %v schema version %d is newer than the supported version %d", m.tableName, version, latest)
+	}
+
+	applied := []string{}
+	for _, migration := range schemaMigrations {
+		if migration.version <= version {
+			continue
+		}
+		err = migration.apply(m, ctx)
+		if err != nil {
+			return applied, fmt.Errorf("failed to migrate to schema version %d: %w", migration.version, err)
+		}
+		err = m.putVersion(ctx, version, migration.version)
+		if err != nil {
+			return applied, err
+		}
+		version = migration.version
+		applied = append(applied, migration.description)
+	}
+	return applied, nil
+}
+
+// createTable creates the table, a table created by terraform is kept when it has the same keys
+func (m *SchemaManager) createTable(ctx context.Context) error {
+	table, err := m.describe(ctx)
+	if err != nil {
+		return err
+	}
+	if table != nil {
+		return checkKeySchema(table.KeySchema)
+	}
+	_, err = m.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:   aws.String(m.tableName),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(hashKeyName), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String(sortKeyName), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(hashKeyName), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(sortKeyName), KeyType: types.KeyTypeRange},
+		},
+	})
+	if err != nil {
+		var riue *types.ResourceInUseException
+		if !errors.As(err, &riue) {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+	return m.waitActive(ctx, func(table *types.TableDescription) bool {
+		return table.TableStatus == types.TableStatusActive
+	})
+}
+
+// indexPrizeDeliveries copies the pending and dead prize deliveries stored before they were kept
+// in the partitions of their state, the copies written since are kept
+func (m *SchemaManager) indexPrizeDeliveries(ctx context.Context) error {
+	expr, err := expression.NewBuilder().WithKeyCondition(
+		expression.Key(hashKeyName).Equal(expression.Value(pkPrizesPrefix)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression: %w", err)
+	}
+	input := dynamodb.QueryInput{
+		TableName:                 aws.String(m.tableName),
+		ConsistentRead:            aws.Bool(true),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+	notExists, err := expression.NewBuilder().WithCondition(
+		expression.AttributeNotExists(expression.Name(hashKeyName)),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build condition expression: %w", err)
+	}
+	for {
+		output, err := m.client.Query(ctx, &input)
+		if err != nil {
+			return fmt.Errorf("failed to query prize deliveries: %w", err)
+		}
+		for _, item := range output.Items {
+			state, ok := item[stateAttrib].(*types.AttributeValueMemberS)
+			if !ok || !slices.Contains(indexedDeliveryStates, domain.DeliveryState(state.Value)) {
+				continue
+			}
+			indexed := maps.Clone(item)
+			indexed[hashKeyName] = &types.AttributeValueMemberS{Value: pkPrizeStatePrefix + state.Value}
+			_, err = m.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:                 aws.String(m.tableName),
+				Item:                      indexed,
+				ConditionExpression:       notExists.Condition(),
+				ExpressionAttributeNames:  notExists.Names(),
+				ExpressionAttributeValues: notExists.Values(),
+			})
+			var ccfe *types.ConditionalCheckFailedException
+			if err != nil && !errors.As(err, &ccfe) {
+				return fmt.Errorf("failed to put prize delivery: %w", err)
+			}
+		}
+		if output.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// createInvertedIndex adds the index with the sort key as hash key and the hash key as range key
+func (m *SchemaManager) createInvertedIndex(ctx context.Context) error {
+	table, err := m.describe(ctx)
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return fmt.Errorf("table %v does not exist", m.tableName)
+	}
+	if indexOf(table, InvertedIndexName) == nil {
+		index := &types.CreateGlobalSecondaryIndexAction{
+			IndexName: aws.String(InvertedIndexName),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String(sortKeyName), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String(hashKeyName), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+		}
+		if isProvisioned(table) {
+			index.ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  table.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits: table.ProvisionedThroughput.WriteCapacityUnits,
+			}
+		}
+		_, err = m.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: aws.String(m.tableName),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String(hashKeyName), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String(sortKeyName), AttributeType: types.ScalarAttributeTypeS},
+			},
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Create: index}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create index %v: %w", InvertedIndexName, err)
+		}
+	}
+	return m.waitActive(ctx, func(table *types.TableDescription) bool {
+		index := indexOf(table, InvertedIndexName)
+		return index != nil && index.IndexStatus == types.IndexStatusActive
+	})
+}
+
+// enableTimeToLive enables the expiration of the items with the time to live attribute, a time
+// to live on another attribute is an error because a table only has one
+func (m *SchemaManager) enableTimeToLive(ctx context.Context) error {
+	output, err := m.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(m.tableName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe time to live: %w", err)
+	}
+	if ttl := output.TimeToLiveDescription; ttl != nil {
+		switch ttl.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			if attr := aws.ToString(ttl.AttributeName); attr != TTLAttribute {
+				return fmt.Errorf("time to live is enabled on attribute %v instead of %v", attr, TTLAttribute)
+			}
+			return nil
+		}
+	}
+	_, err = m.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(m.tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable time to live: %w", err)
+	}
+	return nil
+}
+
+// describe returns the table description, nil when the table does not exist
+func (m *SchemaManager) describe(ctx context.Context) (*types.TableDescription, error) {
+	output, err := m.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(m.tableName),
+	})
+	if err != nil {
+		var rnfe *types.ResourceNotFoundException
+		if errors.As(err, &rnfe) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe table: %w", err)
+	}
+	return output.Table, nil
+}
+
+// waitActive polls the table description until the check is true
+func (m *SchemaManager) waitActive(ctx context.Context, check func(*types.TableDescription) bool) error {
+	for {
+		table, err := m.describe(ctx)
+		if err != nil {
+			return err
+		}
+		if table != nil && check(table) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for table %v to be active: %w", m.tableName, ctx.Err())
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+// version returns the recorded schema version, 0 when it was never recorded
+func (m *SchemaManager) version(ctx context.Context) (int, error) {
+	output, err := m.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(m.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			hashKeyName: &types.AttributeValueMemberS{Value: pkSchema},
+			sortKeyName: &types.AttributeValueMemberS{Value: skSchemaVersion},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	attr, ok := output.Item[versionAttrib].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(attr.Value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse schema version %v: %w", attr.Value, err)
+	}
+	return version, nil
+}
+
+// putVersion records the schema version if it was not changed since it was read
+func (m *SchemaManager) putVersion(ctx context.Context, previous int, version int) error {
+	cond := expression.Or(
+		expression.AttributeNotExists(expression.Name(hashKeyName)),
+		expression.Name(versionAttrib).Equal(expression.Value(previous)),
+	)
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression: %w", err)
+	}
+	_, err = m.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(m.tableName),
+		Item: map[string]types.AttributeValue{
+			hashKeyName:   &types.AttributeValueMemberS{Value: pkSchema},
+			sortKeyName:   &types.AttributeValueMemberS{Value: skSchemaVersion},
+			versionAttrib: &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+			updatedAttrib: &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		var ccfe *types.ConditionalCheckFailedException
+		if errors.As(err, &ccfe) {
+			return fmt.Errorf("schema version %d of table %v was changed by another migration", previous, m.tableName)
+		}
+		return fmt.Errorf("failed to put schema version: %w", err)
+	}
+	return nil
+}
+
+// checkKeySchema checks the table has the pk hash key and the sk range key
+func checkKeySchema(keys []types.KeySchemaElement) error {
+	want := map[string]types.KeyType{hashKeyName: types.KeyTypeHash, sortKeyName: types.KeyTypeRange}
+	if len(keys) != len(want) {
+		return fmt.Errorf("table has %d keys instead of the %v hash key and the %v range key", len(keys), hashKeyName, sortKeyName)
+	}
+	for _, key := range keys {
+		if want[aws.ToString(key.AttributeName)] != key.KeyType {
+			return fmt.Errorf("table has the unexpected %v key %v", key.KeyType, aws.ToString(key.AttributeName))
+		}
+	}
+	return nil
+}
+
+func indexOf(table *types.TableDescription, name string) *types.GlobalSecondaryIndexDescription {
+	for i := range table.GlobalSecondaryIndexes {
+		if aws.ToString(table.GlobalSecondaryIndexes[i].IndexName) == name {
+			return &table.GlobalSecondaryIndexes[i]
+		}
+	}
+	return nil
+}
+
+// isProvisioned returns true when the table is not on demand, its indexes need a throughput
+func isProvisioned(table *types.TableDescription) bool {
+	if table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode == types.BillingModePayPerRequest {
+		return false
+	}
+	return table.ProvisionedThroughput != nil && aws.ToInt64(table.ProvisionedThroughput.ReadCapacityUnits) > 0
+}
@@ -0,0 +1,197 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/posilva/simpleboards/internal/adapters/output/repository"
+	testmocks "github.com/posilva/simpleboards/internal/testutil/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func activeTable(keys ...string) *dynamodb.DescribeTableOutput {
+	if len(keys) == 0 {
+		keys = []string{"pk", "sk"}
+	}
+	table := &types.TableDescription{TableStatus: types.TableStatusActive}
+	for i, key := range keys {
+		keyType := types.KeyTypeHash
+		if i > 0 {
+			keyType = types.KeyTypeRange
+		}
+		table.KeySchema = append(table.KeySchema, types.KeySchemaElement{AttributeName: aws.String(key), KeyType: keyType})
+	}
+	return &dynamodb.DescribeTableOutput{Table: table}
+}
+
+func indexedTable() *dynamodb.DescribeTableOutput {
+	output := activeTable()
+	output.Table.GlobalSecondaryIndexes = []types.GlobalSecondaryIndexDescription{{
+		IndexName:   aws.String(repository.InvertedIndexName),
+		IndexStatus: types.IndexStatusActive,
+	}}
+	return output
+}
+
+func timeToLive(status types.TimeToLiveStatus, attribute string) *dynamodb.DescribeTimeToLiveOutput {
+	return &dynamodb.DescribeTimeToLiveOutput{
+		TimeToLiveDescription: &types.TimeToLiveDescription{TimeToLiveStatus: status, AttributeName: aws.String(attribute)},
+	}
+}
+
+func TestSchemaManager_Init(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBSchemaClient(ctrl)
+
+	gomock.InOrder(
+		client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(nil, &types.ResourceNotFoundException{}).Times(2),
+		client.EXPECT().CreateTable(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+				assert.Equal(t, "table", aws.ToString(input.TableName))
+				assert.Equal(t, types.BillingModePayPerRequest, input.BillingMode)
+				return &dynamodb.CreateTableOutput{}, nil
+			}),
+		client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(&dynamodb.DescribeTableOutput{
+			Table: &types.TableDescription{TableStatus: types.TableStatusCreating},
+		}, nil),
+		client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable(), nil).Times(2),
+		client.EXPECT().UpdateTable(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, input *dynamodb.UpdateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+				create := input.GlobalSecondaryIndexUpdates[0].Create
+				assert.Equal(t, repository.InvertedIndexName, aws.ToString(create.IndexName))
+				assert.Nil(t, create.ProvisionedThroughput)
+				return &dynamodb.UpdateTableOutput{}, nil
+			}),
+		client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(indexedTable(), nil),
+	)
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{}, nil)
+	client.EXPECT().DescribeTimeToLive(gomock.Any(), gomock.Any()).Return(timeToLive(types.TimeToLiveStatusDisabled, ""), nil)
+	client.EXPECT().UpdateTimeToLive(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.UpdateTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+			assert.Equal(t, repository.TTLAttribute, aws.ToString(input.TimeToLiveSpecification.AttributeName))
+			return &dynamodb.UpdateTimeToLiveOutput{}, nil
+		})
+	version := 0
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			version++
+			assert.Equal(t, strconv.Itoa(version), input.Item["version"].(*types.AttributeValueMemberN).Value)
+			return &dynamodb.PutItemOutput{}, nil
+		}).Times(repository.LatestSchemaVersion())
+
+	applied, err := repository.NewSchemaManager(client, "table").WithPollInterval(0).Init()
+	assert.NoError(t, err)
+	assert.Len(t, applied, repository.LatestSchemaVersion())
+}
+
+func TestSchemaManager_MigrateExistingTable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBSchemaClient(ctrl)
+
+	// a table created by terraform has no version and its index and time to live are kept
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(indexedTable(), nil).Times(4)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	client.EXPECT().DescribeTimeToLive(gomock.Any(), gomock.Any()).Return(timeToLive(types.TimeToLiveStatusEnabled, repository.TTLAttribute), nil)
+
+	// the pending and dead prize deliveries are copied to the partitions of their state
+	delivery := func(state string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"pk":    &types.AttributeValueMemberS{Value: "LBRD#PRIZES"},
+			"sk":    &types.AttributeValueMemberS{Value: "weekly::" + state},
+			"state": &types.AttributeValueMemberS{Value: state},
+		}
+	}
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{delivery("pending"), delivery("delivered"), delivery("dead")},
+	}, nil)
+	var indexed []string
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			pk := input.Item["pk"].(*types.AttributeValueMemberS).Value
+			if pk == "LBRD#SCHEMA" {
+				return &dynamodb.PutItemOutput{}, nil
+			}
+			indexed = append(indexed, pk)
+			// a copy written since is kept
+			return nil, &types.ConditionalCheckFailedException{}
+		}).Times(2 + repository.LatestSchemaVersion())
+
+	applied, err := repository.NewSchemaManager(client, "table").WithPollInterval(0).Migrate()
+	assert.NoError(t, err)
+	assert.Len(t, applied, repository.LatestSchemaVersion())
+	assert.Equal(t, []string{"LBRD#PRIZES#STATE#pending", "LBRD#PRIZES#STATE#dead"}, indexed)
+
+	// an up to date table is not changed
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable(), nil)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{"version": &types.AttributeValueMemberN{Value: strconv.Itoa(repository.LatestSchemaVersion())}},
+	}, nil)
+	applied, err = repository.NewSchemaManager(client, "table").Migrate()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestSchemaManager_MigrateFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBSchemaClient(ctrl)
+	manager := repository.NewSchemaManager(client, "table").WithPollInterval(0)
+
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(nil, &types.ResourceNotFoundException{})
+	_, err := manager.Migrate()
+	assert.Error(t, err)
+
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable(), nil)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{"version": &types.AttributeValueMemberN{Value: "99"}},
+	}, nil)
+	_, err = manager.Migrate()
+	assert.ErrorContains(t, err, "newer")
+
+	// a table with other keys is not migrated
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable("id"), nil).Times(2)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	applied, err := manager.Migrate()
+	assert.Error(t, err)
+	assert.Empty(t, applied)
+
+	// a concurrent migration fails the version update
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable(), nil).Times(2)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(nil, &types.ConditionalCheckFailedException{})
+	_, err = manager.Migrate()
+	assert.ErrorContains(t, err, "another migration")
+
+	// a table only has one time to live attribute
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(indexedTable(), nil).Times(3)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{"version": &types.AttributeValueMemberN{Value: "2"}},
+	}, nil)
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil)
+	client.EXPECT().DescribeTimeToLive(gomock.Any(), gomock.Any()).Return(timeToLive(types.TimeToLiveStatusEnabled, "ttl"), nil)
+	applied, err = manager.Migrate()
+	assert.ErrorContains(t, err, "time to live")
+	assert.Len(t, applied, 1)
+}
+
+func TestSchemaManager_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := testmocks.NewMockDynamoDBSchemaClient(ctrl)
+
+	client.EXPECT().DescribeTable(gomock.Any(), gomock.Any()).Return(activeTable(), nil)
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+
+	status, err := repository.NewSchemaManager(client, "table").Status()
+	assert.NoError(t, err)
+	assert.True(t, status.Exists)
+	assert.Equal(t, 0, status.Version)
+	assert.Len(t, status.Pending, repository.LatestSchemaVersion())
+}
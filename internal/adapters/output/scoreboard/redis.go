@@ -3,6 +3,10 @@ package scoreboard
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/redis/rueidis"
@@ -15,12 +19,14 @@ type RedisScoreboard struct {
 
 type RedisScoreboardOptions struct {
 	BatchSize int `json:"batch_size"`
+	PageSize  int `json:"page_size"`
 }
 
 // DefaultRedisScoreboardOptions returns the default optoins for redis cache
 func DefaultRedisScoreboardOptions() RedisScoreboardOptions {
 	return RedisScoreboardOptions{
 		BatchSize: 50,
+		PageSize:  1000,
 	}
 }
 
@@ -48,44 +54,608 @@ func NewRedisScoreboardWithClient(client rueidis.Client) *RedisScoreboard {
 }
 
 // Get returns the list of results with batchsize
-func (c *RedisScoreboard) Get(name string) ([]domain.ScoreboardResult, error) {
-	return c.GetTopN(name, int64(c.options.BatchSize))
+func (c *RedisScoreboard) Get(name string, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	return c.GetTopN(name, int64(c.options.BatchSize), order)
+}
+
+// TopN returns the number of entries Get returns, the ranks from 0 to the batch size
+func (c *RedisScoreboard) TopN() int64 {
+	return int64(c.options.BatchSize) + 1
 }
 
 // GetTopN ...
-func (c *RedisScoreboard) GetTopN(name string, n int64) ([]domain.ScoreboardResult, error) {
-	cmd := c.client.B().Zrevrange().Key(name).Start(0).Stop(n).Withscores().Build()
-	m, err := c.client.Do(context.Background(), cmd).AsZScores()
+func (c *RedisScoreboard) GetTopN(name string, n int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	return c.rangeResults(context.Background(), name, 0, n, order)
+}
+
+// GetRange returns the entries ranked between the zero based start and stop positions
+func (c *RedisScoreboard) GetRange(name string, start int64, stop int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	results, err := c.rangeResults(context.Background(), name, start, stop, order)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get range: %v", err)
+	}
+	return results, nil
+}
+
+// addScoreScript sets the score of an entry and adds its change to the running sum of the
+// scoreboard. The sum is only kept when it was kept from the first score, a failed increment
+// drops it and the mean is calculated paging through the scoreboard. The exact value is kept
+// for the scores which may be shared by other values
+var addScoreScript = rueidis.NewLuaScript(`
+local kept = redis.call('EXISTS', KEYS[2]) == 1 or redis.call('EXISTS', KEYS[1]) == 0
+local previous = redis.call('ZSCORE', KEYS[1], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+if ARGV[3] ~= '' then
+	redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+else
+	redis.call('HDEL', KEYS[3], ARGV[2])
+end
+if kept then
+	local r = redis.pcall('INCRBYFLOAT', KEYS[2], tonumber(ARGV[1]) - (tonumber(previous) or 0))
+	if type(r) == 'table' and r.err then
+		redis.call('DEL', KEYS[2])
+	end
+end
+return 1
+`)
+
+// removeScoreScript removes an entry and subtracts its score from the running sum of the scoreboard
+var removeScoreScript = rueidis.NewLuaScript(`
+local previous = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not previous then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	local r = redis.pcall('INCRBYFLOAT', KEYS[2], -tonumber(previous))
+	if type(r) == 'table' and r.err then
+		redis.call('DEL', KEYS[2])
+	end
+end
+return 1
+`)
+
+// sumKey returns the key of the running sum of the scores of a scoreboard, the hash tag keeps it
+// in the slot of the scoreboard
+func sumKey(nameWithEpoch string) string {
+	return "{" + nameWithEpoch + "}::sum"
+}
+
+// exactKey returns the key of the exact values of the entries whose scores may be shared by other
+// values, the hash tag keeps it in the slot of the scoreboard
+func exactKey(nameWithEpoch string) string {
+	return "{" + nameWithEpoch + "}::exact"
+}
+
+// AddScore sets the score of an entry and keeps the running sum of the scoreboard
+func (c *RedisScoreboard) AddScore(entryID string, nameWithEpoch string, value float64) error {
+	return c.addScore(entryID, nameWithEpoch, value, "")
+}
+
+// AddExactScore sets the score of an entry to the float64 of an exact value, the value is kept
+// when the score may be shared by other values so the entries are ranked apart by their values
+func (c *RedisScoreboard) AddExactScore(entryID string, nameWithEpoch string, value domain.ExactScore) error {
+	exact := ""
+	if value.SharedScore() {
+		exact = value.Value
 	}
+	return c.addScore(entryID, nameWithEpoch, value.Score, exact)
+}
 
+func (c *RedisScoreboard) addScore(entryID string, nameWithEpoch string, value float64, exact string) error {
+	keys := []string{nameWithEpoch, sumKey(nameWithEpoch), exactKey(nameWithEpoch)}
+	args := []string{strconv.FormatFloat(value, 'f', -1, 64), entryID, exact}
+	return addScoreScript.Exec(context.Background(), c.client, keys, args).Error()
+}
+
+// RemoveScore removes an entry from a scoreboard, it returns false when the entry had no score
+func (c *RedisScoreboard) RemoveScore(entryID string, nameWithEpoch string) (bool, error) {
+	keys := []string{nameWithEpoch, sumKey(nameWithEpoch), exactKey(nameWithEpoch)}
+	removed, err := removeScoreScript.Exec(context.Background(), c.client, keys, []string{entryID}).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("failed to remove score: %v", err)
+	}
+	return removed > 0, nil
+}
+
+// TODO: check the return of the functtion to match the Rank type in the result
+
+// GetRank ...
+func (c *RedisScoreboard) GetRank(nameWithEpoch string, entryID string, order domain.SortOrder) (uint64, error) {
+	rank, err := c.entryRank(context.Background(), nameWithEpoch, entryID, order)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rank: %v", err)
+	}
+	return uint64(rank) + 1, nil
+}
+
+// GetNeighbours returns the entries ranked up to above positions before and below positions after
+// an entry without the entry, it is empty when the entry has no score
+func (c *RedisScoreboard) GetNeighbours(nameWithEpoch string, entryID string, above int64, below int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	ctx := context.Background()
 	results := []domain.ScoreboardResult{}
-	for i, r := range m {
+	rank, err := c.entryRank(ctx, nameWithEpoch, entryID, order)
+	if rueidis.IsRedisNil(err) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rank: %v", err)
+	}
+
+	neighbours, err := c.rangeResults(ctx, nameWithEpoch, max(rank-above, 0), rank+below, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get neighbours: %v", err)
+	}
+	for _, r := range neighbours {
+		if r.EntryID != entryID {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// GetScores returns the scores of the given entries using ZMSCORE, entries without score are skipped
+func (c *RedisScoreboard) GetScores(nameWithEpoch string, entryIDs []string) ([]domain.ScoreboardResult, error) {
+	results := []domain.ScoreboardResult{}
+	if len(entryIDs) == 0 {
+		return results, nil
+	}
+
+	cmd := c.client.B().Zmscore().Key(nameWithEpoch).Member(entryIDs...).Build()
+	values, err := c.client.Do(context.Background(), cmd).ToArray()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scores: %v", err)
+	}
+
+	for i, v := range values {
+		if v.IsNil() {
+			continue
+		}
+		score, err := v.AsFloat64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse score of '%v': %v", entryIDs[i], err)
+		}
 		results = append(results, domain.ScoreboardResult{
-			EntryID: r.Member,
-			Score:   r.Score,
-			Rank:    int64(i + 1),
+			EntryID: entryIDs[i],
+			Score:   score,
 		})
 	}
 	return results, nil
 }
 
-// AddScore ...
-func (c *RedisScoreboard) AddScore(entryID string, nameWithEpoch string, value float64) error {
-	cmd := c.client.B().Zadd().Key(nameWithEpoch).ScoreMember().ScoreMember(value, entryID).Build()
+// GetStanding returns the rank, score and percentiles of an entry along with the total of entries,
+// when nextBand is set it also returns the score of the lowest ranked entry in the next percentile band
+func (c *RedisScoreboard) GetStanding(nameWithEpoch string, entryID string, nextBand bool, order domain.SortOrder) (domain.ScoreboardStanding, error) {
+	ctx := context.Background()
+	results := c.client.DoMulti(ctx,
+		c.rank(nameWithEpoch, entryID, order),
+		c.client.B().Zscore().Key(nameWithEpoch).Member(entryID).Build(),
+		c.client.B().Zcard().Key(nameWithEpoch).Build(),
+		c.client.B().Hexists().Key(exactKey(nameWithEpoch)).Field(entryID).Build(),
+	)
+
+	total, err := results[2].AsInt64()
+	if err != nil {
+		return domain.ScoreboardStanding{}, fmt.Errorf("failed to get total of entries: %v", err)
+	}
+	rank, err := results[0].AsInt64()
+	if rueidis.IsRedisNil(err) {
+		return domain.ScoreboardStanding{EntryID: entryID, Total: total}, nil
+	}
+	if err != nil {
+		return domain.ScoreboardStanding{}, fmt.Errorf("failed to get rank: %v", err)
+	}
+	score, err := results[1].AsFloat64()
+	if err != nil {
+		return domain.ScoreboardStanding{}, fmt.Errorf("failed to get score: %v", err)
+	}
+	shared, err := results[3].AsBool()
+	if err != nil {
+		return domain.ScoreboardStanding{}, fmt.Errorf("failed to get exact score: %v", err)
+	}
+	if shared {
+		rank, err = c.tiedRank(ctx, nameWithEpoch, entryID, score, rank, order)
+		if err != nil {
+			return domain.ScoreboardStanding{}, fmt.Errorf("failed to get rank: %v", err)
+		}
+	}
+
+	standing := domain.NewScoreboardStanding(entryID, score, rank+1, total)
+	if !nextBand {
+		return standing, nil
+	}
+
+	band, bandRank := standing.NextBand()
+	if bandRank == 0 {
+		return standing, nil
+	}
+	cmd := c.rangeByRank(nameWithEpoch, bandRank-1, bandRank-1, order)
+	m, err := c.client.Do(ctx, cmd).AsZScores()
+	if err != nil {
+		return domain.ScoreboardStanding{}, fmt.Errorf("failed to get next band score: %v", err)
+	}
+	if len(m) > 0 {
+		standing.NextBandTopPercent = band
+		standing.NextBandScore = m[0].Score
+	}
+	return standing, nil
+}
+
+// GetStats returns the aggregated statistics of a scoreboard, the mean is calculated from the
+// running sum of the scores, or paging through the whole scoreboard when it has none, and the
+// histogram uses the buckets as ascending boundaries
+func (c *RedisScoreboard) GetStats(nameWithEpoch string, buckets []float64) (domain.ScoreboardStats, error) {
+	ctx := context.Background()
+	results := c.client.DoMulti(ctx,
+		c.client.B().Zcard().Key(nameWithEpoch).Build(),
+		c.client.B().Zrange().Key(nameWithEpoch).Min("0").Max("0").Withscores().Build(),
+		c.client.B().Zrevrange().Key(nameWithEpoch).Start(0).Stop(0).Withscores().Build(),
+		c.client.B().Get().Key(sumKey(nameWithEpoch)).Build(),
+	)
+	total, err := results[0].AsInt64()
+	if err != nil {
+		return domain.ScoreboardStats{}, fmt.Errorf("failed to get total of entries: %v", err)
+	}
+	stats := domain.ScoreboardStats{Participants: total}
+	if total == 0 {
+		stats.Histogram = histogramBuckets(buckets)
+		return stats, nil
+	}
+
+	lowest, err := results[1].AsZScores()
+	if err != nil || len(lowest) == 0 {
+		return domain.ScoreboardStats{}, fmt.Errorf("failed to get min score: %v", err)
+	}
+	highest, err := results[2].AsZScores()
+	if err != nil || len(highest) == 0 {
+		return domain.ScoreboardStats{}, fmt.Errorf("failed to get max score: %v", err)
+	}
+	stats.Min = lowest[0].Score
+	stats.Max = highest[0].Score
+
+	median, err := c.scoresInRange(ctx, nameWithEpoch, (total-1)/2, total/2)
+	if err != nil {
+		return domain.ScoreboardStats{}, fmt.Errorf("failed to get median score: %v", err)
+	}
+	for _, m := range median {
+		stats.Median += m.Score / float64(len(median))
+	}
+
+	sum, err := results[3].AsFloat64()
+	if rueidis.IsRedisNil(err) {
+		sum, err = c.sumScores(ctx, nameWithEpoch, total)
+	}
+	if err != nil {
+		return domain.ScoreboardStats{}, fmt.Errorf("failed to get sum of scores: %v", err)
+	}
+	stats.Mean = sum / float64(total)
+
+	stats.Histogram = histogramBuckets(buckets)
+	if len(stats.Histogram) == 0 {
+		return stats, nil
+	}
+	cmds := make(rueidis.Commands, 0, len(stats.Histogram))
+	for _, b := range stats.Histogram {
+		cmds = append(cmds, c.client.B().Zcount().Key(nameWithEpoch).Min(lowerBound(b.From)).Max(upperBound(b.To)).Build())
+	}
+	for i, r := range c.client.DoMulti(ctx, cmds...) {
+		count, err := r.AsInt64()
+		if err != nil {
+			return domain.ScoreboardStats{}, fmt.Errorf("failed to count scores of histogram bucket: %v", err)
+		}
+		stats.Histogram[i].Count = count
+	}
+	return stats, nil
+}
+
+// sumScores sums the scores paging through a scoreboard without a running sum
+func (c *RedisScoreboard) sumScores(ctx context.Context, nameWithEpoch string, total int64) (float64, error) {
+	sum := 0.0
+	pageSize := int64(c.options.PageSize)
+	for start := int64(0); start < total; start += pageSize {
+		page, err := c.scoresInRange(ctx, nameWithEpoch, start, start+pageSize-1)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range page {
+			sum += p.Score
+		}
+	}
+	return sum, nil
+}
+
+func (c *RedisScoreboard) scoresInRange(ctx context.Context, nameWithEpoch string, start int64, stop int64) ([]rueidis.ZScore, error) {
+	cmd := c.client.B().Zrange().Key(nameWithEpoch).Min(strconv.FormatInt(start, 10)).Max(strconv.FormatInt(stop, 10)).Withscores().Build()
+	return c.client.Do(ctx, cmd).AsZScores()
+}
+
+// rangeResults returns the entries ranked between the zero based start and stop positions, the
+// entries which share a score with other values are ranked by their exact values
+func (c *RedisScoreboard) rangeResults(ctx context.Context, nameWithEpoch string, start int64, stop int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	replies := c.client.DoMulti(ctx,
+		c.rangeByRank(nameWithEpoch, start, stop, order),
+		c.client.B().Exists().Key(exactKey(nameWithEpoch)).Build(),
+	)
+	m, err := replies[0].AsZScores()
+	if err != nil {
+		return nil, err
+	}
+	exact, err := replies[1].AsBool()
+	if err != nil {
+		return nil, err
+	}
+	if exact {
+		err = c.rankTies(ctx, nameWithEpoch, start, m, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+	results := []domain.ScoreboardResult{}
+	for i, z := range m {
+		results = append(results, domain.ScoreboardResult{EntryID: z.Member, Score: z.Score, Rank: start + int64(i) + 1})
+	}
+	return results, nil
+}
+
+// rankTies orders the entries of a page ranked from start which share a score by their exact
+// values. Only the entries whose score may be shared by other values have one, the groups at the
+// edges of the page are read whole since they may start before or end after the page
+func (c *RedisScoreboard) rankTies(ctx context.Context, nameWithEpoch string, start int64, page []rueidis.ZScore, order domain.SortOrder) error {
+	members := make([]string, 0, len(page))
+	for _, z := range page {
+		members = append(members, z.Member)
+	}
+	values, err := c.exactValues(ctx, nameWithEpoch, members)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(page); {
+		j, shared := i, false
+		for ; j < len(page) && page[j].Score == page[i].Score; j++ {
+			shared = shared || values[page[j].Member] != nil
+		}
+		switch {
+		case !shared:
+		case i > 0 && j < len(page):
+			sortTied(members[i:j], values, order)
+			for k := i; k < j; k++ {
+				page[k].Member = members[k]
+			}
+		default:
+			tied, first, err := c.tiedEntries(ctx, nameWithEpoch, page[i].Score, order)
+			if err != nil {
+				return err
+			}
+			for k := i; k < j; k++ {
+				if n := start + int64(k) - first; n >= 0 && n < int64(len(tied)) {
+					page[k].Member = tied[n]
+				}
+			}
+		}
+		i = j
+	}
+	return nil
+}
+
+// entryRank returns the zero based rank of an entry, an entry whose score may be shared by other
+// values is ranked by its exact value among the entries with the score
+func (c *RedisScoreboard) entryRank(ctx context.Context, nameWithEpoch string, entryID string, order domain.SortOrder) (int64, error) {
+	replies := c.client.DoMulti(ctx,
+		c.rank(nameWithEpoch, entryID, order),
+		c.client.B().Zscore().Key(nameWithEpoch).Member(entryID).Build(),
+		c.client.B().Hexists().Key(exactKey(nameWithEpoch)).Field(entryID).Build(),
+	)
+	rank, err := replies[0].AsInt64()
+	if err != nil {
+		return 0, err
+	}
+	shared, err := replies[2].AsBool()
+	if err != nil || !shared {
+		return rank, err
+	}
+	score, err := replies[1].AsFloat64()
+	if err != nil {
+		return 0, err
+	}
+	return c.tiedRank(ctx, nameWithEpoch, entryID, score, rank, order)
+}
+
+// tiedRank returns the zero based rank of an entry among the entries with its score ranked by
+// their exact values, or the given rank when the entry has left them
+func (c *RedisScoreboard) tiedRank(ctx context.Context, nameWithEpoch string, entryID string, score float64, rank int64, order domain.SortOrder) (int64, error) {
+	tied, first, err := c.tiedEntries(ctx, nameWithEpoch, score, order)
+	if err != nil {
+		return 0, err
+	}
+	for i, member := range tied {
+		if member == entryID {
+			return first + int64(i), nil
+		}
+	}
+	return rank, nil
+}
+
+// tiedEntries returns the entries with a score ranked by their exact values along with the zero
+// based rank of the first of them
+func (c *RedisScoreboard) tiedEntries(ctx context.Context, nameWithEpoch string, score float64, order domain.SortOrder) ([]string, int64, error) {
+	s := strconv.FormatFloat(score, 'f', -1, 64)
+	ahead := c.client.B().Zcount().Key(nameWithEpoch).Min("(" + s).Max("+inf").Build()
+	if order == domain.Ascending {
+		ahead = c.client.B().Zcount().Key(nameWithEpoch).Min("-inf").Max("(" + s).Build()
+	}
+	replies := c.client.DoMulti(ctx, c.client.B().Zrangebyscore().Key(nameWithEpoch).Min(s).Max(s).Build(), ahead)
+	tied, err := replies[0].AsStrSlice()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tied entries: %v", err)
+	}
+	first, err := replies[1].AsInt64()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count entries ahead: %v", err)
+	}
+	values, err := c.exactValues(ctx, nameWithEpoch, tied)
+	if err != nil {
+		return nil, 0, err
+	}
+	sortTied(tied, values, order)
+	return tied, first, nil
+}
+
+// exactValues returns the exact values kept for the entries, the entries without one are skipped
+func (c *RedisScoreboard) exactValues(ctx context.Context, nameWithEpoch string, entryIDs []string) (map[string]*big.Rat, error) {
+	values := map[string]*big.Rat{}
+	if len(entryIDs) == 0 {
+		return values, nil
+	}
+	replies, err := c.client.Do(ctx, c.client.B().Hmget().Key(exactKey(nameWithEpoch)).Field(entryIDs...).Build()).ToArray()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exact scores: %v", err)
+	}
+	for i, reply := range replies {
+		if reply.IsNil() {
+			continue
+		}
+		v, err := reply.ToString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exact score of '%v': %v", entryIDs[i], err)
+		}
+		value, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid exact score of '%v': %v", entryIDs[i], v)
+		}
+		values[entryIDs[i]] = value
+	}
+	return values, nil
+}
+
+// sortTied sorts the entries which share a score by their exact values in the sort order, the
+// entries with the same value are ordered by member like redis orders them
+func sortTied(entryIDs []string, values map[string]*big.Rat, order domain.SortOrder) {
+	sort.SliceStable(entryIDs, func(i, j int) bool {
+		cmp := 0
+		if a, b := values[entryIDs[i]], values[entryIDs[j]]; a != nil && b != nil {
+			cmp = a.Cmp(b)
+		}
+		if cmp == 0 {
+			cmp = compareStrings(entryIDs[i], entryIDs[j])
+		}
+		if order == domain.Ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+}
+
+func compareStrings(a string, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// rangeByRank builds the command that lists the entries between two ranks in the sort order
+func (c *RedisScoreboard) rangeByRank(nameWithEpoch string, start int64, stop int64, order domain.SortOrder) rueidis.Completed {
+	if order == domain.Ascending {
+		return c.client.B().Zrange().Key(nameWithEpoch).Min(strconv.FormatInt(start, 10)).Max(strconv.FormatInt(stop, 10)).Withscores().Build()
+	}
+	return c.client.B().Zrevrange().Key(nameWithEpoch).Start(start).Stop(stop).Withscores().Build()
+}
+
+// rank builds the command that returns the zero based rank of an entry in the sort order
+func (c *RedisScoreboard) rank(nameWithEpoch string, entryID string, order domain.SortOrder) rueidis.Completed {
+	if order == domain.Ascending {
+		return c.client.B().Zrank().Key(nameWithEpoch).Member(entryID).Build()
+	}
+	return c.client.B().Zrevrank().Key(nameWithEpoch).Member(entryID).Build()
+}
+
+// histogramBuckets creates the histogram buckets from the boundaries including the unbounded ones
+func histogramBuckets(boundaries []float64) []domain.HistogramBucket {
+	if len(boundaries) == 0 {
+		return nil
+	}
+	buckets := make([]domain.HistogramBucket, 0, len(boundaries)+1)
+	var from *float64
+	for i := range boundaries {
+		to := &boundaries[i]
+		buckets = append(buckets, domain.HistogramBucket{From: from, To: to})
+		from = to
+	}
+	return append(buckets, domain.HistogramBucket{From: from})
+}
+
+func lowerBound(v *float64) string {
+	if v == nil {
+		return "-inf"
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func upperBound(v *float64) string {
+	if v == nil {
+		return "+inf"
+	}
+	return "(" + strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// Allow implements the RateLimiter interface with keys that expire after the interval, an action
+// is allowed when its key does not exist
+func (c *RedisScoreboard) Allow(key string, interval time.Duration) (bool, error) {
+	cmd := c.client.B().Set().Key(key).Value("1").Nx().PxMilliseconds(interval.Milliseconds()).Build()
 	err := c.client.Do(context.Background(), cmd).Error()
-	return err
+	if rueidis.IsRedisNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set rate limit key: %v", err)
+	}
+	return true, nil
 }
 
-// TODO: check the return of the functtion to match the Rank type in the result
+// Keys returns the scoreboards matching a glob pattern
+func (c *RedisScoreboard) Keys(pattern string) ([]string, error) {
+	ctx := context.Background()
+	keys := []string{}
+	cursor := uint64(0)
+	for {
+		cmd := c.client.B().Scan().Cursor(cursor).Match(pattern).Count(int64(c.options.PageSize)).Type("zset").Build()
+		entry, err := c.client.Do(ctx, cmd).AsScanEntry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keys: %v", err)
+		}
+		keys = append(keys, entry.Elements...)
+		if entry.Cursor == 0 {
+			return keys, nil
+		}
+		cursor = entry.Cursor
+	}
+}
 
-// GetRank ...
-func (c *RedisScoreboard) GetRank(nameWithEpoch string, entryID string) (uint64, error) {
-	cmd := c.client.B().Zrevrank().Key(nameWithEpoch).Member(entryID).Build()
-	score, err := c.client.Do(context.Background(), cmd).AsInt64()
+// renameScript renames a scoreboard, its running sum and its exact values when the new name does
+// not exist yet
+var renameScript = rueidis.NewLuaScript(`
+if redis.call('RENAMENX', KEYS[1], KEYS[3]) == 0 then
+	return 0
+end
+redis.call('DEL', KEYS[4], KEYS[6])
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	redis.call('RENAME', KEYS[2], KEYS[4])
+end
+if redis.call('EXISTS', KEYS[5]) == 1 then
+	redis.call('RENAME', KEYS[5], KEYS[6])
+end
+return 1
+`)
+
+// Rename renames a scoreboard when the new name does not exist yet
+func (c *RedisScoreboard) Rename(from string, to string) (bool, error) {
+	keys := []string{from, sumKey(from), to, sumKey(to), exactKey(from), exactKey(to)}
+	renamed, err := renameScript.Exec(context.Background(), c.client, keys, nil).AsBool()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get rank: %v", err)
+		return false, fmt.Errorf("failed to rename scoreboard: %v", err)
 	}
-	return uint64(score) + 1, nil
+	return renamed, nil
 }
@@ -2,14 +2,29 @@ package scoreboard
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/redis/rueidis"
 	mock "github.com/redis/rueidis/mock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+// evalsha matches the execution of a script with the keys and arguments
+func evalsha(keys []string, args ...string) gomock.Matcher {
+	want := append([]string{strconv.Itoa(len(keys))}, keys...)
+	want = append(want, args...)
+	return mock.MatchFn(func(cmd []string) bool {
+		return cmd[0] == "EVALSHA" && slices.Equal(cmd[2:], want)
+	}, fmt.Sprintf("EVALSHA %v", want))
+}
+
 func TestNewRedisScoreboard(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -30,12 +45,34 @@ func TestAddScore(t *testing.T) {
 	lbName := testutil.NewUnique(testutil.Name(t))
 	entryID := testutil.NewID()
 
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "1", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "1", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 
 	err := board.AddScore(entryID, lbName, 1)
 	assert.Nil(t, err)
 }
 
+func TestRemoveScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, entryID)).Return(mock.Result(mock.RedisInt64(1)))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, entryID)).Return(mock.Result(mock.RedisInt64(0)))
+
+	removed, err := board.RemoveScore(entryID, lbName)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = board.RemoveScore(entryID, lbName)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
 func TestGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -49,21 +86,24 @@ func TestGet(t *testing.T) {
 	entryID := testutil.NewID()
 	entryID2 := testutil.NewID()
 
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "5", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "5", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err := board.AddScore(entryID, lbName, 5)
 	assert.Nil(t, err)
 
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "10", entryID2)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "10", entryID2, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err = board.AddScore(entryID2, lbName, 10)
 	assert.Nil(t, err)
 
-	c.EXPECT().Do(ctx, mock.Match("ZREVRANGE", lbName, "0", "50", "WITHSCORES")).Return(mock.Result(mock.RedisArray(
-		mock.RedisString(entryID2),
-		mock.RedisString("10"),
-		mock.RedisString(entryID),
-		mock.RedisString("5"),
-	)))
-	r, err := board.Get(lbName)
+	c.EXPECT().DoMulti(ctx, mock.Match("ZREVRANGE", lbName, "0", "50", "WITHSCORES"), mock.Match("EXISTS", exactKey(lbName))).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisArray(
+			mock.RedisString(entryID2),
+			mock.RedisString("10"),
+			mock.RedisString(entryID),
+			mock.RedisString("5"),
+		)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	r, err := board.Get(lbName, domain.Descending)
 	assert.Nil(t, err)
 	assert.NotNil(t, r)
 	assert.Len(t, r, 2)
@@ -71,6 +111,46 @@ func TestGet(t *testing.T) {
 	assert.Equal(t, entryID, r[1].EntryID)
 }
 
+func TestGetAscending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	entryID2 := testutil.NewID()
+
+	c.EXPECT().DoMulti(ctx, mock.Match("ZRANGE", lbName, "0", "50", "WITHSCORES"), mock.Match("EXISTS", exactKey(lbName))).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisArray(
+			mock.RedisString(entryID),
+			mock.RedisString("5"),
+			mock.RedisString(entryID2),
+			mock.RedisString("10"),
+		)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	r, err := board.Get(lbName, domain.Ascending)
+	assert.Nil(t, err)
+	assert.Len(t, r, 2)
+	assert.Equal(t, entryID, r[0].EntryID)
+	assert.Equal(t, int64(1), r[0].Rank)
+
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZRANK", lbName, entryID2),
+		mock.Match("ZSCORE", lbName, entryID2),
+		mock.Match("HEXISTS", exactKey(lbName), entryID2),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(1)),
+		mock.Result(mock.RedisString("10")),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	rank, err := board.GetRank(lbName, entryID2, domain.Ascending)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), rank)
+}
+
 func TestGetRank(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -82,29 +162,379 @@ func TestGetRank(t *testing.T) {
 	lbName := testutil.NewUnique(testutil.Name(t))
 
 	entryID := testutil.NewID()
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "5", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "5", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err := board.AddScore(entryID, lbName, 5)
 	assert.Nil(t, err)
 
 	entryID = testutil.NewID()
 
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "25", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "25", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err = board.AddScore(entryID, lbName, 25)
 	assert.Nil(t, err)
 
 	entryID = testutil.NewID()
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "50", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "50", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err = board.AddScore(entryID, lbName, 50)
 	assert.Nil(t, err)
 
 	entryID = testutil.NewID()
-	c.EXPECT().Do(ctx, mock.Match("ZADD", lbName, "45", entryID)).Return(mock.Result(mock.RedisString("does-not-matter")))
+	c.EXPECT().Do(ctx, evalsha([]string{lbName, sumKey(lbName), exactKey(lbName)}, "45", entryID, "")).Return(mock.Result(mock.RedisString("does-not-matter")))
 	err = board.AddScore(entryID, lbName, 45)
 	assert.Nil(t, err)
 
-	c.EXPECT().Do(ctx, mock.Match("ZREVRANK", lbName, entryID)).Return(mock.Result(mock.RedisInt64(2)))
-	r, err := board.GetRank(lbName, entryID)
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZREVRANK", lbName, entryID),
+		mock.Match("ZSCORE", lbName, entryID),
+		mock.Match("HEXISTS", exactKey(lbName), entryID),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(2)),
+		mock.Result(mock.RedisString("45")),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	r, err := board.GetRank(lbName, entryID, domain.Descending)
 	assert.Nil(t, err)
 	assert.NotNil(t, r)
 	assert.Equal(t, r, uint64(3))
 }
+
+func TestGetScores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	entryID2 := testutil.NewID()
+
+	c.EXPECT().Do(ctx, mock.Match("ZMSCORE", lbName, entryID, entryID2)).Return(mock.Result(mock.RedisArray(
+		mock.RedisString("10"),
+		mock.RedisNil(),
+	)))
+	r, err := board.GetScores(lbName, []string{entryID, entryID2})
+	assert.Nil(t, err)
+	assert.Len(t, r, 1)
+	assert.Equal(t, entryID, r[0].EntryID)
+	assert.Equal(t, float64(10), r[0].Score)
+}
+
+func TestGetStanding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZREVRANK", lbName, entryID),
+		mock.Match("ZSCORE", lbName, entryID),
+		mock.Match("ZCARD", lbName),
+		mock.Match("HEXISTS", exactKey(lbName), entryID),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(6)),
+		mock.Result(mock.RedisString("40")),
+		mock.Result(mock.RedisInt64(100)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	c.EXPECT().Do(ctx, mock.Match("ZREVRANGE", lbName, "5", "5", "WITHSCORES")).Return(mock.Result(mock.RedisArray(
+		mock.RedisString(testutil.NewID()),
+		mock.RedisString("55"),
+	)))
+
+	s, err := board.GetStanding(lbName, entryID, true, domain.Descending)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), s.Rank)
+	assert.Equal(t, int64(100), s.Total)
+	assert.Equal(t, float64(40), s.Score)
+	assert.Equal(t, float64(7), s.TopPercent)
+	assert.Equal(t, float64(6), s.NextBandTopPercent)
+	assert.Equal(t, float64(55), s.NextBandScore)
+}
+
+func TestGetStandingNotRanked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+
+	c.EXPECT().DoMulti(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisNil()),
+		mock.Result(mock.RedisNil()),
+		mock.Result(mock.RedisInt64(100)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+
+	s, err := board.GetStanding(lbName, entryID, true, domain.Descending)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), s.Rank)
+	assert.Equal(t, int64(100), s.Total)
+}
+
+func TestGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZCARD", lbName),
+		mock.Match("ZRANGE", lbName, "0", "0", "WITHSCORES"),
+		mock.Match("ZREVRANGE", lbName, "0", "0", "WITHSCORES"),
+		mock.Match("GET", sumKey(lbName)),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(3)),
+		mock.Result(mock.RedisArray(mock.RedisString("a"), mock.RedisString("1"))),
+		mock.Result(mock.RedisArray(mock.RedisString("c"), mock.RedisString("30"))),
+		mock.Result(mock.RedisNil()),
+	})
+	c.EXPECT().Do(ctx, mock.Match("ZRANGE", lbName, "1", "1", "WITHSCORES")).Return(mock.Result(mock.RedisArray(
+		mock.RedisString("b"), mock.RedisString("5"),
+	)))
+	c.EXPECT().Do(ctx, mock.Match("ZRANGE", lbName, "0", "999", "WITHSCORES")).Return(mock.Result(mock.RedisArray(
+		mock.RedisString("a"), mock.RedisString("1"),
+		mock.RedisString("b"), mock.RedisString("5"),
+		mock.RedisString("c"), mock.RedisString("30"),
+	)))
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZCOUNT", lbName, "-inf", "(10"),
+		mock.Match("ZCOUNT", lbName, "10", "+inf"),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(2)),
+		mock.Result(mock.RedisInt64(1)),
+	})
+
+	s, err := board.GetStats(lbName, []float64{10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), s.Participants)
+	assert.Equal(t, float64(1), s.Min)
+	assert.Equal(t, float64(30), s.Max)
+	assert.Equal(t, float64(5), s.Median)
+	assert.Equal(t, float64(12), s.Mean)
+	assert.Len(t, s.Histogram, 2)
+	assert.Nil(t, s.Histogram[0].From)
+	assert.Equal(t, int64(2), s.Histogram[0].Count)
+	assert.Nil(t, s.Histogram[1].To)
+	assert.Equal(t, int64(1), s.Histogram[1].Count)
+}
+
+func TestGetStatsRunningSum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+
+	// the mean is calculated from the running sum without paging through the scoreboard
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZCARD", lbName),
+		mock.Match("ZRANGE", lbName, "0", "0", "WITHSCORES"),
+		mock.Match("ZREVRANGE", lbName, "0", "0", "WITHSCORES"),
+		mock.Match("GET", sumKey(lbName)),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(4)),
+		mock.Result(mock.RedisArray(mock.RedisString("a"), mock.RedisString("1"))),
+		mock.Result(mock.RedisArray(mock.RedisString("d"), mock.RedisString("30"))),
+		mock.Result(mock.RedisString("40")),
+	})
+	c.EXPECT().Do(ctx, mock.Match("ZRANGE", lbName, "1", "2", "WITHSCORES")).Return(mock.Result(mock.RedisArray(
+		mock.RedisString("b"), mock.RedisString("4"),
+		mock.RedisString("c"), mock.RedisString("5"),
+	)))
+
+	s, err := board.GetStats(lbName, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(10), s.Mean)
+	assert.Equal(t, 4.5, s.Median)
+}
+
+func TestRename(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	from := testutil.NewUnique(testutil.Name(t))
+	to := testutil.NewUnique(testutil.Name(t))
+
+	c.EXPECT().Do(ctx, evalsha([]string{from, sumKey(from), to, sumKey(to), exactKey(from), exactKey(to)})).Return(mock.Result(mock.RedisInt64(1)))
+	renamed, err := board.Rename(from, to)
+	assert.Nil(t, err)
+	assert.True(t, renamed)
+
+	c.EXPECT().Do(ctx, evalsha([]string{from, sumKey(from), to, sumKey(to), exactKey(from), exactKey(to)})).Return(mock.Result(mock.RedisInt64(0)))
+	renamed, err = board.Rename(from, to)
+	assert.Nil(t, err)
+	assert.False(t, renamed)
+}
+
+func TestGetNeighbours(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZREVRANK", lbName, "p3"),
+		mock.Match("ZSCORE", lbName, "p3"),
+		mock.Match("HEXISTS", exactKey(lbName), "p3"),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(1)),
+		mock.Result(mock.RedisString("40")),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	c.EXPECT().DoMulti(ctx, mock.Match("ZREVRANGE", lbName, "0", "3", "WITHSCORES"), mock.Match("EXISTS", exactKey(lbName))).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisArray(
+			mock.RedisString("p1"),
+			mock.RedisString("50"),
+			mock.RedisString("p3"),
+			mock.RedisString("40"),
+			mock.RedisString("p2"),
+			mock.RedisString("30"),
+		)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	results, err := board.GetNeighbours(lbName, "p3", 3, 2, domain.Descending)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ScoreboardResult{
+		{EntryID: "p1", Score: 50, Rank: 1},
+		{EntryID: "p2", Score: 30, Rank: 3},
+	}, results)
+
+	c.EXPECT().DoMulti(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisNil()),
+		mock.Result(mock.RedisNil()),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	results, err = board.GetNeighbours(lbName, "p4", 3, 0, domain.Ascending)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestAllow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+
+	c.EXPECT().Do(ctx, mock.Match("SET", "overtaken::p1", "1", "NX", "PX", "60000")).Return(mock.Result(mock.RedisString("OK")))
+	allowed, err := board.Allow("overtaken::p1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	c.EXPECT().Do(ctx, mock.Match("SET", "overtaken::p1", "1", "NX", "PX", "60000")).Return(mock.Result(mock.RedisNil()))
+	allowed, err = board.Allow("overtaken::p1", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestGetRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+
+	c.EXPECT().DoMulti(ctx, mock.Match("ZREVRANGE", lbName, "2", "3", "WITHSCORES"), mock.Match("EXISTS", exactKey(lbName))).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisArray(
+			mock.RedisString("p3"),
+			mock.RedisString("30"),
+			mock.RedisString("p4"),
+			mock.RedisString("20"),
+		)),
+		mock.Result(mock.RedisInt64(0)),
+	})
+	results, err := board.GetRange(lbName, 2, 3, domain.Descending)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ScoreboardResult{
+		{EntryID: "p3", Score: 30, Rank: 3},
+		{EntryID: "p4", Score: 20, Rank: 4},
+	}, results)
+}
+
+func TestExactScoreTies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := mock.NewClient(ctrl)
+	board := NewRedisScoreboardWithClient(c)
+	ctx := context.Background()
+	lbName := testutil.NewUnique(testutil.Name(t))
+	keys := []string{lbName, sumKey(lbName), exactKey(lbName)}
+	// the three values share the float64 2^63
+	shared := "9223372036854776000"
+
+	c.EXPECT().Do(ctx, evalsha(keys, shared, "a", "9223372036854775807")).Return(mock.Result(mock.RedisInt64(1)))
+	err := board.AddExactScore("a", lbName, domain.ExactScore{Value: "9223372036854775807", Score: 9223372036854775807})
+	assert.NoError(t, err)
+	// the values which do not share their float64 are not kept
+	c.EXPECT().Do(ctx, evalsha(keys, "10", "d", "")).Return(mock.Result(mock.RedisInt64(1)))
+	err = board.AddExactScore("d", lbName, domain.ExactScore{Value: "10", Score: 10})
+	assert.NoError(t, err)
+
+	tied := func() {
+		c.EXPECT().DoMulti(ctx,
+			mock.Match("ZRANGEBYSCORE", lbName, shared, shared),
+			mock.Match("ZCOUNT", lbName, "("+shared, "+inf"),
+		).Return([]rueidis.RedisResult{
+			mock.Result(mock.RedisArray(mock.RedisString("a"), mock.RedisString("b"), mock.RedisString("c"))),
+			mock.Result(mock.RedisInt64(0)),
+		})
+		c.EXPECT().Do(ctx, mock.Match("HMGET", exactKey(lbName), "a", "b", "c")).Return(mock.Result(mock.RedisArray(
+			mock.RedisString("9223372036854775807"),
+			mock.RedisString("9223372036854775806"),
+			mock.RedisString("9223372036854775500"),
+		)))
+	}
+
+	// redis orders the tied entries by member, they are ranked by their values
+	c.EXPECT().DoMulti(ctx, mock.Match("ZREVRANGE", lbName, "0", "1", "WITHSCORES"), mock.Match("EXISTS", exactKey(lbName))).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisArray(mock.RedisString("c"), mock.RedisString(shared), mock.RedisString("b"), mock.RedisString(shared))),
+		mock.Result(mock.RedisInt64(1)),
+	})
+	c.EXPECT().Do(ctx, mock.Match("HMGET", exactKey(lbName), "c", "b")).Return(mock.Result(mock.RedisArray(
+		mock.RedisString("9223372036854775500"),
+		mock.RedisString("9223372036854775806"),
+	)))
+	tied()
+	results, err := board.GetRange(lbName, 0, 1, domain.Descending)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ScoreboardResult{
+		{EntryID: "a", Score: 9223372036854775807, Rank: 1},
+		{EntryID: "b", Score: 9223372036854775807, Rank: 2},
+	}, results)
+
+	c.EXPECT().DoMulti(ctx,
+		mock.Match("ZREVRANK", lbName, "c"),
+		mock.Match("ZSCORE", lbName, "c"),
+		mock.Match("HEXISTS", exactKey(lbName), "c"),
+	).Return([]rueidis.RedisResult{
+		mock.Result(mock.RedisInt64(0)),
+		mock.Result(mock.RedisString(shared)),
+		mock.Result(mock.RedisInt64(1)),
+	})
+	tied()
+	rank, err := board.GetRank(lbName, "c", domain.Descending)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), rank)
+}
@@ -0,0 +1,82 @@
+// Package webhook delivers the award batches of closed epochs to HTTP webhooks
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+const (
+	// SignatureHeader holds the signature of the request, see Sign
+	SignatureHeader = "X-Simpleboards-Signature"
+	// TimestampHeader holds the unix time the request was signed at
+	TimestampHeader = "X-Simpleboards-Timestamp"
+	// DeliveryHeader holds the id of the batch, which receivers can use to ignore retries
+	DeliveryHeader = "X-Simpleboards-Delivery"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// HTTPNotifier implements the PrizeNotifier interface posting award batches as JSON
+type HTTPNotifier struct {
+	client *http.Client
+}
+
+// NewHTTPNotifier creates a new HTTP notifier, a client with a 10s timeout is used when the
+// client is nil
+func NewHTTPNotifier(client *http.Client) *HTTPNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &HTTPNotifier{client: client}
+}
+
+// Notify posts an award batch to the webhook, any status other than 2xx is an error
+func (n *HTTPNotifier) Notify(webhook domain.WebhookConfig, batch domain.AwardBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal award batch: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, batch.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	if webhook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post award batch: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature of a request, the hex HMAC-SHA256 with the secret of the timestamp
+// and the body joined by a dot, prefixed with sha256=
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
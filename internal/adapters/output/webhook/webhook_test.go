@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotify(t *testing.T) {
+	batch := domain.AwardBatch{
+		ID:          "weekly::3",
+		Leaderboard: "weekly",
+		Epoch:       3,
+		Awards:      []domain.PrizeAward{{EntryID: "p1", Rank: 1, Score: 100, Action: "gems:500", Epoch: 3}},
+	}
+
+	var received domain.AwardBatch
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign("secret", r.Header.Get(TimestampHeader), body), r.Header.Get(SignatureHeader))
+		assert.Equal(t, "weekly::3", r.Header.Get(DeliveryHeader))
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer stub.Close()
+
+	n := NewHTTPNotifier(nil)
+	err := n.Notify(domain.WebhookConfig{URL: stub.URL, Secret: "secret"}, batch)
+	assert.NoError(t, err)
+	assert.Equal(t, batch, received)
+}
+
+func TestNotifyFailure(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer stub.Close()
+
+	n := NewHTTPNotifier(nil)
+	err := n.Notify(domain.WebhookConfig{URL: stub.URL}, domain.AwardBatch{ID: "weekly::3"})
+	assert.ErrorContains(t, err, "503")
+}
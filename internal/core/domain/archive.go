@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// EpochArchive holds the final standings of the global scoreboard, which comes first, and every
+// scoreboard of a closed leaderboard epoch, or of the scoreboards read from it
+type EpochArchive struct {
+	Leaderboard string              `json:"leaderboard"`
+	Epoch       int64               `json:"epoch"`
+	ArchivedAt  time.Time           `json:"archived_at"`
+	Scoreboards []LeaderboardScores `json:"scoreboards"`
+}
+
+// Scoreboard returns the standings of a scoreboard of the archive by name
+func (a EpochArchive) Scoreboard(name string) (LeaderboardScores, bool) {
+	for _, sb := range a.Scoreboards {
+		if sb.Name == name {
+			return sb, true
+		}
+	}
+	return LeaderboardScores{}, false
+}
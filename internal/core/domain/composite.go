@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxCompositeDigits is the number of decimal digits a float64 represents exactly
+const maxCompositeDigits = 15
+
+// SortOrder enum for the direction values are ranked in
+type SortOrder int
+
+const (
+	// Descending ranks the highest value first
+	Descending SortOrder = iota
+	// Ascending ranks the lowest value first
+	Ascending
+)
+
+// ScoreComponent configures a component of a composite score, components are ranked in the
+// order they are configured
+type ScoreComponent struct {
+	Name  string    `json:"name"`
+	Order SortOrder `json:"order"`
+	// Digits is the number of integer digits reserved for the values of the component
+	Digits int `json:"digits"`
+}
+
+// ScoreComponents is the list of components of a composite score.
+// Values are packed in a single score where each component takes its digits, with ascending
+// components complemented so the highest score is always the best
+type ScoreComponents []ScoreComponent
+
+// CompositeScore is a list of component values along with their packed score
+type CompositeScore struct {
+	Components []float64
+	Score      float64
+}
+
+// Validate checks the components fit in a score
+func (c ScoreComponents) Validate() error {
+	digits := 0
+	names := make(map[string]struct{}, len(c))
+	for _, sc := range c {
+		if sc.Digits <= 0 {
+			return fmt.Errorf("component '%v' must reserve digits", sc.Name)
+		}
+		if _, ok := names[sc.Name]; ok {
+			return fmt.Errorf("duplicated component '%v'", sc.Name)
+		}
+		names[sc.Name] = struct{}{}
+		digits += sc.Digits
+	}
+	if digits > maxCompositeDigits {
+		return fmt.Errorf("components reserve %d digits, up to %d are supported", digits, maxCompositeDigits)
+	}
+	return nil
+}
+
+// Encode packs the values of the components in a score
+func (c ScoreComponents) Encode(values []float64) (CompositeScore, error) {
+	if len(values) != len(c) {
+		return CompositeScore{}, fmt.Errorf("expected %d components, got %d", len(c), len(values))
+	}
+	score := 0.0
+	for i, sc := range c {
+		v := values[i]
+		limit := math.Pow10(sc.Digits)
+		if v != math.Trunc(v) || v < 0 || v >= limit {
+			return CompositeScore{}, fmt.Errorf("component '%v' must be an integer from 0 to %.0f: %v", sc.Name, limit-1, v)
+		}
+		if sc.Order == Ascending {
+			v = limit - 1 - v
+		}
+		score = score*limit + v
+	}
+	return CompositeScore{Components: values, Score: score}, nil
+}
+
+// Decode unpacks the values of the components of a score
+func (c ScoreComponents) Decode(score float64) []float64 {
+	values := make([]float64, len(c))
+	for i := len(c) - 1; i >= 0; i-- {
+		limit := math.Pow10(c[i].Digits)
+		v := math.Mod(score, limit)
+		score = math.Trunc(score / limit)
+		if c[i].Order == Ascending {
+			v = limit - 1 - v
+		}
+		values[i] = v
+	}
+	return values
+}
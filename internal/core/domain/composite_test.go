@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeOrdering(t *testing.T) {
+	c := ScoreComponents{
+		{Name: "points", Order: Descending, Digits: 6},
+		{Name: "moves", Order: Ascending, Digits: 4},
+	}
+	assert.NoError(t, c.Validate())
+
+	best, err := c.Encode([]float64{500, 10})
+	assert.NoError(t, err)
+	fewerMoves, err := c.Encode([]float64{400, 3})
+	assert.NoError(t, err)
+	moreMoves, err := c.Encode([]float64{400, 12})
+	assert.NoError(t, err)
+
+	assert.Greater(t, best.Score, fewerMoves.Score)
+	assert.Greater(t, fewerMoves.Score, moreMoves.Score)
+	assert.Equal(t, []float64{400, 12}, c.Decode(moreMoves.Score))
+	assert.Equal(t, []float64{500, 10}, c.Decode(best.Score))
+}
+
+func TestCompositeInvalid(t *testing.T) {
+	c := ScoreComponents{{Name: "points", Digits: 2}}
+	_, err := c.Encode([]float64{100})
+	assert.Error(t, err)
+	_, err = c.Encode([]float64{1.5})
+	assert.Error(t, err)
+	_, err = c.Encode([]float64{1, 2})
+	assert.Error(t, err)
+
+	assert.Error(t, ScoreComponents{{Name: "a", Digits: 10}, {Name: "b", Digits: 6}}.Validate())
+	assert.Error(t, ScoreComponents{{Name: "a", Digits: 1}, {Name: "a", Digits: 1}}.Validate())
+	assert.Error(t, LeaderboardConfig{Function: Sum, Components: c}.Validate())
+	descending := Descending
+	assert.Error(t, LeaderboardConfig{Function: Min, SortOrder: &descending, Components: c}.Validate())
+	assert.NoError(t, LeaderboardConfig{Function: Last, Components: c}.Validate())
+}
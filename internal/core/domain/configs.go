@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// ConfigAction is what applying a leaderboard configuration does to the stored one
+type ConfigAction string
+
+const (
+	// ConfigCreate stores a leaderboard which is not stored yet
+	ConfigCreate ConfigAction = "create"
+	// ConfigUpdate replaces a stored leaderboard which changed
+	ConfigUpdate ConfigAction = "update"
+	// ConfigUnchanged is a leaderboard which is stored as it is
+	ConfigUnchanged ConfigAction = "unchanged"
+	// ConfigUnmanaged is a stored leaderboard which is not in the applied configurations, it is
+	// kept as it is
+	ConfigUnmanaged ConfigAction = "unmanaged"
+)
+
+// ConfigChange is the change of a leaderboard configuration, Fields holds the changed fields of
+// creations and updates
+type ConfigChange struct {
+	Name   string              `json:"name"`
+	Action ConfigAction        `json:"action"`
+	Fields []ConfigFieldChange `json:"fields,omitempty"`
+}
+
+// ConfigFieldChange is a changed field of a configuration identified by its JSON path, From and
+// To are the JSON values which are empty when the field is not set
+type ConfigFieldChange struct {
+	Path string `json:"path"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// ConfigStatus is the state of the leaderboard configurations loaded by a provider, the version
+// is the same for providers with the same configurations
+type ConfigStatus struct {
+	Version      string         `json:"version"`
+	LoadedAt     time.Time      `json:"loaded_at"`
+	Leaderboards []string       `json:"leaderboards"`
+	Broken       []BrokenConfig `json:"broken"`
+	// Error is the error of the last refresh which did not load any configuration
+	Error string `json:"error,omitempty"`
+}
+
+// BrokenConfig is a leaderboard configuration which failed to load, Serving is true when the
+// last good configuration of the leaderboard is still provided
+type BrokenConfig struct {
+	Name    string `json:"name"`
+	Error   string `json:"error"`
+	Serving bool   `json:"serving"`
+}
@@ -4,13 +4,28 @@ import (
 	"fmt"
 	"math"
 	"time"
+	// embeds the time zone database so reset time zones load in minimal images
+	_ "time/tzdata"
 
 	"github.com/gorhill/cronexpr"
 )
 
+const (
+	// anchorDateLayout is the layout of anchors given as a date in the reset time zone
+	anchorDateLayout = "2006-01-02"
+	// longestEpochSample bounds the occurrences checked to find the longest epoch and to check
+	// the occurrences are evenly spaced
+	longestEpochSample = 10000
+)
+
 // CronExpression data
 type CronExpression struct {
 	expr     *cronexpr.Expression
+	schedule *schedule
+	window   *window
+	manual   bool
+	location *time.Location
+	legacy   bool
 	first    time.Time
 	second   time.Time
 	interval int64
@@ -20,6 +35,8 @@ type CronExpression struct {
 func NewCronExpression(reset ResetExpression) (CronExpression, error) {
 	var e string
 	switch reset.Type {
+	case Manually:
+		return CronExpression{manual: true}, nil
 	case Hourly:
 		e = "0 * * * *"
 	case Daily:
@@ -28,10 +45,17 @@ func NewCronExpression(reset ResetExpression) (CronExpression, error) {
 		e = "0 0 * * 7"
 	case Monthly:
 		e = "0 0 1 * *"
+	case Window:
+		return newWindowExpression(reset)
 	default:
 		e = reset.CronExpression
 	}
 
+	location, err := time.LoadLocation(reset.TimeZone)
+	if err != nil {
+		return CronExpression{}, fmt.Errorf("failed to load time zone '%v': %v", reset.TimeZone, err)
+	}
+
 	initUnix := time.Unix(0, 0).UTC()
 	expr, err := cronexpr.Parse(e)
 	if err != nil {
@@ -42,17 +66,190 @@ func NewCronExpression(reset ResetExpression) (CronExpression, error) {
 	second := expr.Next(first)
 	intervalSecs := second.Sub(first).Seconds()
 
-	return CronExpression{
+	ce := CronExpression{
 		expr:     expr,
+		location: location,
+		legacy:   reset.Anchor == "",
 		first:    first,
 		second:   second,
 		interval: int64(intervalSecs),
-	}, nil
+	}
+
+	// without anchor the epochs keep the fixed interval numbering of the leaderboards created
+	// before anchors, the occurrences are counted from the anchor only when it is set. The fixed
+	// interval only matches the resets of expressions whose occurrences are evenly spaced, the
+	// others require an anchor
+	anchorFirst := first
+	if ce.legacy && !ce.isRegular() {
+		return CronExpression{}, fmt.Errorf("cron expression '%v' has irregular intervals and requires an anchor", e)
+	}
+	if !ce.legacy {
+		anchor, err := ce.parseAnchor(reset.Anchor)
+		if err != nil {
+			return CronExpression{}, err
+		}
+		anchorFirst = expr.Next(anchor.Add(-time.Second))
+	}
+	if anchorFirst.IsZero() {
+		return CronExpression{}, fmt.Errorf("cron expression '%v' has no occurrences after the anchor", e)
+	}
+	ce.schedule = scheduleFor(e, expr, anchorFirst)
+	return ce, nil
+}
+
+// isRegular checks if the occurrences of the expression are evenly spaced in the wall clock
+func (e *CronExpression) isRegular() bool {
+	interval := time.Duration(e.interval) * time.Second
+	prev := e.second
+	for i := 0; i < longestEpochSample && !prev.IsZero(); i++ {
+		next := e.expr.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) != interval {
+			return false
+		}
+		prev = next
+	}
+	return true
+}
+
+// newWindowExpression creates an expression with a single epoch from the window start to its end
+func newWindowExpression(reset ResetExpression) (CronExpression, error) {
+	location, err := time.LoadLocation(reset.TimeZone)
+	if err != nil {
+		return CronExpression{}, fmt.Errorf("failed to load time zone '%v': %v", reset.TimeZone, err)
+	}
+	ce := CronExpression{location: location}
+	w, err := newWindow(&ce, reset)
+	if err != nil {
+		return CronExpression{}, err
+	}
+	ce.window = w
+	return ce, nil
+}
+
+// IsManual checks if the epoch is advanced manually instead of by the clock, the current epoch
+// of manual expressions is stored with the leaderboard and they have no epoch times
+func (e *CronExpression) IsManual() bool {
+	return e.manual
+}
+
+// IsOpen checks if scores can be reported at ref, only fixed windows close
+func (e *CronExpression) IsOpen(ref time.Time) bool {
+	return e.window == nil || e.window.contains(ref)
+}
+
+// Location returns the time zone the expression is evaluated in
+func (e *CronExpression) Location() *time.Location {
+	if e.location == nil {
+		return time.UTC
+	}
+	return e.location
 }
 
-// GetEpochFromReferenceUnixTimestamp calculates the epoch based on a cron expression and a ref unix timestamp
+// GetEpochFromReferenceUnixTimestamp calculates the epoch of a ref unix timestamp counting the
+// occurrences of the cron expression since the anchor in the wall clock of the time zone
 func (e *CronExpression) GetEpochFromReferenceUnixTimestamp(ref int64) int64 {
-	return int64(math.Floor(float64((ref-e.first.Unix())/int64(e.interval)))) + 1
+	if e.window != nil {
+		return e.window.epoch()
+	}
+	if e.manual {
+		return 0
+	}
+	if e.legacy {
+		return e.GetLegacyEpochFromReferenceUnixTimestamp(ref)
+	}
+	return e.schedule.epochAt(e.wallClock(time.Unix(ref, 0)))
+}
+
+// GetLegacyEpochFromReferenceUnixTimestamp calculates the epoch assuming a fixed interval between
+// the first two occurrences after the unix epoch, only correct for regular schedules and kept to
+// migrate the keys of leaderboards created with it
+func (e *CronExpression) GetLegacyEpochFromReferenceUnixTimestamp(ref int64) int64 {
+	if e.window != nil {
+		return e.window.epoch()
+	}
+	if e.manual {
+		return 0
+	}
+	wall := e.wallClock(time.Unix(ref, 0)).Unix()
+	return int64(math.Floor(float64((wall-e.first.Unix())/int64(e.interval)))) + 1
+}
+
+// MigrateLegacyEpoch returns the epoch of the latest time of a legacy epoch that is not after the
+// reference, which maps the current legacy epoch to the current epoch
+func (e *CronExpression) MigrateLegacyEpoch(legacyEpoch int64, ref time.Time) int64 {
+	// windows and manual epochs never had legacy numbering and expressions without anchor
+	// still use it
+	if e.window != nil || e.manual || e.legacy {
+		return legacyEpoch
+	}
+	interval := time.Duration(e.interval) * time.Second
+	last := e.fromWallClock(e.first.Add(time.Duration(legacyEpoch)*interval - time.Second))
+	if last.After(ref) {
+		last = ref
+	}
+	return e.schedule.epochAt(e.wallClock(last))
+}
+
+// GetEpochStart returns the time an epoch starts or the zero time when the epoch has no start
+func (e *CronExpression) GetEpochStart(epoch int64) time.Time {
+	if e.window != nil {
+		if epoch != e.window.epoch() {
+			return time.Time{}
+		}
+		return e.window.start
+	}
+	if e.manual {
+		return time.Time{}
+	}
+	var wall time.Time
+	if e.legacy {
+		wall = e.first.Add(time.Duration(epoch-1) * time.Duration(e.interval) * time.Second)
+	} else {
+		wall = e.schedule.occurrence(epoch)
+	}
+	if wall.IsZero() {
+		return wall
+	}
+	return e.fromWallClock(wall).UTC()
+}
+
+// GetEpochEnd returns the time an epoch ends which is the start of the next epoch
+func (e *CronExpression) GetEpochEnd(epoch int64) time.Time {
+	if e.window != nil {
+		if epoch != e.window.epoch() {
+			return time.Time{}
+		}
+		return e.window.end
+	}
+	return e.GetEpochStart(epoch + 1)
+}
+
+// LongestEpoch returns the longest epoch in the occurrences after the reference, manual epochs
+// have no length
+func (e *CronExpression) LongestEpoch(ref time.Time) time.Duration {
+	if e.window != nil {
+		return e.window.end.Sub(e.window.start)
+	}
+	if e.manual {
+		return 0
+	}
+	if e.legacy {
+		return time.Duration(e.interval) * time.Second
+	}
+	longest := time.Duration(0)
+	prev := e.schedule.next(e.wallClock(ref))
+	for i := 0; i < longestEpochSample && !prev.IsZero(); i++ {
+		next := e.schedule.next(prev)
+		if next.IsZero() {
+			break
+		}
+		longest = max(longest, next.Sub(prev))
+		prev = next
+	}
+	return longest
 }
 
 // GetNexFromNowUTC returns the next time after the current UTC timestamp
@@ -62,10 +259,53 @@ func (e *CronExpression) GetNexFromNowUTC() time.Time {
 
 // GetNexFromRefUTC returns the next time after the reference timestamp
 func (e *CronExpression) GetNexFromRefUTC(ref time.Time) time.Time {
-	return e.expr.Next(ref.UTC())
+	if e.manual {
+		return time.Time{}
+	}
+	// a window closes once and is never reset
+	if e.window != nil {
+		if ref.Before(e.window.end) {
+			return e.window.end
+		}
+		return time.Time{}
+	}
+	// occurrences are calculated in the wall clock of the time zone, skipping the ones
+	// that map to a time not after the reference when the clock goes back
+	wall := e.schedule.next(e.wallClock(ref))
+	next := e.fromWallClock(wall)
+	for !next.After(ref) && !wall.IsZero() {
+		wall = e.schedule.next(wall)
+		next = e.fromWallClock(wall)
+	}
+	return next.UTC()
 }
 
 // GetNexTimestampFromRefUTC returns the next time after the reference timestamp
 func (e *CronExpression) GetNexTimestampFromRefUTC(ref time.Time) int64 {
-	return e.expr.Next(ref.UTC()).Unix()
+	return e.GetNexFromRefUTC(ref).Unix()
+}
+
+// parseAnchor parses an anchor as RFC3339 or as a date in the time zone into a wall clock
+func (e *CronExpression) parseAnchor(anchor string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, anchor)
+	if err == nil {
+		return e.wallClock(t), nil
+	}
+	t, err = time.Parse(anchorDateLayout, anchor)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse anchor '%v': must be RFC3339 or %v", anchor, anchorDateLayout)
+	}
+	return t, nil
+}
+
+// wallClock returns the wall clock of the time zone at t represented as an UTC time
+func (e *CronExpression) wallClock(t time.Time) time.Time {
+	l := t.In(e.Location())
+	return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), l.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the time of a wall clock in the time zone, wall clocks skipped by
+// a DST change are moved forward by the time zone offset change
+func (e *CronExpression) fromWallClock(w time.Time) time.Time {
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), e.Location())
 }
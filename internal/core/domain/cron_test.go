@@ -72,6 +72,188 @@ func TestGetNexTimestampFromRefUTC(t *testing.T) {
 	assert.Equal(t, int64(1719849600),
 		ce.GetNexTimestampFromRefUTC(time.Unix(ref, 0)))
 }
+func TestTimeZoneDailyAcrossDST(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:     Daily,
+		TimeZone: "Europe/Lisbon",
+	})
+	assert.NoError(t, err)
+
+	// Lisbon moves from UTC+0 to UTC+1 at 2024-03-31 01:00 UTC
+	beforeDST := time.Date(2024, time.March, 31, 0, 30, 0, 0, time.UTC)
+	afterDST := time.Date(2024, time.March, 31, 22, 30, 0, 0, time.UTC)
+	nextDay := time.Date(2024, time.March, 31, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, ce.GetEpochFromReferenceUnixTimestamp(beforeDST.Unix()), ce.GetEpochFromReferenceUnixTimestamp(afterDST.Unix()))
+	assert.Equal(t, ce.GetEpochFromReferenceUnixTimestamp(afterDST.Unix())+1, ce.GetEpochFromReferenceUnixTimestamp(nextDay.Unix()))
+
+	assert.Equal(t, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+		ce.GetNexFromRefUTC(time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, time.March, 31, 23, 0, 0, 0, time.UTC),
+		ce.GetNexFromRefUTC(afterDST))
+}
+
+func TestTimeZoneHourlyBackwardDST(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:     Hourly,
+		TimeZone: "Europe/Lisbon",
+	})
+	assert.NoError(t, err)
+
+	// Lisbon moves from UTC+1 to UTC+0 at 2024-10-27 01:00 UTC repeating the 01:00 hour,
+	// the repeated wall clock hour belongs to a single epoch
+	ref := time.Date(2024, time.October, 27, 0, 30, 0, 0, time.UTC)
+	repeated := time.Date(2024, time.October, 27, 1, 30, 0, 0, time.UTC)
+	assert.Equal(t, ce.GetEpochFromReferenceUnixTimestamp(ref.Unix()), ce.GetEpochFromReferenceUnixTimestamp(repeated.Unix()))
+	assert.Equal(t, time.Date(2024, time.October, 27, 2, 0, 0, 0, time.UTC), ce.GetNexFromRefUTC(ref))
+	assert.Equal(t, time.Date(2024, time.October, 27, 2, 0, 0, 0, time.UTC), ce.GetNexFromRefUTC(repeated))
+}
+
+func TestTimeZoneCustomWeekly(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:           Custom,
+		CronExpression: "0 6 * * 1",
+		TimeZone:       "Asia/Tokyo",
+	})
+	assert.NoError(t, err)
+
+	// 2024-07-01 is a Monday, 09:00 in Tokyo
+	ref := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, time.July, 7, 21, 0, 0, 0, time.UTC), ce.GetNexFromRefUTC(ref))
+
+	before := time.Date(2024, time.July, 7, 20, 59, 0, 0, time.UTC)
+	after := time.Date(2024, time.July, 7, 21, 1, 0, 0, time.UTC)
+	assert.Equal(t, ce.GetEpochFromReferenceUnixTimestamp(before.Unix())+1, ce.GetEpochFromReferenceUnixTimestamp(after.Unix()))
+}
+
+func TestMonthlyCountsOccurrences(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:   Monthly,
+		Anchor: "1970-01-01",
+	})
+	assert.NoError(t, err)
+
+	epoch := ce.GetEpochFromReferenceUnixTimestamp(refGlobal)
+	assert.Equal(t, int64(655), epoch)
+	assert.Equal(t, time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC), ce.GetEpochStart(epoch))
+	assert.Equal(t, time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC), ce.GetEpochEnd(epoch))
+	assert.Equal(t, epoch-1, ce.GetEpochFromReferenceUnixTimestamp(ce.GetEpochStart(epoch).Unix()-1))
+}
+
+func TestCustomIrregularWithAnchor(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:           Custom,
+		CronExpression: "0 6 * * 1,4",
+		Anchor:         "2024-01-01",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(0), ce.GetEpochFromReferenceUnixTimestamp(time.Date(2024, time.January, 1, 5, 0, 0, 0, time.UTC).Unix()))
+	assert.Equal(t, int64(1), ce.GetEpochFromReferenceUnixTimestamp(time.Date(2024, time.January, 1, 6, 0, 0, 0, time.UTC).Unix()))
+	assert.Equal(t, int64(53), ce.GetEpochFromReferenceUnixTimestamp(refGlobal))
+	assert.Equal(t, time.Date(2024, time.July, 1, 6, 0, 0, 0, time.UTC), ce.GetEpochStart(53))
+	assert.Equal(t, time.Date(2024, time.July, 4, 6, 0, 0, 0, time.UTC), ce.GetEpochEnd(53))
+	assert.True(t, ce.GetEpochStart(0).IsZero())
+}
+
+func TestAnchorWithTimeZoneAcrossDST(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:     Daily,
+		TimeZone: "Europe/Lisbon",
+		Anchor:   "2024-03-30",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), ce.GetEpochFromReferenceUnixTimestamp(time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC).Unix()))
+	assert.Equal(t, int64(2), ce.GetEpochFromReferenceUnixTimestamp(time.Date(2024, time.March, 31, 22, 30, 0, 0, time.UTC).Unix()))
+	assert.Equal(t, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), ce.GetEpochStart(2))
+	assert.Equal(t, time.Date(2024, time.March, 31, 23, 0, 0, 0, time.UTC), ce.GetEpochEnd(2))
+}
+
+func TestLegacyEpochs(t *testing.T) {
+	// leaderboards without anchor keep the numbering they were created with, which matches the
+	// resets of evenly spaced occurrences
+	legacy, err := NewCronExpression(ResetExpression{Type: Weekly, TimeZone: "Europe/Lisbon"})
+	assert.NoError(t, err)
+	ref := time.Unix(refGlobal, 0)
+	epoch := legacy.GetEpochFromReferenceUnixTimestamp(refGlobal)
+	assert.False(t, legacy.GetEpochStart(epoch).After(ref))
+	assert.Equal(t, legacy.GetNexFromRefUTC(ref), legacy.GetEpochEnd(epoch))
+	assert.Equal(t, epoch, legacy.MigrateLegacyEpoch(epoch, ref))
+
+	// irregular occurrences would not reset at the epoch ends and require an anchor
+	reset := ResetExpression{Type: Monthly}
+	_, err = NewCronExpression(reset)
+	assert.ErrorContains(t, err, "requires an anchor")
+	_, err = NewCronExpression(ResetExpression{Type: Custom, CronExpression: "0 0 * * 1-5"})
+	assert.ErrorContains(t, err, "requires an anchor")
+
+	reset.Anchor = "1970-01-01"
+	ce, err := NewCronExpression(reset)
+	assert.NoError(t, err)
+	epoch = ce.GetLegacyEpochFromReferenceUnixTimestamp(refGlobal)
+	assert.Equal(t, int64(710), epoch)
+	assert.Equal(t, int64(655), ce.MigrateLegacyEpoch(epoch, ref))
+}
+
+func TestInvalidAnchor(t *testing.T) {
+	_, err := NewCronExpression(ResetExpression{
+		Type:   Daily,
+		Anchor: "yesterday",
+	})
+	assert.Error(t, err)
+}
+
+func TestInvalidTimeZone(t *testing.T) {
+	_, err := NewCronExpression(ResetExpression{
+		Type:     Daily,
+		TimeZone: "Europe/Nowhere",
+	})
+	assert.Error(t, err)
+}
+
+func TestWindow(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{
+		Type:     Window,
+		TimeZone: "Europe/Lisbon",
+		Start:    "2024-07-01",
+		End:      "2024-07-08T12:00:00Z",
+	})
+	assert.NoError(t, err)
+
+	start := time.Date(2024, time.June, 30, 23, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.July, 8, 12, 0, 0, 0, time.UTC)
+	epoch := ce.GetEpochFromReferenceUnixTimestamp(refGlobal)
+	assert.Equal(t, start.Unix(), epoch)
+	assert.Equal(t, epoch, ce.GetEpochFromReferenceUnixTimestamp(end.Add(time.Hour).Unix()))
+	assert.Equal(t, start, ce.GetEpochStart(epoch))
+	assert.Equal(t, end, ce.GetEpochEnd(epoch))
+	assert.True(t, ce.GetEpochStart(epoch+1).IsZero())
+
+	assert.False(t, ce.IsOpen(start.Add(-time.Second)))
+	assert.True(t, ce.IsOpen(start))
+	assert.False(t, ce.IsOpen(end))
+	assert.Equal(t, end, ce.GetNexFromRefUTC(start))
+	assert.True(t, ce.GetNexFromRefUTC(end).IsZero())
+}
+
+func TestInvalidWindow(t *testing.T) {
+	_, err := NewCronExpression(ResetExpression{Type: Window, Start: "2024-07-01"})
+	assert.Error(t, err)
+	_, err = NewCronExpression(ResetExpression{Type: Window, Start: "2024-07-08", End: "2024-07-01"})
+	assert.Error(t, err)
+}
+
+func TestManually(t *testing.T) {
+	ce, err := NewCronExpression(ResetExpression{Type: Manually})
+	assert.NoError(t, err)
+
+	assert.True(t, ce.IsManual())
+	assert.True(t, ce.IsOpen(time.Now()))
+	assert.True(t, ce.GetEpochStart(1).IsZero())
+	assert.True(t, ce.GetEpochEnd(1).IsZero())
+	assert.True(t, ce.GetNexFromNowUTC().IsZero())
+}
+
 func TestUnixTimestamp(t *testing.T) {
 	e := "00 6 * * 1" // every Monday at 6am
 	e = "* * * * *"   // every minute
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxDecayHalfLives is the number of half-lives an epoch can last, normalised scores grow by 2^960
+// at most which keeps raw scores up to 2^64 below the float64 range
+const maxDecayHalfLives = 960
+
+// DecayType enum for how decayed scores lose value
+type DecayType int
+
+const (
+	// ExponentialDecay halves the value of scores every half-life
+	ExponentialDecay DecayType = iota
+	// LinearDecay removes a fixed amount of points per hour down to zero
+	LinearDecay
+)
+
+// DecayConfig holds the configuration of the Decay function.
+// Scores are stored normalised to the start of the epoch so the order of the scoreboard at any
+// time is the order of the decayed values and members are not rewritten as time passes
+type DecayConfig struct {
+	Type DecayType `json:"type"`
+	// HalfLife is the duration, e.g. 6h, in which exponentially decayed scores lose half their value
+	HalfLife string `json:"half_life,omitempty"`
+	// PointsPerHour is the value linearly decayed scores lose per hour
+	PointsPerHour float64 `json:"points_per_hour,omitempty"`
+}
+
+// DecayedScore is a raw score reported at a time along with its normalised value
+type DecayedScore struct {
+	Raw   float64
+	At    time.Time
+	Score float64
+}
+
+// Validate checks the decay parameters
+func (c DecayConfig) Validate() error {
+	switch c.Type {
+	case ExponentialDecay:
+		d, err := time.ParseDuration(c.HalfLife)
+		if err != nil {
+			return fmt.Errorf("failed to parse half-life '%v': %v", c.HalfLife, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("half-life must be positive: %v", c.HalfLife)
+		}
+	case LinearDecay:
+		if c.PointsPerHour <= 0 {
+			return fmt.Errorf("points per hour must be positive: %v", c.PointsPerHour)
+		}
+	default:
+		return fmt.Errorf("unknown decay type: %v", c.Type)
+	}
+	return nil
+}
+
+// validateEpoch checks exponentially decayed scores stay in the float64 range in epochs of length
+func (c DecayConfig) validateEpoch(length time.Duration) error {
+	if c.Type != ExponentialDecay {
+		return nil
+	}
+	halfLives := float64(length) / float64(c.halfLife())
+	if halfLives > maxDecayHalfLives {
+		return fmt.Errorf("epochs of %v last %.0f half-lives of %v, at most %d are supported", length, halfLives, c.HalfLife, maxDecayHalfLives)
+	}
+	return nil
+}
+
+// Encode normalises a raw score reported at a time to the reference time.
+// Exponential decay overflows after about a thousand half-lives from the reference, so the
+// leaderboard validation bounds the half-lives of an epoch
+func (c DecayConfig) Encode(raw float64, at time.Time, ref time.Time) float64 {
+	elapsed := at.Sub(ref)
+	if c.Type == LinearDecay {
+		return raw + c.PointsPerHour*elapsed.Hours()
+	}
+	return raw * math.Exp2(float64(elapsed)/float64(c.halfLife()))
+}
+
+// Decode returns the value at a time of a score normalised to the reference time
+func (c DecayConfig) Decode(score float64, at time.Time, ref time.Time) float64 {
+	elapsed := at.Sub(ref)
+	if c.Type == LinearDecay {
+		return math.Max(0, score-c.PointsPerHour*elapsed.Hours())
+	}
+	return score * math.Exp2(-float64(elapsed)/float64(c.halfLife()))
+}
+
+func (c DecayConfig) halfLife() time.Duration {
+	d, _ := time.ParseDuration(c.HalfLife)
+	return d
+}
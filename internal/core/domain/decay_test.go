@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialDecay(t *testing.T) {
+	c := DecayConfig{Type: ExponentialDecay, HalfLife: "1h"}
+	assert.NoError(t, c.Validate())
+	ref := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	old := c.Encode(100, ref, ref)
+	recent := c.Encode(60, ref.Add(time.Hour), ref)
+	assert.Greater(t, recent, old)
+
+	now := ref.Add(2 * time.Hour)
+	assert.InDelta(t, 25, c.Decode(old, now, ref), 1e-9)
+	assert.InDelta(t, 30, c.Decode(recent, now, ref), 1e-9)
+}
+
+func TestLinearDecay(t *testing.T) {
+	c := DecayConfig{Type: LinearDecay, PointsPerHour: 10}
+	assert.NoError(t, c.Validate())
+	ref := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	old := c.Encode(100, ref, ref)
+	recent := c.Encode(95, ref.Add(time.Hour), ref)
+	assert.Greater(t, recent, old)
+
+	assert.InDelta(t, 75, c.Decode(recent, ref.Add(3*time.Hour), ref), 1e-9)
+	assert.Equal(t, 0.0, c.Decode(old, ref.Add(20*time.Hour), ref))
+}
+
+func TestInvalidDecay(t *testing.T) {
+	assert.Error(t, DecayConfig{Type: ExponentialDecay, HalfLife: "soon"}.Validate())
+	assert.Error(t, DecayConfig{Type: ExponentialDecay, HalfLife: "-1h"}.Validate())
+	assert.Error(t, DecayConfig{Type: LinearDecay}.Validate())
+
+	cfg := LeaderboardConfig{Function: Decay, ResetExpression: ResetExpression{Type: Daily}}
+	assert.Error(t, cfg.Validate())
+	cfg.Decay = &DecayConfig{Type: LinearDecay, PointsPerHour: 1}
+	assert.NoError(t, cfg.Validate())
+	cfg.ResetExpression.Type = Manually
+	assert.Error(t, cfg.Validate())
+}
+
+func TestDecayHalfLivesPerEpoch(t *testing.T) {
+	cfg := LeaderboardConfig{
+		Function:        Decay,
+		ResetExpression: ResetExpression{Type: Weekly},
+		Decay:           &DecayConfig{Type: ExponentialDecay, HalfLife: "10m"},
+	}
+	// a week lasts 1008 half-lives of 10m
+	assert.Error(t, cfg.Validate())
+	cfg.Decay.HalfLife = "11m"
+	assert.NoError(t, cfg.Validate())
+	ref := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	assert.False(t, math.IsInf(cfg.Decay.Encode(math.Exp2(64), ref.Add(7*24*time.Hour), ref), 0))
+
+	// the longest month lasts 992 half-lives of 45m
+	cfg.ResetExpression = ResetExpression{Type: Monthly, Anchor: "2024-01-01"}
+	cfg.Decay.HalfLife = "45m"
+	assert.Error(t, cfg.Validate())
+	cfg.Decay.HalfLife = "47m"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ResetExpression = ResetExpression{Type: Window, Start: "2024-07-01", End: "2024-07-02"}
+	cfg.Decay.HalfLife = "1m"
+	assert.Error(t, cfg.Validate())
+	cfg.Decay = &DecayConfig{Type: LinearDecay, PointsPerHour: 1}
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeaderboardNotFoundError ...
+type LeaderboardNotFoundError struct {
+	Name string
+}
+
+// Error interface implementation
+func (e *LeaderboardNotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Name)
+}
+
+// LeaderboardClosedError is returned when a score is reported outside the window of a leaderboard
+type LeaderboardClosedError struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Error interface implementation
+func (e *LeaderboardClosedError) Error() string {
+	return fmt.Sprintf("%s only accepts scores from %s to %s", e.Name, e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339))
+}
+
+// LeaderboardStateError is returned when the lifecycle state of a leaderboard does not allow an operation
+type LeaderboardStateError struct {
+	Name      string
+	State     LeaderboardState
+	Operation string
+}
+
+// Error interface implementation
+func (e *LeaderboardStateError) Error() string {
+	return fmt.Sprintf("%s is %s and does not accept %s", e.Name, e.State, e.Operation)
+}
+
+// LifecycleTransitionError is returned when a leaderboard cannot move to a lifecycle state
+type LifecycleTransitionError struct {
+	Name string
+	Err  error
+}
+
+// Error interface implementation
+func (e *LifecycleTransitionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+// InvalidScoreError is returned when a reported score does not match the leaderboard configuration
+type InvalidScoreError struct {
+	Name string
+	Err  error
+}
+
+// Error interface implementation
+func (e *InvalidScoreError) Error() string {
+	return fmt.Sprintf("invalid score for %s: %v", e.Name, e.Err)
+}
+
+// PrizeDeliveryNotFoundError is returned when an epoch does not have an award batch
+type PrizeDeliveryNotFoundError struct {
+	Name  string
+	Epoch int64
+}
+
+// Error interface implementation
+func (e *PrizeDeliveryNotFoundError) Error() string {
+	return fmt.Sprintf("prizes of %s epoch %d not found", e.Name, e.Epoch)
+}
+
+// PrizeNotFoundError is returned when an entry does not have a prize in a leaderboard epoch
+type PrizeNotFoundError struct {
+	EntryID string
+	Name    string
+	Epoch   int64
+}
+
+// Error interface implementation
+func (e *PrizeNotFoundError) Error() string {
+	return fmt.Sprintf("%s does not have a prize in %s epoch %d", e.EntryID, e.Name, e.Epoch)
+}
+
+// PrizeClaimedError is returned when a prize was already claimed
+type PrizeClaimedError struct {
+	EntryID string
+	Name    string
+	Epoch   int64
+}
+
+// Error interface implementation
+func (e *PrizeClaimedError) Error() string {
+	return fmt.Sprintf("prize of %s in %s epoch %d was already claimed", e.EntryID, e.Name, e.Epoch)
+}
+
+// ScoreboardNotFoundError is returned when a leaderboard does not have a scoreboard of a field
+type ScoreboardNotFoundError struct {
+	Name  string
+	Field string
+}
+
+// Error interface implementation
+func (e *ScoreboardNotFoundError) Error() string {
+	return fmt.Sprintf("%s does not have a %s scoreboard", e.Name, e.Field)
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultOvertakeDepth is the number of entries above an entry checked for overtakes
+	defaultOvertakeDepth = 10
+	// maxOvertakeDepth caps the entries checked for overtakes on every score
+	maxOvertakeDepth = 100
+	// defaultOvertakeInterval is the time between the overtake events of an overtaken entry
+	defaultOvertakeInterval = 10 * time.Minute
+)
+
+// EventType is the type of the events published on score changes
+type EventType string
+
+const (
+	// ScoreAccepted is published when a reported score is stored, it is stored with the score
+	// so it is published even when the server stops before the events of the score are raised
+	ScoreAccepted EventType = "score.accepted"
+	// ScoreRejected is published when a reported score is not stored, see Event.Reason
+	ScoreRejected EventType = "score.rejected"
+	// PersonalBest is published when a score improves the best score of an entry in an epoch
+	PersonalBest EventType = "score.personal_best"
+	// RankChanged is published when a score changes the rank of an entry in the global scoreboard
+	RankChanged EventType = "rank.changed"
+	// Overtaken is published for each entry a score moves the entry past, EntryID overtook
+	// OvertakenEntryID
+	Overtaken EventType = "entry.overtaken"
+	// EpochClosed is published once per epoch when it closes, after its prizes are awarded
+	EpochClosed EventType = "epoch.closed"
+)
+
+// Rejection reasons of the ScoreRejected events
+const (
+	// RejectedNotImproved scores do not improve the stored score of the function
+	RejectedNotImproved = "not_improved"
+	// RejectedInvalid scores do not match the leaderboard configuration
+	RejectedInvalid = "invalid"
+	// RejectedClosed scores are reported outside the window or lifecycle state accepting them
+	RejectedClosed = "closed"
+)
+
+// Event is a change published to the event bus at least once, consumers dedupe them by ID since
+// the events are published again until the bus acknowledges them
+type Event struct {
+	ID            string    `json:"id"`
+	Type          EventType `json:"type"`
+	Leaderboard   string    `json:"leaderboard"`
+	Epoch         int64     `json:"epoch"`
+	EntryID       string    `json:"entry_id,omitempty"`
+	Score         float64   `json:"score,omitempty"`
+	ExactScore    string    `json:"exact_score,omitempty"`
+	PreviousScore *float64  `json:"previous_score,omitempty"`
+	Rank          int64     `json:"rank,omitempty"`
+	PreviousRank  int64     `json:"previous_rank,omitempty"`
+	// OvertakenEntryID is the entry overtaken by EntryID in the Overtaken events
+	OvertakenEntryID string    `json:"overtaken_entry_id,omitempty"`
+	Reason           string    `json:"reason,omitempty"`
+	Metadata         Metadata  `json:"metadata,omitempty"`
+	At               time.Time `json:"at"`
+}
+
+// OvertakeConfig enables the Overtaken events of a leaderboard
+type OvertakeConfig struct {
+	// Depth is the number of entries above an entry checked for overtakes, defaults to 10
+	Depth int `json:"depth,omitempty"`
+	// Interval is the duration, e.g. 10m, an overtaken entry is not notified again for
+	Interval string `json:"interval,omitempty"`
+}
+
+// Validate checks the overtake settings
+func (c OvertakeConfig) Validate() error {
+	if c.Depth < 0 || c.Depth > maxOvertakeDepth {
+		return fmt.Errorf("overtake depth must be from 0 to %d: %d", maxOvertakeDepth, c.Depth)
+	}
+	if c.Interval != "" {
+		d, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("failed to parse overtake interval '%v': %v", c.Interval, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("overtake interval must not be negative: %v", c.Interval)
+		}
+	}
+	return nil
+}
+
+// EntriesAbove returns the number of entries above an entry checked for overtakes
+func (c OvertakeConfig) EntriesAbove() int64 {
+	if c.Depth == 0 {
+		return defaultOvertakeDepth
+	}
+	return int64(c.Depth)
+}
+
+// NotifyInterval returns the time an overtaken entry is not notified again for
+func (c OvertakeConfig) NotifyInterval() time.Duration {
+	if d, err := time.ParseDuration(c.Interval); err == nil {
+		return d
+	}
+	return defaultOvertakeInterval
+}
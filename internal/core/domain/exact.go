@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// maxScoreDecimals is the number of decimals of the values of an int64 number of units
+const maxScoreDecimals = 18
+
+// sharedScoreUnits is the number of units from which the float64 of an exact score may be shared
+// by other values, below 2^50 units the gaps between float64 values are under half a unit
+const sharedScoreUnits = 1 << 50
+
+// decimalPattern matches the plain decimal numbers accepted as exact scores
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// ScoreType enum for how the scores of a leaderboard are represented
+type ScoreType int
+
+const (
+	// FloatScore scores are float64 values
+	FloatScore ScoreType = iota
+	// IntegerScore scores are int64 values
+	IntegerScore
+	// FixedPointScore scores are int64 numbers of units with a fixed number of decimals
+	FixedPointScore
+)
+
+// ExactScore is an integer or fixed-point score stored as a decimal of up to an int64 number of
+// units. Score is the nearest float64 of the value, which scoreboards rank by, and distinct
+// values share it from sharedScoreUnits units so scoreboards rank them apart by the value
+type ExactScore struct {
+	Value string
+	Score float64
+}
+
+// SumBounds returns the range a stored total must be in to add the value without leaving the
+// int64 range of units
+func (s ExactScore) SumBounds() (string, string) {
+	decimals := s.decimals()
+	unit := scoreUnit(decimals)
+	value, _ := new(big.Rat).SetString(s.Value)
+	lower := new(big.Rat).Sub(new(big.Rat).Mul(unit, new(big.Rat).SetInt64(math.MinInt64)), value)
+	upper := new(big.Rat).Sub(new(big.Rat).Mul(unit, new(big.Rat).SetInt64(math.MaxInt64)), value)
+	return lower.FloatString(decimals), upper.FloatString(decimals)
+}
+
+// SharedScore checks if the float64 of the value may be shared by other values
+func (s ExactScore) SharedScore() bool {
+	units, ok := new(big.Int).SetString(strings.Replace(strings.TrimPrefix(s.Value, "-"), ".", "", 1), 10)
+	return ok && units.Cmp(big.NewInt(sharedScoreUnits)) >= 0
+}
+
+// decimals returns the decimals of the value, which has the decimals of the leaderboard
+func (s ExactScore) decimals() int {
+	if i := strings.IndexByte(s.Value, '.'); i >= 0 {
+		return len(s.Value) - i - 1
+	}
+	return 0
+}
+
+// IsExact checks if the scores of the leaderboard are stored exactly as decimals
+func (c LeaderboardConfig) IsExact() bool {
+	return c.ScoreType == IntegerScore || c.ScoreType == FixedPointScore
+}
+
+// ParseScore validates a decimal score against the score type of the leaderboard, the value
+// must be an int64 number of units
+func (c LeaderboardConfig) ParseScore(s string) (ExactScore, error) {
+	if !decimalPattern.MatchString(s) {
+		return ExactScore{}, fmt.Errorf("score must be a decimal number: %v", s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	units := new(big.Rat).Quo(r, c.unit())
+	if !units.IsInt() {
+		return ExactScore{}, fmt.Errorf("score must have up to %d decimals: %v", c.scoreDecimals(), s)
+	}
+	err := c.checkRange(r)
+	if err != nil {
+		return ExactScore{}, err
+	}
+	return c.exactScore(r), nil
+}
+
+// FormatScore returns the exact score of a decimal stored by the repository with the decimals of
+// the leaderboard
+func (c LeaderboardConfig) FormatScore(v string) (ExactScore, error) {
+	r, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return ExactScore{}, fmt.Errorf("invalid stored score: %v", v)
+	}
+	return c.exactScore(r), nil
+}
+
+func (c LeaderboardConfig) exactScore(r *big.Rat) ExactScore {
+	f, _ := r.Float64()
+	return ExactScore{Value: r.FloatString(c.scoreDecimals()), Score: f}
+}
+
+// checkRange checks that a value of the leaderboard is an int64 number of units
+func (c LeaderboardConfig) checkRange(r *big.Rat) error {
+	units := new(big.Rat).Quo(r, c.unit())
+	if !units.Num().IsInt64() {
+		unit := c.unit()
+		lower := new(big.Rat).Mul(unit, new(big.Rat).SetInt64(math.MinInt64))
+		upper := new(big.Rat).Mul(unit, new(big.Rat).SetInt64(math.MaxInt64))
+		return fmt.Errorf("score is out of range, the limits are %v and %v: %v", lower.FloatString(c.scoreDecimals()), upper.FloatString(c.scoreDecimals()), r.FloatString(c.scoreDecimals()))
+	}
+	return nil
+}
+
+// unit returns the smallest step of the values of the leaderboard
+func (c LeaderboardConfig) unit() *big.Rat {
+	return scoreUnit(c.scoreDecimals())
+}
+
+func scoreUnit(decimals int) *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+func (c LeaderboardConfig) scoreDecimals() int {
+	if c.ScoreType == FixedPointScore {
+		return c.ScoreDecimals
+	}
+	return 0
+}
+
+// validateScoreType checks the decimals of the score type
+func (c LeaderboardConfig) validateScoreType() error {
+	switch c.ScoreType {
+	case FloatScore, IntegerScore:
+		if c.ScoreDecimals != 0 {
+			return fmt.Errorf("score decimals require the fixed-point score type")
+		}
+	case FixedPointScore:
+		if c.ScoreDecimals < 1 || c.ScoreDecimals > maxScoreDecimals {
+			return fmt.Errorf("fixed-point scores must have from 1 to %d decimals: %d", maxScoreDecimals, c.ScoreDecimals)
+		}
+	default:
+		return fmt.Errorf("unknown score type: %v", c.ScoreType)
+	}
+	if c.IsExact() && (len(c.Components) > 0 || c.Function == Decay) {
+		return fmt.Errorf("components and decay require the float score type")
+	}
+	return nil
+}
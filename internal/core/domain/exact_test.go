@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFixedPointScore(t *testing.T) {
+	c := LeaderboardConfig{Function: Sum, ScoreType: FixedPointScore, ScoreDecimals: 2}
+	assert.NoError(t, c.Validate())
+
+	v, err := c.ParseScore("10.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.10", v.Value)
+	assert.Equal(t, 10.1, v.Score)
+
+	v, err = c.ParseScore("-0.05")
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.05", v.Value)
+
+	_, err = c.ParseScore("0.001")
+	assert.Error(t, err)
+	_, err = c.ParseScore("1e3")
+	assert.Error(t, err)
+	_, err = c.ParseScore("92233720368547758.08")
+	assert.Error(t, err)
+
+	v, err = c.ParseScore("-92233720368547758.08")
+	assert.NoError(t, err)
+	assert.True(t, v.SharedScore())
+	lower, upper := v.SumBounds()
+	assert.Equal(t, "0.00", lower)
+	assert.Equal(t, "184467440737095516.15", upper)
+
+	v, err = c.FormatScore("0.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.30", v.Value)
+}
+
+func TestParseIntegerScore(t *testing.T) {
+	c := LeaderboardConfig{Function: Sum, ScoreType: IntegerScore}
+
+	v, err := c.ParseScore("1125899906842623")
+	assert.NoError(t, err)
+	assert.Equal(t, "1125899906842623", v.Value)
+	assert.Equal(t, 1125899906842623.0, v.Score)
+	assert.False(t, v.SharedScore())
+
+	lower, upper := v.SumBounds()
+	assert.Equal(t, "-9224497936761618431", lower)
+	assert.Equal(t, "9222246136947933184", upper)
+
+	_, err = c.ParseScore("1.5")
+	assert.Error(t, err)
+
+	// the whole int64 range is stored, the values which share a float64 are ranked by the value
+	v, err = c.ParseScore("9223372036854775807")
+	assert.NoError(t, err)
+	assert.Equal(t, "9223372036854775807", v.Value)
+	assert.True(t, v.SharedScore())
+	v, err = c.ParseScore("-9223372036854775808")
+	assert.NoError(t, err)
+	assert.Equal(t, "-9223372036854775808", v.Value)
+	_, err = c.ParseScore("9223372036854775808")
+	assert.Error(t, err)
+
+	v, err = c.FormatScore("18014398509481985")
+	assert.NoError(t, err)
+	assert.Equal(t, "18014398509481985", v.Value)
+}
+
+func TestInvalidScoreType(t *testing.T) {
+	assert.Error(t, LeaderboardConfig{ScoreType: FixedPointScore}.Validate())
+	assert.Error(t, LeaderboardConfig{ScoreType: FixedPointScore, ScoreDecimals: 19}.Validate())
+	assert.Error(t, LeaderboardConfig{ScoreType: IntegerScore, ScoreDecimals: 2}.Validate())
+	assert.Error(t, LeaderboardConfig{Function: Max, ScoreType: IntegerScore, Components: ScoreComponents{{Name: "a", Digits: 2}}}.Validate())
+}
@@ -0,0 +1,32 @@
+package domain
+
+const (
+	// ExportCSV is the format of the standings as CSV with a header row
+	ExportCSV = "csv"
+	// ExportNDJSON is the format of the standings as a JSON object per line
+	ExportNDJSON = "ndjson"
+)
+
+// StandingsRow is a row of the export of the standings of a scoreboard
+type StandingsRow struct {
+	Scoreboard string   `json:"scoreboard"`
+	Rank       int64    `json:"rank"`
+	EntryID    string   `json:"entry_id"`
+	Score      float64  `json:"score"`
+	ExactScore string   `json:"exact_score,omitempty"`
+	Counter    uint64   `json:"counter"`
+	Metadata   Metadata `json:"metadata,omitempty"`
+}
+
+// StoredEntry is the record of an entry in a leaderboard epoch, Score is the stored decimal.
+// Raw and ReportedAt are the reported score of Decay leaderboards and ImportID and ImportLine
+// mark the last record of an import applied to the entry
+type StoredEntry struct {
+	Score      string
+	Counter    uint64
+	Metadata   Metadata
+	Raw        float64
+	ReportedAt int64
+	ImportID   string
+	ImportLine int64
+}
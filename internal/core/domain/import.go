@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// ImportMode is how imported scores are written
+type ImportMode string
+
+const (
+	// ImportApply applies the imported scores with the function of the leaderboard like reported
+	// scores
+	ImportApply ImportMode = "apply"
+	// ImportSet stores the imported scores as they are, replacing the stored scores
+	ImportSet ImportMode = "set"
+)
+
+// ImportRecord is a score read from an import, Err is set when the line could not be parsed
+type ImportRecord struct {
+	Line     int64
+	EntryID  string
+	Score    string
+	Metadata Metadata
+	Err      error
+}
+
+// ImportOptions are the options of an import, the current epoch is imported when Epoch is not
+// positive
+type ImportOptions struct {
+	Epoch       int64
+	Mode        ImportMode
+	Concurrency int
+	ChunkSize   int
+	DryRun      bool
+}
+
+// ImportIssue is a record of an import which was invalid or failed to be written
+type ImportIssue struct {
+	Line    int64  `json:"line"`
+	EntryID string `json:"entry_id,omitempty"`
+	Error   string `json:"error"`
+}
+
+// ImportReport is the progress and the validation report of an import, Records is the number of
+// records read which is where a resumed import starts and ID marks the records it applied
+type ImportReport struct {
+	ID          string        `json:"id"`
+	Leaderboard string        `json:"leaderboard"`
+	Epoch       int64         `json:"epoch"`
+	Mode        ImportMode    `json:"mode"`
+	DryRun      bool          `json:"dry_run,omitempty"`
+	Records     int64         `json:"records"`
+	Applied     int64         `json:"applied"`
+	NotImproved int64         `json:"not_improved"`
+	Invalid     int64         `json:"invalid"`
+	Failed      int64         `json:"failed"`
+	Issues      []ImportIssue `json:"issues,omitempty"`
+	Done        bool          `json:"done"`
+}
+
+// ApplyToEntry applies a score with the function of the leaderboard to the record of an entry
+// like the repository applies reported scores, normalised for Decay leaderboards. It returns
+// false when the score does not change the record, an entry without record has no counter
+func (c LeaderboardConfig) ApplyToEntry(entry StoredEntry, score string, meta Metadata) (StoredEntry, bool, error) {
+	for k, v := range meta {
+		if stored, ok := entry.Metadata[k]; ok && stored != v {
+			return entry, false, fmt.Errorf("metadata %s does not match the stored %s", k, stored)
+		}
+	}
+	value, ok := new(big.Rat).SetString(score)
+	if !ok {
+		return entry, false, fmt.Errorf("score must be a number: %v", score)
+	}
+	exists := entry.Counter > 0
+	current := new(big.Rat)
+	if exists {
+		current, ok = current.SetString(entry.Score)
+		if !ok {
+			return entry, false, fmt.Errorf("invalid stored score: %v", entry.Score)
+		}
+	}
+	switch c.Function {
+	case Sum:
+		value.Add(current, value)
+		if c.IsExact() {
+			err := c.checkRange(value)
+			if err != nil {
+				return entry, false, err
+			}
+		}
+	case Max, Decay:
+		if exists && current.Cmp(value) > 0 {
+			return entry, false, nil
+		}
+	case Min:
+		if exists && current.Cmp(value) < 0 {
+			return entry, false, nil
+		}
+	case Last:
+	default:
+		return entry, false, fmt.Errorf("function %v is not supported for imports", c.Function)
+	}
+
+	updated := entry
+	updated.Counter++
+	updated.Metadata = make(Metadata, len(entry.Metadata)+len(meta))
+	for k, v := range entry.Metadata {
+		updated.Metadata[k] = v
+	}
+	for k, v := range meta {
+		updated.Metadata[k] = v
+	}
+	if c.IsExact() {
+		updated.Score = value.FloatString(c.scoreDecimals())
+	} else {
+		f, _ := value.Float64()
+		updated.Score = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return updated, true, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyToEntry(t *testing.T) {
+	meta := Metadata{"country": "pt"}
+	c := LeaderboardConfig{Function: Sum}
+
+	entry, done, err := c.ApplyToEntry(StoredEntry{}, "10.5", meta)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, StoredEntry{Score: "10.5", Counter: 1, Metadata: meta}, entry)
+	entry, done, err = c.ApplyToEntry(entry, "2", Metadata{"league": "gold"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, StoredEntry{Score: "12.5", Counter: 2, Metadata: Metadata{"country": "pt", "league": "gold"}}, entry)
+
+	// the stored metadata does not change
+	_, _, err = c.ApplyToEntry(entry, "1", Metadata{"country": "es"})
+	assert.Error(t, err)
+
+	c.Function = Max
+	_, done, err = c.ApplyToEntry(entry, "12", nil)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	c.Function = Min
+	entry, done, err = c.ApplyToEntry(entry, "12", nil)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "12", entry.Score)
+	c.Function = Last
+	entry, _, _ = c.ApplyToEntry(entry, "20", nil)
+	assert.Equal(t, "20", entry.Score)
+	assert.Equal(t, uint64(4), entry.Counter)
+}
+
+func TestApplyToExactEntry(t *testing.T) {
+	c := LeaderboardConfig{Function: Sum, ScoreType: FixedPointScore, ScoreDecimals: 2}
+
+	entry, done, err := c.ApplyToEntry(StoredEntry{Score: "0.1", Counter: 1}, "0.20", nil)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "0.30", entry.Score)
+
+	// sums past the int64 range of units are not stored
+	entry, _, err = c.ApplyToEntry(StoredEntry{Score: "92233720368547758.06", Counter: 1}, "0.01", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "92233720368547758.07", entry.Score)
+	_, _, err = c.ApplyToEntry(entry, "0.01", nil)
+	assert.Error(t, err)
+}
@@ -1,5 +1,13 @@
 package domain
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Metadata type
 type Metadata map[string]string
 
@@ -18,6 +26,8 @@ const (
 	Min
 	// Sum function that accumulated value stored
 	Sum
+	// Decay function that saves the value with the greatest decayed value
+	Decay
 )
 
 // LeaderboardResetType enum for leaderboards reset type
@@ -30,6 +40,8 @@ const (
 	Weekly
 	Monthly
 	Custom
+	// Window runs a single epoch from a start to an end time
+	Window
 )
 
 type LeaderboardPrizeTable struct {
@@ -61,17 +73,155 @@ type LeaderboardScoreBoardConfig struct {
 
 type ResetExpression struct {
 	Type           LeaderboardResetType `json:"reset_type"`
-	CronExpression string               `json:"cron, omitempty"`
+	CronExpression string               `json:"cron,omitempty"`
+	// TimeZone is the IANA time zone the reset is evaluated in, defaults to UTC
+	TimeZone string `json:"timezone,omitempty"`
+	// Anchor is the RFC3339 time or date from which epochs are counted, the first reset at or
+	// after it starts epoch 1. Without it the epochs keep the fixed interval numbering of the
+	// first two resets after the unix epoch, so it is required by schedules whose resets are not
+	// evenly spaced like monthly ones
+	Anchor string `json:"anchor,omitempty"`
+	// Start and End are the RFC3339 times or dates in the time zone of a Window reset
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
 }
 
 // LeaderboardConfig holds information of a Leaderboard instance
 type LeaderboardConfig struct {
 	Name            string                        `json:"name"`
 	Function        LeaderboardFunctionType       `json:"function"`
-	ResetExpression ResetExpression               `json:"reset`
+	ResetExpression ResetExpression               `json:"reset"`
 	PrizeTable      LeaderboardPrizeTable         `json:"prizes_table"`
 	Scoreboards     []LeaderboardScoreBoardConfig `json:"scoreboards"`
-	CronExpression  CronExpression                `json:"-"`
+	StatsBuckets    []float64                     `json:"stats_buckets,omitempty"`
+	Lifecycle       LeaderboardLifecycle          `json:"lifecycle"`
+	Decay           *DecayConfig                  `json:"decay,omitempty"`
+	Components      ScoreComponents               `json:"components,omitempty"`
+	// SortOrder overrides the order entries are ranked in, see Order
+	SortOrder *SortOrder `json:"sort_order,omitempty"`
+	// ScoreType and ScoreDecimals set how scores are represented, see ExactScore
+	ScoreType     ScoreType `json:"score_type,omitempty"`
+	ScoreDecimals int       `json:"score_decimals,omitempty"`
+	// Webhook receives the prizes of the closed epochs, overriding the global webhook
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	// ClaimPrizes stores the prizes of the closed epochs for the entries to claim instead of
+	// delivering them to a webhook
+	ClaimPrizes bool `json:"claim_prizes,omitempty"`
+	// Overtakes publishes the Overtaken events of the entries a score moves past
+	Overtakes      *OvertakeConfig `json:"overtakes,omitempty"`
+	CronExpression CronExpression  `json:"-"`
+}
+
+// Order returns the order entries are ranked in, by default the lowest value is the best for the
+// min function and the highest value for the others
+func (c LeaderboardConfig) Order() SortOrder {
+	if c.SortOrder != nil {
+		return *c.SortOrder
+	}
+	if c.Function == Min {
+		return Ascending
+	}
+	return Descending
+}
+
+// Validate checks the settings that depend on each other
+func (c LeaderboardConfig) Validate() error {
+	err := c.validateScoreType()
+	if err != nil {
+		return err
+	}
+	if c.Webhook != nil {
+		err = c.Webhook.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if c.Overtakes != nil {
+		err = c.Overtakes.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if (len(c.Components) > 0 || c.Function == Decay) && c.Order() != Descending {
+		return fmt.Errorf("components and decay rank the highest score first and require the descending order")
+	}
+	if len(c.Components) > 0 {
+		// the packed score always ranks the highest first, ascending components already rank
+		// their lowest values first so min would keep the worst result
+		if c.Function != Max && c.Function != Last {
+			return fmt.Errorf("components require the max or last function")
+		}
+		return c.Components.Validate()
+	}
+	if c.Function != Decay {
+		return nil
+	}
+	if c.Decay == nil {
+		return fmt.Errorf("decay function requires a decay configuration")
+	}
+	if c.ResetExpression.Type == Manually {
+		return fmt.Errorf("decay function requires a reset with epoch start times")
+	}
+	err = c.Decay.Validate()
+	if err != nil {
+		return err
+	}
+	ce, err := NewCronExpression(c.ResetExpression)
+	if err != nil {
+		return fmt.Errorf("invalid reset: %v", err)
+	}
+	return c.Decay.validateEpoch(ce.LongestEpoch(time.Now()))
+}
+
+// ValidateConfigs checks the configurations like the config providers do before using them
+// along with their names, which must be unique regardless of case
+func ValidateConfigs(configs []LeaderboardConfig) error {
+	var errs []error
+	names := make(map[string]bool, len(configs))
+	for i, config := range configs {
+		if config.Name == "" {
+			errs = append(errs, fmt.Errorf("leaderboard %d: name is missing", i+1))
+			continue
+		}
+		if names[strings.ToLower(config.Name)] {
+			errs = append(errs, fmt.Errorf("%v: name is duplicated", config.Name))
+			continue
+		}
+		names[strings.ToLower(config.Name)] = true
+		err := config.Validate()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", config.Name, err))
+		}
+		_, err = NewCronExpression(config.ResetExpression)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: invalid reset: %v", config.Name, err))
+		}
+		for _, sb := range config.Scoreboards {
+			if sb.Field == "" {
+				errs = append(errs, fmt.Errorf("%v: scoreboard field is missing", config.Name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UnmarshalJSON reads the configuration accepting the ResetExpression key used by
+// configurations stored before the reset key was in place
+func (c *LeaderboardConfig) UnmarshalJSON(data []byte) error {
+	type config LeaderboardConfig
+	aux := struct {
+		*config
+		LegacyReset *ResetExpression `json:"ResetExpression"`
+	}{config: (*config)(c)}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	if aux.LegacyReset != nil {
+		c.ResetExpression = *aux.LegacyReset
+	}
+	return nil
 }
 
 // TODO: add a field to represent the metadata to show in the UI
@@ -79,10 +229,12 @@ type LeaderboardConfig struct {
 
 // LeaderboardEntry entry data
 type LeaderboardEntry struct {
-	Metadata string  `json:"metadata"`
-	EntryID  string  `json:"entry_id"`
-	Score    float64 `json:"score"`
-	Rank     int64   `json:"rank"`
+	Metadata   string    `json:"metadata"`
+	EntryID    string    `json:"entry_id"`
+	Score      float64   `json:"score"`
+	Rank       int64     `json:"rank"`
+	Components []float64 `json:"components,omitempty"`
+	ExactScore string    `json:"exact_score,omitempty"`
 }
 
 // LeaderboardScores leaderboard score
@@ -91,14 +243,62 @@ type LeaderboardScores struct {
 	Scores []LeaderboardEntry `json:"scores"`
 }
 
+// LeaderboardStanding holds the relative standing of an entry in a leaderboard
+type LeaderboardStanding struct {
+	Name               string    `json:"name"`
+	EntryID            string    `json:"entry_id"`
+	Score              float64   `json:"score"`
+	Rank               int64     `json:"rank"`
+	Total              int64     `json:"total"`
+	TopPercent         float64   `json:"top_percent"`
+	Percentile         float64   `json:"percentile"`
+	NextBandTopPercent float64   `json:"next_band_top_percent,omitempty"`
+	NextBandScore      float64   `json:"next_band_score,omitempty"`
+	Components         []float64 `json:"components,omitempty"`
+	ExactScore         string    `json:"exact_score,omitempty"`
+}
+
+// LeaderboardStats holds aggregated statistics of a leaderboard epoch
+type LeaderboardStats struct {
+	Name         string            `json:"name"`
+	Epoch        int64             `json:"epoch"`
+	Participants int64             `json:"participants"`
+	Submissions  uint64            `json:"submissions"`
+	Min          float64           `json:"min"`
+	Max          float64           `json:"max"`
+	Mean         float64           `json:"mean"`
+	Median       float64           `json:"median"`
+	Histogram    []HistogramBucket `json:"histogram,omitempty"`
+}
+
+// EpochMigration holds the result of moving a leaderboard legacy epoch to the counted epoch
+type EpochMigration struct {
+	LegacyEpoch int64    `json:"legacy_epoch"`
+	Epoch       int64    `json:"epoch"`
+	Scoreboards []string `json:"scoreboards"`
+	Entries     int      `json:"entries"`
+	Conflicts   int      `json:"conflicts"`
+}
+
 type ScoreUpdate struct {
-	Score    float64           `json:"score,omitempty"`
-	Done     bool              `json:"done,omitempty"`
-	Counter  uint64            `json:"counter,omitempty"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+	Score      float64           `json:"score,omitempty"`
+	Done       bool              `json:"done,omitempty"`
+	Counter    uint64            `json:"counter,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Components []float64         `json:"components,omitempty"`
+	ExactScore string            `json:"exact_score,omitempty"`
+}
+
+// EpochInfo holds the timing of a leaderboard epoch
+type EpochInfo struct {
+	Epoch      int64     `json:"epoch"`
+	Start      time.Time `json:"epoch_start"`
+	End        time.Time `json:"epoch_end"`
+	NextReset  time.Time `json:"next_reset"`
+	ServerTime time.Time `json:"server_time"`
 }
 
 type ReportScoreOutput struct {
 	Update ScoreUpdate
-	Epoch  int64
+	Epoch  EpochInfo
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalLeaderboardConfig(t *testing.T) {
+	var cfg LeaderboardConfig
+	err := json.Unmarshal([]byte(`{"name":"lb","function":3,"reset":{"reset_type":5,"cron":"0 6 * * 1","timezone":"Asia/Tokyo"}}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "lb", cfg.Name)
+	assert.Equal(t, Sum, cfg.Function)
+	assert.Equal(t, ResetExpression{Type: Custom, CronExpression: "0 6 * * 1", TimeZone: "Asia/Tokyo"}, cfg.ResetExpression)
+}
+
+func TestUnmarshalLeaderboardConfigLegacyReset(t *testing.T) {
+	var cfg LeaderboardConfig
+	err := json.Unmarshal([]byte(`{"name":"lb","ResetExpression":{"reset_type":2}}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, Daily, cfg.ResetExpression.Type)
+}
+
+func TestLeaderboardConfigOrder(t *testing.T) {
+	assert.Equal(t, Descending, LeaderboardConfig{Function: Max}.Order())
+	assert.Equal(t, Ascending, LeaderboardConfig{Function: Min}.Order())
+
+	descending := Descending
+	assert.Equal(t, Descending, LeaderboardConfig{Function: Min, SortOrder: &descending}.Order())
+
+	ascending := Ascending
+	cfg := LeaderboardConfig{Function: Max, SortOrder: &ascending, Components: ScoreComponents{{Name: "a", Digits: 1}}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidateConfigs(t *testing.T) {
+	valid := LeaderboardConfig{Name: "weekly", Function: Sum, ResetExpression: ResetExpression{Type: Weekly},
+		Scoreboards: []LeaderboardScoreBoardConfig{{Type: Country, Field: "country"}}}
+	assert.NoError(t, ValidateConfigs([]LeaderboardConfig{valid}))
+
+	duplicated := valid
+	duplicated.Name = "Weekly"
+	decay := LeaderboardConfig{Name: "decay", Function: Decay, ResetExpression: ResetExpression{Type: Weekly}}
+	cron := LeaderboardConfig{Name: "cron", Function: Sum, ResetExpression: ResetExpression{Type: Custom, CronExpression: "not a cron"}}
+	field := LeaderboardConfig{Name: "field", Function: Sum, ResetExpression: ResetExpression{Type: Daily},
+		Scoreboards: []LeaderboardScoreBoardConfig{{Type: Country}}}
+	err := ValidateConfigs([]LeaderboardConfig{valid, duplicated, decay, cron, field, {}})
+	assert.ErrorContains(t, err, "Weekly: name is duplicated")
+	assert.ErrorContains(t, err, "decay: decay function requires a decay configuration")
+	assert.ErrorContains(t, err, "cron: invalid reset")
+	assert.ErrorContains(t, err, "field: scoreboard field is missing")
+	assert.ErrorContains(t, err, "leaderboard 6: name is missing")
+}
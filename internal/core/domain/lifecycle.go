@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeaderboardState is the lifecycle state of a leaderboard
+type LeaderboardState string
+
+const (
+	// Draft leaderboards are being prepared and are not visible
+	Draft LeaderboardState = "draft"
+	// Scheduled leaderboards become active at the activation time
+	Scheduled LeaderboardState = "scheduled"
+	// Active leaderboards accept scores, configurations without state are active
+	Active LeaderboardState = "active"
+	// Paused leaderboards are visible but do not accept scores
+	Paused LeaderboardState = "paused"
+	// Closed leaderboards are visible and read-only
+	Closed LeaderboardState = "closed"
+	// Archived leaderboards are retired and not visible
+	Archived LeaderboardState = "archived"
+)
+
+// lifecycleTransitions maps each state to the states it can move to
+var lifecycleTransitions = map[LeaderboardState][]LeaderboardState{
+	Draft:     {Scheduled, Active, Archived},
+	Scheduled: {Draft, Active, Archived},
+	Active:    {Paused, Closed},
+	Paused:    {Active, Closed},
+	Closed:    {Active, Archived},
+	Archived:  {},
+}
+
+// LeaderboardLifecycle holds the lifecycle state of a leaderboard and when it changed
+type LeaderboardLifecycle struct {
+	State       LeaderboardState `json:"state,omitempty"`
+	ActivateAt  *time.Time       `json:"activate_at,omitempty"`
+	ActivatedAt *time.Time       `json:"activated_at,omitempty"`
+	PausedAt    *time.Time       `json:"paused_at,omitempty"`
+	ClosedAt    *time.Time       `json:"closed_at,omitempty"`
+	ArchivedAt  *time.Time       `json:"archived_at,omitempty"`
+	UpdatedAt   *time.Time       `json:"updated_at,omitempty"`
+}
+
+// LifecycleAudit records a lifecycle transition of a leaderboard
+type LifecycleAudit struct {
+	Name       string           `json:"name"`
+	From       LeaderboardState `json:"from"`
+	To         LeaderboardState `json:"to"`
+	ActivateAt *time.Time       `json:"activate_at,omitempty"`
+	Actor      string           `json:"actor"`
+	Reason     string           `json:"reason,omitempty"`
+	At         time.Time        `json:"at"`
+}
+
+// StateAt returns the state of the lifecycle at a given time, scheduled leaderboards are
+// active once the activation time is reached
+func (l LeaderboardLifecycle) StateAt(t time.Time) LeaderboardState {
+	switch {
+	case l.State == "":
+		return Active
+	case l.State == Scheduled && l.ActivateAt != nil && !t.Before(*l.ActivateAt):
+		return Active
+	default:
+		return l.State
+	}
+}
+
+// IsWritable checks if scores can be reported at a given time
+func (l LeaderboardLifecycle) IsWritable(t time.Time) bool {
+	return l.StateAt(t) == Active
+}
+
+// IsReadable checks if scores can be listed at a given time
+func (l LeaderboardLifecycle) IsReadable(t time.Time) bool {
+	switch l.StateAt(t) {
+	case Active, Paused, Closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// InitialLifecycle returns the lifecycle of a leaderboard created at a given time, leaderboards
+// are created as drafts or active and the other states are only reached by transitions
+func InitialLifecycle(state LeaderboardState, at time.Time) (LeaderboardLifecycle, error) {
+	l := LeaderboardLifecycle{State: state, UpdatedAt: &at}
+	switch state {
+	case "", Active:
+		l.ActivatedAt = &at
+	case Draft:
+	default:
+		return LeaderboardLifecycle{}, fmt.Errorf("leaderboards are created as %s or %s, not %s", Draft, Active, state)
+	}
+	return l, nil
+}
+
+// Transition returns the lifecycle after moving to a state at a given time, scheduling
+// requires an activation time after it
+func (l LeaderboardLifecycle) Transition(to LeaderboardState, at time.Time, activateAt *time.Time) (LeaderboardLifecycle, error) {
+	from := l.StateAt(at)
+	allowed := false
+	for _, s := range lifecycleTransitions[from] {
+		allowed = allowed || s == to
+	}
+	if !allowed {
+		return l, fmt.Errorf("invalid lifecycle transition from %s to %s", from, to)
+	}
+	if to == Scheduled && (activateAt == nil || !activateAt.After(at)) {
+		return l, fmt.Errorf("scheduling requires an activation time after %s", at.Format(time.RFC3339))
+	}
+
+	next := l
+	next.State = to
+	next.UpdatedAt = &at
+	next.ActivateAt = nil
+	switch to {
+	case Scheduled:
+		next.ActivateAt = activateAt
+	case Active:
+		next.ActivatedAt = &at
+	case Paused:
+		next.PausedAt = &at
+	case Closed:
+		next.ClosedAt = &at
+	case Archived:
+		next.ArchivedAt = &at
+	}
+	return next, nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleDefaultsToActive(t *testing.T) {
+	l := LeaderboardLifecycle{}
+	now := time.Now()
+
+	assert.Equal(t, Active, l.StateAt(now))
+	assert.True(t, l.IsWritable(now))
+	assert.True(t, l.IsReadable(now))
+}
+
+func TestLifecycleScheduledActivation(t *testing.T) {
+	now := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	activateAt := now.Add(time.Hour)
+
+	l, err := LeaderboardLifecycle{State: Draft}.Transition(Scheduled, now, &activateAt)
+	assert.NoError(t, err)
+	assert.Equal(t, Scheduled, l.StateAt(now))
+	assert.False(t, l.IsReadable(now))
+	assert.Equal(t, Active, l.StateAt(activateAt))
+	assert.True(t, l.IsWritable(activateAt))
+
+	_, err = LeaderboardLifecycle{State: Draft}.Transition(Scheduled, now, nil)
+	assert.Error(t, err)
+	_, err = LeaderboardLifecycle{State: Draft}.Transition(Scheduled, now, &now)
+	assert.Error(t, err)
+}
+
+func TestLifecycleTransitions(t *testing.T) {
+	now := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	l, err := LeaderboardLifecycle{}.Transition(Paused, now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Paused, l.State)
+	assert.Equal(t, &now, l.PausedAt)
+	assert.False(t, l.IsWritable(now))
+	assert.True(t, l.IsReadable(now))
+
+	l, err = l.Transition(Closed, now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &now, l.ClosedAt)
+
+	l, err = l.Transition(Archived, now, nil)
+	assert.NoError(t, err)
+	assert.False(t, l.IsReadable(now))
+
+	_, err = l.Transition(Active, now, nil)
+	assert.Error(t, err)
+	_, err = LeaderboardLifecycle{}.Transition(Draft, now, nil)
+	assert.Error(t, err)
+}
+
+func TestInitialLifecycle(t *testing.T) {
+	now := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	l, err := InitialLifecycle(Draft, now)
+	assert.NoError(t, err)
+	assert.Equal(t, LeaderboardLifecycle{State: Draft, UpdatedAt: &now}, l)
+	l, err = InitialLifecycle("", now)
+	assert.NoError(t, err)
+	assert.Equal(t, Active, l.StateAt(now))
+	assert.Equal(t, &now, l.ActivatedAt)
+
+	for _, state := range []LeaderboardState{Scheduled, Paused, Closed, Archived} {
+		_, err = InitialLifecycle(state, now)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// defaultWebhookMaxAttempts is the number of deliveries of a batch before it is dead-lettered
+	defaultWebhookMaxAttempts = 8
+	// defaultWebhookBackoff is the delay before the first retry, doubled on every attempt
+	defaultWebhookBackoff = 30 * time.Second
+	// maxWebhookBackoff caps the delay between retries
+	maxWebhookBackoff = 6 * time.Hour
+)
+
+// DeliveryState is the state of the delivery of an award batch
+type DeliveryState string
+
+const (
+	// DeliveryPending batches are waiting for a delivery attempt
+	DeliveryPending DeliveryState = "pending"
+	// DeliveryDelivered batches were accepted by the webhook
+	DeliveryDelivered DeliveryState = "delivered"
+	// DeliveryDead batches exhausted their attempts and are in the dead-letter list
+	DeliveryDead DeliveryState = "dead"
+	// DeliveryClaimable batches were stored as prizes the entries claim
+	DeliveryClaimable DeliveryState = "claimable"
+	// DeliveryNone batches belong to leaderboards without prizes to deliver, they are only recorded
+	// to close the epoch once
+	DeliveryNone DeliveryState = "none"
+)
+
+// WebhookConfig configures the webhook award batches are posted to
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Secret signs the body of the requests, see the webhook adapter for the signature format
+	Secret string `json:"secret,omitempty"`
+	// MaxAttempts is the number of deliveries before a batch is dead-lettered, defaults to 8
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// Backoff is the duration, e.g. 30s, before the first retry which doubles on every attempt
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// RedactedSecret replaces the secrets of the configurations written to files and diffs
+const RedactedSecret = "<redacted>"
+
+// Redacted returns the configuration with its webhook secret replaced by RedactedSecret
+func (c LeaderboardConfig) Redacted() LeaderboardConfig {
+	if c.Webhook != nil && c.Webhook.Secret != "" {
+		webhook := *c.Webhook
+		webhook.Secret = RedactedSecret
+		c.Webhook = &webhook
+	}
+	return c
+}
+
+// PrizeAward is the prize of an entry ranked in a closed epoch
+type PrizeAward struct {
+	EntryID    string  `json:"entry_id"`
+	Rank       int64   `json:"rank"`
+	Score      float64 `json:"score"`
+	ExactScore string  `json:"exact_score,omitempty"`
+	Action     string  `json:"action"`
+	Epoch      int64   `json:"epoch"`
+}
+
+// AwardBatch holds the prizes of a closed leaderboard epoch
+type AwardBatch struct {
+	ID          string       `json:"id"`
+	Leaderboard string       `json:"leaderboard"`
+	Epoch       int64        `json:"epoch"`
+	ClosedAt    time.Time    `json:"closed_at"`
+	Awards      []PrizeAward `json:"awards"`
+}
+
+// EntryPrize is a prize awarded to an entry which players claim
+type EntryPrize struct {
+	PrizeAward
+	Leaderboard string     `json:"leaderboard"`
+	AwardedAt   time.Time  `json:"awarded_at"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+	ClaimID     string     `json:"claim_id,omitempty"`
+}
+
+// PrizeDelivery tracks the delivery of an award batch to a webhook
+type PrizeDelivery struct {
+	Batch       AwardBatch    `json:"batch"`
+	State       DeliveryState `json:"state"`
+	Attempts    int           `json:"attempts"`
+	NextAttempt time.Time     `json:"next_attempt"`
+	LastError   string        `json:"last_error,omitempty"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Validate checks the webhook settings
+func (c WebhookConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("webhook requires an url")
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("webhook max attempts must not be negative: %d", c.MaxAttempts)
+	}
+	if c.Backoff != "" {
+		d, err := time.ParseDuration(c.Backoff)
+		if err != nil {
+			return fmt.Errorf("failed to parse webhook backoff '%v': %v", c.Backoff, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("webhook backoff must be positive: %v", c.Backoff)
+		}
+	}
+	return nil
+}
+
+// Attempts returns the number of deliveries of a batch before it is dead-lettered
+func (c WebhookConfig) Attempts() int {
+	if c.MaxAttempts == 0 {
+		return defaultWebhookMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+// RetryAfter returns the delay after a failed attempt, doubled on every attempt up to 6h
+func (c WebhookConfig) RetryAfter(attempt int) time.Duration {
+	backoff := defaultWebhookBackoff
+	if d, err := time.ParseDuration(c.Backoff); err == nil && d > 0 {
+		backoff = d
+	}
+	delay := float64(backoff) * math.Exp2(float64(max(attempt-1, 0)))
+	if delay > float64(maxWebhookBackoff) {
+		return maxWebhookBackoff
+	}
+	return time.Duration(delay)
+}
+
+// MaxRank returns the last rank with a prize
+func (t LeaderboardPrizeTable) MaxRank() uint64 {
+	var rank uint64
+	for _, p := range t.Table {
+		rank = max(rank, p.RankTo)
+	}
+	return rank
+}
+
+// PrizeFor returns the prize of a rank, the first range of the table that holds it wins
+func (t LeaderboardPrizeTable) PrizeFor(rank uint64) (LeaderboardPrize, bool) {
+	for _, p := range t.Table {
+		if rank >= p.RankFrom && rank <= p.RankTo {
+			return p, true
+		}
+	}
+	return LeaderboardPrize{}, false
+}
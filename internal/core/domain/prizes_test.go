@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrizeTable(t *testing.T) {
+	table := LeaderboardPrizeTable{Table: []LeaderboardPrize{
+		{RankFrom: 1, RankTo: 1, Action: "gold"},
+		{RankFrom: 2, RankTo: 10, Action: "silver"},
+	}}
+	assert.Equal(t, uint64(10), table.MaxRank())
+
+	p, ok := table.PrizeFor(5)
+	assert.True(t, ok)
+	assert.Equal(t, "silver", p.Action)
+	_, ok = table.PrizeFor(11)
+	assert.False(t, ok)
+}
+
+func TestWebhookRetryAfter(t *testing.T) {
+	c := WebhookConfig{URL: "http://localhost", Backoff: "10s"}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, 8, c.Attempts())
+	assert.Equal(t, 10*time.Second, c.RetryAfter(1))
+	assert.Equal(t, 40*time.Second, c.RetryAfter(3))
+	assert.Equal(t, 6*time.Hour, c.RetryAfter(30))
+
+	assert.Error(t, WebhookConfig{}.Validate())
+	assert.Error(t, WebhookConfig{URL: "http://localhost", Backoff: "soon"}.Validate())
+}
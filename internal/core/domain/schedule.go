@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+)
+
+const (
+	// checkpointEvery is the number of occurrences between stored checkpoints of irregular schedules
+	checkpointEvery = 1024
+	// regularSampleDays and regularSampleMax bound the occurrences checked to detect a regular schedule
+	regularSampleDays = 800
+	regularSampleMax  = 100000
+)
+
+// schedules caches the schedules so configuration refreshes reuse the counted occurrences
+var schedules sync.Map
+
+// schedule resolves the occurrences of a cron expression in wall clock time, the occurrence n
+// starts the epoch n. Regular schedules are resolved arithmetically and irregular ones are
+// resolved counting the occurrences from sparse checkpoints
+type schedule struct {
+	lock        sync.Mutex
+	expr        *cronexpr.Expression
+	first       time.Time
+	interval    time.Duration
+	checkpoints []time.Time
+}
+
+func scheduleFor(cron string, expr *cronexpr.Expression, first time.Time) *schedule {
+	key := fmt.Sprintf("%s@%d", cron, first.Unix())
+	if s, ok := schedules.Load(key); ok {
+		return s.(*schedule)
+	}
+	s, _ := schedules.LoadOrStore(key, newSchedule(expr, first))
+	return s.(*schedule)
+}
+
+func newSchedule(expr *cronexpr.Expression, first time.Time) *schedule {
+	s := &schedule{
+		expr:        expr,
+		first:       first,
+		checkpoints: []time.Time{first},
+	}
+	if first.IsZero() {
+		return s
+	}
+
+	// the schedule is regular when the gaps between occurrences do not change in the sample
+	next := expr.Next(first)
+	if next.IsZero() {
+		return s
+	}
+	interval := next.Sub(first)
+	limit := first.AddDate(0, 0, regularSampleDays)
+	for i := 0; i < regularSampleMax && next.Before(limit); i++ {
+		n := expr.Next(next)
+		if n.IsZero() || n.Sub(next) != interval {
+			return s
+		}
+		next = n
+	}
+	s.interval = interval
+	return s
+}
+
+// next returns the occurrence after the wall clock w, the cron expression is not safe for concurrent use
+func (s *schedule) next(w time.Time) time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.expr.Next(w)
+}
+
+// epochAt returns the number of occurrences at or before the wall clock w
+func (s *schedule) epochAt(w time.Time) int64 {
+	if s.first.IsZero() || w.Before(s.first) {
+		return 0
+	}
+	if s.interval > 0 {
+		return int64(w.Sub(s.first)/s.interval) + 1
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for !s.checkpoints[len(s.checkpoints)-1].After(w) {
+		if !s.extend() {
+			break
+		}
+	}
+	i := sort.Search(len(s.checkpoints), func(i int) bool {
+		return s.checkpoints[i].After(w)
+	}) - 1
+
+	n := int64(i)*checkpointEvery + 1
+	for t := s.expr.Next(s.checkpoints[i]); !t.IsZero() && !t.After(w); t = s.expr.Next(t) {
+		n++
+	}
+	return n
+}
+
+// occurrence returns the wall clock of the occurrence n or the zero time when it does not exist
+func (s *schedule) occurrence(n int64) time.Time {
+	if n < 1 || s.first.IsZero() {
+		return time.Time{}
+	}
+	if s.interval > 0 {
+		return s.first.Add(time.Duration(n-1) * s.interval)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := int((n - 1) / checkpointEvery)
+	for len(s.checkpoints) <= i {
+		if !s.extend() {
+			return time.Time{}
+		}
+	}
+	t := s.checkpoints[i]
+	for j := int64(0); j < (n-1)%checkpointEvery && !t.IsZero(); j++ {
+		t = s.expr.Next(t)
+	}
+	return t
+}
+
+// extend appends the next checkpoint, it returns false when the schedule has no more occurrences
+func (s *schedule) extend() bool {
+	t := s.checkpoints[len(s.checkpoints)-1]
+	for j := 0; j < checkpointEvery; j++ {
+		t = s.expr.Next(t)
+		if t.IsZero() {
+			return false
+		}
+	}
+	s.checkpoints = append(s.checkpoints, t)
+	return true
+}
@@ -1,8 +1,72 @@
 package domain
 
+import "math"
+
 // ScoreboardResult stores the scoreboard result
 type ScoreboardResult struct {
 	EntryID string
 	Score   float64
 	Rank    int64
 }
+
+// ScoreboardStanding stores the relative standing of an entry in a scoreboard, a zero
+// rank means the entry is not in the scoreboard
+type ScoreboardStanding struct {
+	EntryID            string
+	Score              float64
+	Rank               int64
+	Total              int64
+	TopPercent         float64
+	Percentile         float64
+	NextBandTopPercent float64
+	NextBandScore      float64
+}
+
+// NewScoreboardStanding creates a standing calculating the percentiles from the rank and the total of entries
+func NewScoreboardStanding(entryID string, score float64, rank int64, total int64) ScoreboardStanding {
+	standing := ScoreboardStanding{
+		EntryID: entryID,
+		Score:   score,
+		Rank:    rank,
+		Total:   total,
+	}
+	if rank > 0 && total > 0 {
+		standing.TopPercent = float64(rank) * 100 / float64(total)
+		standing.Percentile = float64(total-rank) * 100 / float64(total)
+	}
+	return standing
+}
+
+// NextBand returns the top percent band above the current one and the lowest rank inside it,
+// bands are whole percentages so an entry in the top 7% has the top 6% as next band
+func (s ScoreboardStanding) NextBand() (float64, int64) {
+	if s.Rank <= 0 || s.Total <= 0 {
+		return 0, 0
+	}
+	band := math.Ceil(s.TopPercent) - 1
+	if band < 1 {
+		return 0, 0
+	}
+	rank := int64(math.Floor(band * float64(s.Total) / 100))
+	if rank < 1 || rank >= s.Rank {
+		return 0, 0
+	}
+	return band, rank
+}
+
+// HistogramBucket stores the number of scores in the range [From, To), a nil bound means unbounded
+type HistogramBucket struct {
+	From  *float64 `json:"from,omitempty"`
+	To    *float64 `json:"to,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// ScoreboardStats stores aggregated statistics of the scores in a scoreboard
+type ScoreboardStats struct {
+	Participants int64
+	Min          float64
+	Max          float64
+	Mean         float64
+	Median       float64
+	Histogram    []HistogramBucket
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScoreboardStanding(t *testing.T) {
+	s := NewScoreboardStanding("entry", 10, 7, 100)
+	assert.Equal(t, float64(7), s.TopPercent)
+	assert.Equal(t, float64(93), s.Percentile)
+
+	band, rank := s.NextBand()
+	assert.Equal(t, float64(6), band)
+	assert.Equal(t, int64(6), rank)
+}
+
+func TestNextBandTopEntries(t *testing.T) {
+	s := NewScoreboardStanding("entry", 10, 1, 100)
+	band, rank := s.NextBand()
+	assert.Equal(t, float64(0), band)
+	assert.Equal(t, int64(0), rank)
+
+	s = NewScoreboardStanding("entry", 10, 2, 10)
+	band, rank = s.NextBand()
+	assert.Equal(t, float64(19), band)
+	assert.Equal(t, int64(1), rank)
+}
+
+func TestNewScoreboardStandingNotRanked(t *testing.T) {
+	s := NewScoreboardStanding("entry", 0, 0, 100)
+	assert.Equal(t, float64(0), s.TopPercent)
+	band, rank := s.NextBand()
+	assert.Equal(t, float64(0), band)
+	assert.Equal(t, int64(0), rank)
+}
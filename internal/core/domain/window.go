@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// window is the single epoch of a fixed window reset
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// newWindow parses the start and end of a window reset in the time zone of the expression
+func newWindow(e *CronExpression, reset ResetExpression) (*window, error) {
+	if reset.Start == "" || reset.End == "" {
+		return nil, fmt.Errorf("window reset requires a start and an end")
+	}
+	start, err := e.parseAnchor(reset.Start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse window start: %v", err)
+	}
+	end, err := e.parseAnchor(reset.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse window end: %v", err)
+	}
+	w := &window{start: e.fromWallClock(start).UTC(), end: e.fromWallClock(end).UTC()}
+	if !w.end.After(w.start) {
+		return nil, fmt.Errorf("window end '%v' must be after start '%v'", reset.End, reset.Start)
+	}
+	return w, nil
+}
+
+// epoch is the start unix timestamp so the keys of each event are unique for the same name
+func (w *window) epoch() int64 {
+	return w.start.Unix()
+}
+
+// contains checks if the reference is within [start, end)
+func (w *window) contains(ref time.Time) bool {
+	return !ref.Before(w.start) && ref.Before(w.end)
+}
@@ -10,10 +10,12 @@
 package mocks
 
 import (
+	io "io"
 	reflect "reflect"
 	time "time"
 
 	domain "github.com/posilva/simpleboards/internal/core/domain"
+	ports "github.com/posilva/simpleboards/internal/core/ports"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -70,6 +72,307 @@ func (mr *MockRepositoryMockRecorder) AddWithMetadata(entry, leaderboard, value,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWithMetadata", reflect.TypeOf((*MockRepository)(nil).AddWithMetadata), entry, leaderboard, value, meta)
 }
 
+// AdvanceEpoch mocks base method.
+func (m *MockRepository) AdvanceEpoch(leaderboard string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceEpoch", leaderboard)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceEpoch indicates an expected call of AdvanceEpoch.
+func (mr *MockRepositoryMockRecorder) AdvanceEpoch(leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceEpoch", reflect.TypeOf((*MockRepository)(nil).AdvanceEpoch), leaderboard)
+}
+
+// ClaimPrize mocks base method.
+func (m *MockRepository) ClaimPrize(entry, leaderboard string, epoch int64, claimID string, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPrize", entry, leaderboard, epoch, claimID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPrize indicates an expected call of ClaimPrize.
+func (mr *MockRepositoryMockRecorder) ClaimPrize(entry, leaderboard, epoch, claimID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPrize", reflect.TypeOf((*MockRepository)(nil).ClaimPrize), entry, leaderboard, epoch, claimID, at)
+}
+
+// CompositeWithMetadata mocks base method.
+func (m *MockRepository) CompositeWithMetadata(entry, leaderboard string, value domain.CompositeScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompositeWithMetadata", entry, leaderboard, value, function, meta)
+	ret0, _ := ret[0].(domain.ScoreUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompositeWithMetadata indicates an expected call of CompositeWithMetadata.
+func (mr *MockRepositoryMockRecorder) CompositeWithMetadata(entry, leaderboard, value, function, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompositeWithMetadata", reflect.TypeOf((*MockRepository)(nil).CompositeWithMetadata), entry, leaderboard, value, function, meta)
+}
+
+// CreateEntryPrizes mocks base method.
+func (m *MockRepository) CreateEntryPrizes(batch domain.AwardBatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntryPrizes", batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEntryPrizes indicates an expected call of CreateEntryPrizes.
+func (mr *MockRepositoryMockRecorder) CreateEntryPrizes(batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntryPrizes", reflect.TypeOf((*MockRepository)(nil).CreateEntryPrizes), batch)
+}
+
+// CreatePrizeDelivery mocks base method.
+func (m *MockRepository) CreatePrizeDelivery(delivery domain.PrizeDelivery) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePrizeDelivery", delivery)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePrizeDelivery indicates an expected call of CreatePrizeDelivery.
+func (mr *MockRepositoryMockRecorder) CreatePrizeDelivery(delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePrizeDelivery", reflect.TypeOf((*MockRepository)(nil).CreatePrizeDelivery), delivery)
+}
+
+// DecayWithMetadata mocks base method.
+func (m *MockRepository) DecayWithMetadata(entry, leaderboard string, value domain.DecayedScore, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecayWithMetadata", entry, leaderboard, value, meta)
+	ret0, _ := ret[0].(domain.ScoreUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecayWithMetadata indicates an expected call of DecayWithMetadata.
+func (mr *MockRepositoryMockRecorder) DecayWithMetadata(entry, leaderboard, value, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecayWithMetadata", reflect.TypeOf((*MockRepository)(nil).DecayWithMetadata), entry, leaderboard, value, meta)
+}
+
+// DeleteEntry mocks base method.
+func (m *MockRepository) DeleteEntry(entry, leaderboard string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEntry", entry, leaderboard)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEntry indicates an expected call of DeleteEntry.
+func (mr *MockRepositoryMockRecorder) DeleteEntry(entry, leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockRepository)(nil).DeleteEntry), entry, leaderboard)
+}
+
+// ExactWithMetadata mocks base method.
+func (m *MockRepository) ExactWithMetadata(entry, leaderboard string, value domain.ExactScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExactWithMetadata", entry, leaderboard, value, function, meta)
+	ret0, _ := ret[0].(domain.ScoreUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExactWithMetadata indicates an expected call of ExactWithMetadata.
+func (mr *MockRepositoryMockRecorder) ExactWithMetadata(entry, leaderboard, value, function, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExactWithMetadata", reflect.TypeOf((*MockRepository)(nil).ExactWithMetadata), entry, leaderboard, value, function, meta)
+}
+
+// GetClosedEpoch mocks base method.
+func (m *MockRepository) GetClosedEpoch(leaderboard string) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClosedEpoch", leaderboard)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetClosedEpoch indicates an expected call of GetClosedEpoch.
+func (mr *MockRepositoryMockRecorder) GetClosedEpoch(leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedEpoch", reflect.TypeOf((*MockRepository)(nil).GetClosedEpoch), leaderboard)
+}
+
+// GetEntries mocks base method.
+func (m *MockRepository) GetEntries(leaderboard string, entries []string) (map[string]domain.StoredEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntries", leaderboard, entries)
+	ret0, _ := ret[0].(map[string]domain.StoredEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntries indicates an expected call of GetEntries.
+func (mr *MockRepositoryMockRecorder) GetEntries(leaderboard, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntries", reflect.TypeOf((*MockRepository)(nil).GetEntries), leaderboard, entries)
+}
+
+// GetEntryPrize mocks base method.
+func (m *MockRepository) GetEntryPrize(entry, leaderboard string, epoch int64) (domain.EntryPrize, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntryPrize", entry, leaderboard, epoch)
+	ret0, _ := ret[0].(domain.EntryPrize)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEntryPrize indicates an expected call of GetEntryPrize.
+func (mr *MockRepositoryMockRecorder) GetEntryPrize(entry, leaderboard, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryPrize", reflect.TypeOf((*MockRepository)(nil).GetEntryPrize), entry, leaderboard, epoch)
+}
+
+// GetEpoch mocks base method.
+func (m *MockRepository) GetEpoch(leaderboard string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEpoch", leaderboard)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEpoch indicates an expected call of GetEpoch.
+func (mr *MockRepositoryMockRecorder) GetEpoch(leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpoch", reflect.TypeOf((*MockRepository)(nil).GetEpoch), leaderboard)
+}
+
+// GetExactScores mocks base method.
+func (m *MockRepository) GetExactScores(leaderboard string, entries []string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExactScores", leaderboard, entries)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExactScores indicates an expected call of GetExactScores.
+func (mr *MockRepositoryMockRecorder) GetExactScores(leaderboard, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExactScores", reflect.TypeOf((*MockRepository)(nil).GetExactScores), leaderboard, entries)
+}
+
+// GetFriends mocks base method.
+func (m *MockRepository) GetFriends(entry string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFriends", entry)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFriends indicates an expected call of GetFriends.
+func (mr *MockRepositoryMockRecorder) GetFriends(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFriends", reflect.TypeOf((*MockRepository)(nil).GetFriends), entry)
+}
+
+// GetLifecycleAudit mocks base method.
+func (m *MockRepository) GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLifecycleAudit", name)
+	ret0, _ := ret[0].([]domain.LifecycleAudit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLifecycleAudit indicates an expected call of GetLifecycleAudit.
+func (mr *MockRepositoryMockRecorder) GetLifecycleAudit(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLifecycleAudit", reflect.TypeOf((*MockRepository)(nil).GetLifecycleAudit), name)
+}
+
+// GetPrizeDeliveries mocks base method.
+func (m *MockRepository) GetPrizeDeliveries(state domain.DeliveryState) ([]domain.PrizeDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrizeDeliveries", state)
+	ret0, _ := ret[0].([]domain.PrizeDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrizeDeliveries indicates an expected call of GetPrizeDeliveries.
+func (mr *MockRepositoryMockRecorder) GetPrizeDeliveries(state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrizeDeliveries", reflect.TypeOf((*MockRepository)(nil).GetPrizeDeliveries), state)
+}
+
+// GetPrizeDelivery mocks base method.
+func (m *MockRepository) GetPrizeDelivery(leaderboard string, epoch int64) (domain.PrizeDelivery, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrizeDelivery", leaderboard, epoch)
+	ret0, _ := ret[0].(domain.PrizeDelivery)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPrizeDelivery indicates an expected call of GetPrizeDelivery.
+func (mr *MockRepositoryMockRecorder) GetPrizeDelivery(leaderboard, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrizeDelivery", reflect.TypeOf((*MockRepository)(nil).GetPrizeDelivery), leaderboard, epoch)
+}
+
+// GetSubmissions mocks base method.
+func (m *MockRepository) GetSubmissions(leaderboard string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubmissions", leaderboard)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubmissions indicates an expected call of GetSubmissions.
+func (mr *MockRepositoryMockRecorder) GetSubmissions(leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubmissions", reflect.TypeOf((*MockRepository)(nil).GetSubmissions), leaderboard)
+}
+
+// GetUnclaimedPrizes mocks base method.
+func (m *MockRepository) GetUnclaimedPrizes(entry string) ([]domain.EntryPrize, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnclaimedPrizes", entry)
+	ret0, _ := ret[0].([]domain.EntryPrize)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnclaimedPrizes indicates an expected call of GetUnclaimedPrizes.
+func (mr *MockRepositoryMockRecorder) GetUnclaimedPrizes(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnclaimedPrizes", reflect.TypeOf((*MockRepository)(nil).GetUnclaimedPrizes), entry)
+}
+
+// IncrementSubmissions mocks base method.
+func (m *MockRepository) IncrementSubmissions(leaderboard string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementSubmissions", leaderboard)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementSubmissions indicates an expected call of IncrementSubmissions.
+func (mr *MockRepositoryMockRecorder) IncrementSubmissions(leaderboard any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSubmissions", reflect.TypeOf((*MockRepository)(nil).IncrementSubmissions), leaderboard)
+}
+
 // Last mocks base method.
 func (m *MockRepository) Last(entry, leaderboard string, value float64) (domain.ScoreUpdate, error) {
 	m.ctrl.T.Helper()
@@ -130,6 +433,22 @@ func (mr *MockRepositoryMockRecorder) MaxWithMetadata(entry, leaderboard, value,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxWithMetadata", reflect.TypeOf((*MockRepository)(nil).MaxWithMetadata), entry, leaderboard, value, meta)
 }
 
+// MigrateEntries mocks base method.
+func (m *MockRepository) MigrateEntries(from, to string, entries []string) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateEntries", from, to, entries)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrateEntries indicates an expected call of MigrateEntries.
+func (mr *MockRepositoryMockRecorder) MigrateEntries(from, to, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateEntries", reflect.TypeOf((*MockRepository)(nil).MigrateEntries), from, to, entries)
+}
+
 // Min mocks base method.
 func (m *MockRepository) Min(entry, leaderboard string, value float64) (domain.ScoreUpdate, error) {
 	m.ctrl.T.Helper()
@@ -160,107 +479,393 @@ func (mr *MockRepositoryMockRecorder) MinWithMetadata(entry, leaderboard, value,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MinWithMetadata", reflect.TypeOf((*MockRepository)(nil).MinWithMetadata), entry, leaderboard, value, meta)
 }
 
-// MockLogger is a mock of Logger interface.
-type MockLogger struct {
-	ctrl     *gomock.Controller
-	recorder *MockLoggerMockRecorder
+// PutEntries mocks base method.
+func (m *MockRepository) PutEntries(leaderboard string, entries map[string]domain.StoredEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutEntries", leaderboard, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// MockLoggerMockRecorder is the mock recorder for MockLogger.
-type MockLoggerMockRecorder struct {
-	mock *MockLogger
+// PutEntries indicates an expected call of PutEntries.
+func (mr *MockRepositoryMockRecorder) PutEntries(leaderboard, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutEntries", reflect.TypeOf((*MockRepository)(nil).PutEntries), leaderboard, entries)
 }
 
-// NewMockLogger creates a new mock instance.
-func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
-	mock := &MockLogger{ctrl: ctrl}
-	mock.recorder = &MockLoggerMockRecorder{mock}
-	return mock
+// ReplaceEntries mocks base method.
+func (m *MockRepository) ReplaceEntries(leaderboard string, entries map[string]domain.StoredEntry, counters map[string]uint64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceEntries", leaderboard, entries, counters)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
-	return m.recorder
+// ReplaceEntries indicates an expected call of ReplaceEntries.
+func (mr *MockRepositoryMockRecorder) ReplaceEntries(leaderboard, entries, counters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceEntries", reflect.TypeOf((*MockRepository)(nil).ReplaceEntries), leaderboard, entries, counters)
 }
 
-// Debug mocks base method.
-func (m *MockLogger) Debug(msg string, v ...any) error {
+// SetClosedEpoch mocks base method.
+func (m *MockRepository) SetClosedEpoch(leaderboard string, epoch int64) error {
 	m.ctrl.T.Helper()
-	varargs := []any{msg}
-	for _, a := range v {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Debug", varargs...)
+	ret := m.ctrl.Call(m, "SetClosedEpoch", leaderboard, epoch)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Debug indicates an expected call of Debug.
-func (mr *MockLoggerMockRecorder) Debug(msg any, v ...any) *gomock.Call {
+// SetClosedEpoch indicates an expected call of SetClosedEpoch.
+func (mr *MockRepositoryMockRecorder) SetClosedEpoch(leaderboard, epoch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{msg}, v...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClosedEpoch", reflect.TypeOf((*MockRepository)(nil).SetClosedEpoch), leaderboard, epoch)
 }
 
-// Error mocks base method.
-func (m *MockLogger) Error(msg string, v ...any) error {
+// SetFriends mocks base method.
+func (m *MockRepository) SetFriends(entry string, friends []string) error {
 	m.ctrl.T.Helper()
-	varargs := []any{msg}
-	for _, a := range v {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Error", varargs...)
+	ret := m.ctrl.Call(m, "SetFriends", entry, friends)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Error indicates an expected call of Error.
-func (mr *MockLoggerMockRecorder) Error(msg any, v ...any) *gomock.Call {
+// SetFriends indicates an expected call of SetFriends.
+func (mr *MockRepositoryMockRecorder) SetFriends(entry, friends any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{msg}, v...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogger)(nil).Error), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFriends", reflect.TypeOf((*MockRepository)(nil).SetFriends), entry, friends)
 }
 
-// Info mocks base method.
-func (m *MockLogger) Info(msg string, v ...any) error {
+// TransitionLifecycle mocks base method.
+func (m *MockRepository) TransitionLifecycle(name string, lifecycle domain.LeaderboardLifecycle, audit domain.LifecycleAudit) error {
 	m.ctrl.T.Helper()
-	varargs := []any{msg}
-	for _, a := range v {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Info", varargs...)
+	ret := m.ctrl.Call(m, "TransitionLifecycle", name, lifecycle, audit)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Info indicates an expected call of Info.
-func (mr *MockLoggerMockRecorder) Info(msg any, v ...any) *gomock.Call {
+// TransitionLifecycle indicates an expected call of TransitionLifecycle.
+func (mr *MockRepositoryMockRecorder) TransitionLifecycle(name, lifecycle, audit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{msg}, v...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionLifecycle", reflect.TypeOf((*MockRepository)(nil).TransitionLifecycle), name, lifecycle, audit)
 }
 
-// MockLeaderboardsService is a mock of LeaderboardsService interface.
-type MockLeaderboardsService struct {
-	ctrl     *gomock.Controller
-	recorder *MockLeaderboardsServiceMockRecorder
+// UpdatePrizeDelivery mocks base method.
+func (m *MockRepository) UpdatePrizeDelivery(delivery domain.PrizeDelivery, attempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePrizeDelivery", delivery, attempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// MockLeaderboardsServiceMockRecorder is the mock recorder for MockLeaderboardsService.
-type MockLeaderboardsServiceMockRecorder struct {
-	mock *MockLeaderboardsService
+// UpdatePrizeDelivery indicates an expected call of UpdatePrizeDelivery.
+func (mr *MockRepositoryMockRecorder) UpdatePrizeDelivery(delivery, attempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePrizeDelivery", reflect.TypeOf((*MockRepository)(nil).UpdatePrizeDelivery), delivery, attempts)
 }
 
-// NewMockLeaderboardsService creates a new mock instance.
-func NewMockLeaderboardsService(ctrl *gomock.Controller) *MockLeaderboardsService {
-	mock := &MockLeaderboardsService{ctrl: ctrl}
-	mock.recorder = &MockLeaderboardsServiceMockRecorder{mock}
-	return mock
+// WithOutbox mocks base method.
+func (m *MockRepository) WithOutbox(events ...domain.Event) ports.Repository {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithOutbox", varargs...)
+	ret0, _ := ret[0].(ports.Repository)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockLeaderboardsService) EXPECT() *MockLeaderboardsServiceMockRecorder {
-	return m.recorder
+// WithOutbox indicates an expected call of WithOutbox.
+func (mr *MockRepositoryMockRecorder) WithOutbox(events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithOutbox", reflect.TypeOf((*MockRepository)(nil).WithOutbox), events...)
+}
+
+// MockPrizeNotifier is a mock of PrizeNotifier interface.
+type MockPrizeNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrizeNotifierMockRecorder
+}
+
+// MockPrizeNotifierMockRecorder is the mock recorder for MockPrizeNotifier.
+type MockPrizeNotifierMockRecorder struct {
+	mock *MockPrizeNotifier
+}
+
+// NewMockPrizeNotifier creates a new mock instance.
+func NewMockPrizeNotifier(ctrl *gomock.Controller) *MockPrizeNotifier {
+	mock := &MockPrizeNotifier{ctrl: ctrl}
+	mock.recorder = &MockPrizeNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrizeNotifier) EXPECT() *MockPrizeNotifierMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method.
+func (m *MockPrizeNotifier) Notify(webhook domain.WebhookConfig, batch domain.AwardBatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", webhook, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockPrizeNotifierMockRecorder) Notify(webhook, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockPrizeNotifier)(nil).Notify), webhook, batch)
+}
+
+// MockEventPublisher is a mock of EventPublisher interface.
+type MockEventPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventPublisherMockRecorder
+}
+
+// MockEventPublisherMockRecorder is the mock recorder for MockEventPublisher.
+type MockEventPublisherMockRecorder struct {
+	mock *MockEventPublisher
+}
+
+// NewMockEventPublisher creates a new mock instance.
+func NewMockEventPublisher(ctrl *gomock.Controller) *MockEventPublisher {
+	mock := &MockEventPublisher{ctrl: ctrl}
+	mock.recorder = &MockEventPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventPublisher) EXPECT() *MockEventPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockEventPublisher) Publish(events ...domain.Event) error {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Publish", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventPublisherMockRecorder) Publish(events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventPublisher)(nil).Publish), events...)
+}
+
+// MockEventOutbox is a mock of EventOutbox interface.
+type MockEventOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventOutboxMockRecorder
+}
+
+// MockEventOutboxMockRecorder is the mock recorder for MockEventOutbox.
+type MockEventOutboxMockRecorder struct {
+	mock *MockEventOutbox
+}
+
+// NewMockEventOutbox creates a new mock instance.
+func NewMockEventOutbox(ctrl *gomock.Controller) *MockEventOutbox {
+	mock := &MockEventOutbox{ctrl: ctrl}
+	mock.recorder = &MockEventOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventOutbox) EXPECT() *MockEventOutboxMockRecorder {
+	return m.recorder
+}
+
+// DeleteEvents mocks base method.
+func (m *MockEventOutbox) DeleteEvents(ids ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteEvents", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEvents indicates an expected call of DeleteEvents.
+func (mr *MockEventOutboxMockRecorder) DeleteEvents(ids ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvents", reflect.TypeOf((*MockEventOutbox)(nil).DeleteEvents), ids...)
+}
+
+// GetPendingEvents mocks base method.
+func (m *MockEventOutbox) GetPendingEvents(before time.Time, limit int) ([]domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingEvents", before, limit)
+	ret0, _ := ret[0].([]domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingEvents indicates an expected call of GetPendingEvents.
+func (mr *MockEventOutboxMockRecorder) GetPendingEvents(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingEvents", reflect.TypeOf((*MockEventOutbox)(nil).GetPendingEvents), before, limit)
+}
+
+// SaveEvents mocks base method.
+func (m *MockEventOutbox) SaveEvents(events ...domain.Event) error {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveEvents", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvents indicates an expected call of SaveEvents.
+func (mr *MockEventOutboxMockRecorder) SaveEvents(events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvents", reflect.TypeOf((*MockEventOutbox)(nil).SaveEvents), events...)
+}
+
+// MockLogger is a mock of Logger interface.
+type MockLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoggerMockRecorder
+}
+
+// MockLoggerMockRecorder is the mock recorder for MockLogger.
+type MockLoggerMockRecorder struct {
+	mock *MockLogger
+}
+
+// NewMockLogger creates a new mock instance.
+func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
+	mock := &MockLogger{ctrl: ctrl}
+	mock.recorder = &MockLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
+	return m.recorder
+}
+
+// Debug mocks base method.
+func (m *MockLogger) Debug(msg string, v ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Debug", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Debug indicates an expected call of Debug.
+func (mr *MockLoggerMockRecorder) Debug(msg any, v ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), varargs...)
+}
+
+// Error mocks base method.
+func (m *MockLogger) Error(msg string, v ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Error", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Error indicates an expected call of Error.
+func (mr *MockLoggerMockRecorder) Error(msg any, v ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogger)(nil).Error), varargs...)
+}
+
+// Info mocks base method.
+func (m *MockLogger) Info(msg string, v ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{msg}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Info", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Info indicates an expected call of Info.
+func (mr *MockLoggerMockRecorder) Info(msg any, v ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{msg}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), varargs...)
+}
+
+// MockLeaderboardsService is a mock of LeaderboardsService interface.
+type MockLeaderboardsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaderboardsServiceMockRecorder
+}
+
+// MockLeaderboardsServiceMockRecorder is the mock recorder for MockLeaderboardsService.
+type MockLeaderboardsServiceMockRecorder struct {
+	mock *MockLeaderboardsService
+}
+
+// NewMockLeaderboardsService creates a new mock instance.
+func NewMockLeaderboardsService(ctrl *gomock.Controller) *MockLeaderboardsService {
+	mock := &MockLeaderboardsService{ctrl: ctrl}
+	mock.recorder = &MockLeaderboardsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaderboardsService) EXPECT() *MockLeaderboardsServiceMockRecorder {
+	return m.recorder
+}
+
+// AdvanceEpoch mocks base method.
+func (m *MockLeaderboardsService) AdvanceEpoch(name string) (domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceEpoch", name)
+	ret0, _ := ret[0].(domain.EpochInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceEpoch indicates an expected call of AdvanceEpoch.
+func (mr *MockLeaderboardsServiceMockRecorder) AdvanceEpoch(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceEpoch", reflect.TypeOf((*MockLeaderboardsService)(nil).AdvanceEpoch), name)
+}
+
+// ExportStandings mocks base method.
+func (m *MockLeaderboardsService) ExportStandings(name string, epoch int64, scoreboards domain.Metadata, out ports.StandingsWriter) (domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportStandings", name, epoch, scoreboards, out)
+	ret0, _ := ret[0].(domain.EpochInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportStandings indicates an expected call of ExportStandings.
+func (mr *MockLeaderboardsServiceMockRecorder) ExportStandings(name, epoch, scoreboards, out any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportStandings", reflect.TypeOf((*MockLeaderboardsService)(nil).ExportStandings), name, epoch, scoreboards, out)
 }
 
 // GetConfig mocks base method.
@@ -272,102 +877,621 @@ func (m *MockLeaderboardsService) GetConfig(name string) (domain.LeaderboardConf
 	return ret0, ret1
 }
 
-// GetConfig indicates an expected call of GetConfig.
-func (mr *MockLeaderboardsServiceMockRecorder) GetConfig(name any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockLeaderboardsService)(nil).GetConfig), name)
+// GetConfig indicates an expected call of GetConfig.
+func (mr *MockLeaderboardsServiceMockRecorder) GetConfig(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockLeaderboardsService)(nil).GetConfig), name)
+}
+
+// GetConfigStatus mocks base method.
+func (m *MockLeaderboardsService) GetConfigStatus() domain.ConfigStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigStatus")
+	ret0, _ := ret[0].(domain.ConfigStatus)
+	return ret0
+}
+
+// GetConfigStatus indicates an expected call of GetConfigStatus.
+func (mr *MockLeaderboardsServiceMockRecorder) GetConfigStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigStatus", reflect.TypeOf((*MockLeaderboardsService)(nil).GetConfigStatus))
+}
+
+// GetEpochInfo mocks base method.
+func (m *MockLeaderboardsService) GetEpochInfo(name string, epoch int64) (domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEpochInfo", name, epoch)
+	ret0, _ := ret[0].(domain.EpochInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEpochInfo indicates an expected call of GetEpochInfo.
+func (mr *MockLeaderboardsServiceMockRecorder) GetEpochInfo(name, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpochInfo", reflect.TypeOf((*MockLeaderboardsService)(nil).GetEpochInfo), name, epoch)
+}
+
+// GetFriendsScores mocks base method.
+func (m *MockLeaderboardsService) GetFriendsScores(entryID, name string, epoch int64, friends []string) (domain.LeaderboardScores, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFriendsScores", entryID, name, epoch, friends)
+	ret0, _ := ret[0].(domain.LeaderboardScores)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetFriendsScores indicates an expected call of GetFriendsScores.
+func (mr *MockLeaderboardsServiceMockRecorder) GetFriendsScores(entryID, name, epoch, friends any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFriendsScores", reflect.TypeOf((*MockLeaderboardsService)(nil).GetFriendsScores), entryID, name, epoch, friends)
+}
+
+// GetLifecycleAudit mocks base method.
+func (m *MockLeaderboardsService) GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLifecycleAudit", name)
+	ret0, _ := ret[0].([]domain.LifecycleAudit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLifecycleAudit indicates an expected call of GetLifecycleAudit.
+func (mr *MockLeaderboardsServiceMockRecorder) GetLifecycleAudit(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLifecycleAudit", reflect.TypeOf((*MockLeaderboardsService)(nil).GetLifecycleAudit), name)
+}
+
+// GetResults mocks base method.
+func (m *MockLeaderboardsService) GetResults(name string, epoch int64) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResults", name, epoch)
+	ret0, _ := ret[0].([]domain.LeaderboardScores)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetResults indicates an expected call of GetResults.
+func (mr *MockLeaderboardsServiceMockRecorder) GetResults(name, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResults", reflect.TypeOf((*MockLeaderboardsService)(nil).GetResults), name, epoch)
+}
+
+// GetResultsWithMetadata mocks base method.
+func (m *MockLeaderboardsService) GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResultsWithMetadata", name, epoch, meta)
+	ret0, _ := ret[0].([]domain.LeaderboardScores)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetResultsWithMetadata indicates an expected call of GetResultsWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) GetResultsWithMetadata(name, epoch, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResultsWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).GetResultsWithMetadata), name, epoch, meta)
+}
+
+// GetStandingsWithMetadata mocks base method.
+func (m *MockLeaderboardsService) GetStandingsWithMetadata(entryID, name string, meta domain.Metadata, nextBand bool) ([]domain.LeaderboardStanding, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStandingsWithMetadata", entryID, name, meta, nextBand)
+	ret0, _ := ret[0].([]domain.LeaderboardStanding)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetStandingsWithMetadata indicates an expected call of GetStandingsWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) GetStandingsWithMetadata(entryID, name, meta, nextBand any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStandingsWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).GetStandingsWithMetadata), entryID, name, meta, nextBand)
+}
+
+// GetStats mocks base method.
+func (m *MockLeaderboardsService) GetStats(name string, epoch int64, buckets []float64) (domain.LeaderboardStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", name, epoch, buckets)
+	ret0, _ := ret[0].(domain.LeaderboardStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockLeaderboardsServiceMockRecorder) GetStats(name, epoch, buckets any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockLeaderboardsService)(nil).GetStats), name, epoch, buckets)
+}
+
+// ImportScores mocks base method.
+func (m *MockLeaderboardsService) ImportScores(name string, in ports.RecordReader, options domain.ImportOptions, checkpoint ports.ImportCheckpoint) (domain.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportScores", name, in, options, checkpoint)
+	ret0, _ := ret[0].(domain.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportScores indicates an expected call of ImportScores.
+func (mr *MockLeaderboardsServiceMockRecorder) ImportScores(name, in, options, checkpoint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportScores", reflect.TypeOf((*MockLeaderboardsService)(nil).ImportScores), name, in, options, checkpoint)
+}
+
+// ListScores mocks base method.
+func (m *MockLeaderboardsService) ListScores(name string) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScores", name)
+	ret0, _ := ret[0].([]domain.LeaderboardScores)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListScores indicates an expected call of ListScores.
+func (mr *MockLeaderboardsServiceMockRecorder) ListScores(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScores", reflect.TypeOf((*MockLeaderboardsService)(nil).ListScores), name)
+}
+
+// ListScoresWithMetadata mocks base method.
+func (m *MockLeaderboardsService) ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScoresWithMetadata", name, meta)
+	ret0, _ := ret[0].([]domain.LeaderboardScores)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListScoresWithMetadata indicates an expected call of ListScoresWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) ListScoresWithMetadata(name, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScoresWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ListScoresWithMetadata), name, meta)
+}
+
+// MigrateLegacyEpochs mocks base method.
+func (m *MockLeaderboardsService) MigrateLegacyEpochs(name string, legacyEpochs []int64) ([]domain.EpochMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateLegacyEpochs", name, legacyEpochs)
+	ret0, _ := ret[0].([]domain.EpochMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateLegacyEpochs indicates an expected call of MigrateLegacyEpochs.
+func (mr *MockLeaderboardsServiceMockRecorder) MigrateLegacyEpochs(name, legacyEpochs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateLegacyEpochs", reflect.TypeOf((*MockLeaderboardsService)(nil).MigrateLegacyEpochs), name, legacyEpochs)
+}
+
+// RemoveScore mocks base method.
+func (m *MockLeaderboardsService) RemoveScore(entryID, name string, epoch int64) ([]string, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveScore", entryID, name, epoch)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RemoveScore indicates an expected call of RemoveScore.
+func (mr *MockLeaderboardsServiceMockRecorder) RemoveScore(entryID, name, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveScore", reflect.TypeOf((*MockLeaderboardsService)(nil).RemoveScore), entryID, name, epoch)
+}
+
+// ReportComponentsWithMetadata mocks base method.
+func (m *MockLeaderboardsService) ReportComponentsWithMetadata(entryID, name string, components []float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportComponentsWithMetadata", entryID, name, components, meta)
+	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportComponentsWithMetadata indicates an expected call of ReportComponentsWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) ReportComponentsWithMetadata(entryID, name, components, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportComponentsWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportComponentsWithMetadata), entryID, name, components, meta)
+}
+
+// ReportDecimalScoreWithMetadata mocks base method.
+func (m *MockLeaderboardsService) ReportDecimalScoreWithMetadata(entryID, name, value string, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportDecimalScoreWithMetadata", entryID, name, value, meta)
+	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportDecimalScoreWithMetadata indicates an expected call of ReportDecimalScoreWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) ReportDecimalScoreWithMetadata(entryID, name, value, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportDecimalScoreWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportDecimalScoreWithMetadata), entryID, name, value, meta)
+}
+
+// ReportScore mocks base method.
+func (m *MockLeaderboardsService) ReportScore(entryID, name string, value float64) (domain.ReportScoreOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportScore", entryID, name, value)
+	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportScore indicates an expected call of ReportScore.
+func (mr *MockLeaderboardsServiceMockRecorder) ReportScore(entryID, name, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScore", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportScore), entryID, name, value)
+}
+
+// ReportScoreWithMetadata mocks base method.
+func (m *MockLeaderboardsService) ReportScoreWithMetadata(entryID, name string, value float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportScoreWithMetadata", entryID, name, value, meta)
+	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportScoreWithMetadata indicates an expected call of ReportScoreWithMetadata.
+func (mr *MockLeaderboardsServiceMockRecorder) ReportScoreWithMetadata(entryID, name, value, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScoreWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportScoreWithMetadata), entryID, name, value, meta)
+}
+
+// SetFriends mocks base method.
+func (m *MockLeaderboardsService) SetFriends(entryID string, friends []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFriends", entryID, friends)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFriends indicates an expected call of SetFriends.
+func (mr *MockLeaderboardsServiceMockRecorder) SetFriends(entryID, friends any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFriends", reflect.TypeOf((*MockLeaderboardsService)(nil).SetFriends), entryID, friends)
+}
+
+// SetScore mocks base method.
+func (m *MockLeaderboardsService) SetScore(entryID, name string, epoch int64, score string, meta domain.Metadata) (domain.ScoreUpdate, domain.EpochInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetScore", entryID, name, epoch, score, meta)
+	ret0, _ := ret[0].(domain.ScoreUpdate)
+	ret1, _ := ret[1].(domain.EpochInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetScore indicates an expected call of SetScore.
+func (mr *MockLeaderboardsServiceMockRecorder) SetScore(entryID, name, epoch, score, meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetScore", reflect.TypeOf((*MockLeaderboardsService)(nil).SetScore), entryID, name, epoch, score, meta)
+}
+
+// TransitionLifecycle mocks base method.
+func (m *MockLeaderboardsService) TransitionLifecycle(name string, to domain.LeaderboardState, activateAt *time.Time, actor, reason string) (domain.LeaderboardLifecycle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionLifecycle", name, to, activateAt, actor, reason)
+	ret0, _ := ret[0].(domain.LeaderboardLifecycle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionLifecycle indicates an expected call of TransitionLifecycle.
+func (mr *MockLeaderboardsServiceMockRecorder) TransitionLifecycle(name, to, activateAt, actor, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionLifecycle", reflect.TypeOf((*MockLeaderboardsService)(nil).TransitionLifecycle), name, to, activateAt, actor, reason)
+}
+
+// MockRecordReader is a mock of RecordReader interface.
+type MockRecordReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecordReaderMockRecorder
+}
+
+// MockRecordReaderMockRecorder is the mock recorder for MockRecordReader.
+type MockRecordReaderMockRecorder struct {
+	mock *MockRecordReader
+}
+
+// NewMockRecordReader creates a new mock instance.
+func NewMockRecordReader(ctrl *gomock.Controller) *MockRecordReader {
+	mock := &MockRecordReader{ctrl: ctrl}
+	mock.recorder = &MockRecordReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecordReader) EXPECT() *MockRecordReaderMockRecorder {
+	return m.recorder
+}
+
+// Read mocks base method.
+func (m *MockRecordReader) Read() (domain.ImportRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read")
+	ret0, _ := ret[0].(domain.ImportRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockRecordReaderMockRecorder) Read() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockRecordReader)(nil).Read))
+}
+
+// MockImportCheckpoint is a mock of ImportCheckpoint interface.
+type MockImportCheckpoint struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportCheckpointMockRecorder
+}
+
+// MockImportCheckpointMockRecorder is the mock recorder for MockImportCheckpoint.
+type MockImportCheckpointMockRecorder struct {
+	mock *MockImportCheckpoint
+}
+
+// NewMockImportCheckpoint creates a new mock instance.
+func NewMockImportCheckpoint(ctrl *gomock.Controller) *MockImportCheckpoint {
+	mock := &MockImportCheckpoint{ctrl: ctrl}
+	mock.recorder = &MockImportCheckpointMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportCheckpoint) EXPECT() *MockImportCheckpointMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockImportCheckpoint) Load() (domain.ImportReport, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load")
+	ret0, _ := ret[0].(domain.ImportReport)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockImportCheckpointMockRecorder) Load() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockImportCheckpoint)(nil).Load))
+}
+
+// Save mocks base method.
+func (m *MockImportCheckpoint) Save(report domain.ImportReport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockImportCheckpointMockRecorder) Save(report any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockImportCheckpoint)(nil).Save), report)
+}
+
+// MockStandingsWriter is a mock of StandingsWriter interface.
+type MockStandingsWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStandingsWriterMockRecorder
+}
+
+// MockStandingsWriterMockRecorder is the mock recorder for MockStandingsWriter.
+type MockStandingsWriterMockRecorder struct {
+	mock *MockStandingsWriter
+}
+
+// NewMockStandingsWriter creates a new mock instance.
+func NewMockStandingsWriter(ctrl *gomock.Controller) *MockStandingsWriter {
+	mock := &MockStandingsWriter{ctrl: ctrl}
+	mock.recorder = &MockStandingsWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStandingsWriter) EXPECT() *MockStandingsWriterMockRecorder {
+	return m.recorder
+}
+
+// Flush mocks base method.
+func (m *MockStandingsWriter) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockStandingsWriterMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockStandingsWriter)(nil).Flush))
+}
+
+// Write mocks base method.
+func (m *MockStandingsWriter) Write(row domain.StandingsRow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockStandingsWriterMockRecorder) Write(row any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockStandingsWriter)(nil).Write), row)
+}
+
+// MockStandingsFormats is a mock of StandingsFormats interface.
+type MockStandingsFormats struct {
+	ctrl     *gomock.Controller
+	recorder *MockStandingsFormatsMockRecorder
+}
+
+// MockStandingsFormatsMockRecorder is the mock recorder for MockStandingsFormats.
+type MockStandingsFormatsMockRecorder struct {
+	mock *MockStandingsFormats
+}
+
+// NewMockStandingsFormats creates a new mock instance.
+func NewMockStandingsFormats(ctrl *gomock.Controller) *MockStandingsFormats {
+	mock := &MockStandingsFormats{ctrl: ctrl}
+	mock.recorder = &MockStandingsFormatsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStandingsFormats) EXPECT() *MockStandingsFormatsMockRecorder {
+	return m.recorder
+}
+
+// ContentType mocks base method.
+func (m *MockStandingsFormats) ContentType(format string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContentType", format)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ContentType indicates an expected call of ContentType.
+func (mr *MockStandingsFormatsMockRecorder) ContentType(format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContentType", reflect.TypeOf((*MockStandingsFormats)(nil).ContentType), format)
+}
+
+// NewWriter mocks base method.
+func (m *MockStandingsFormats) NewWriter(format string, w io.Writer) (ports.StandingsWriter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewWriter", format, w)
+	ret0, _ := ret[0].(ports.StandingsWriter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewWriter indicates an expected call of NewWriter.
+func (mr *MockStandingsFormatsMockRecorder) NewWriter(format, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewWriter", reflect.TypeOf((*MockStandingsFormats)(nil).NewWriter), format, w)
+}
+
+// MockPrizesService is a mock of PrizesService interface.
+type MockPrizesService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrizesServiceMockRecorder
+}
+
+// MockPrizesServiceMockRecorder is the mock recorder for MockPrizesService.
+type MockPrizesServiceMockRecorder struct {
+	mock *MockPrizesService
+}
+
+// NewMockPrizesService creates a new mock instance.
+func NewMockPrizesService(ctrl *gomock.Controller) *MockPrizesService {
+	mock := &MockPrizesService{ctrl: ctrl}
+	mock.recorder = &MockPrizesServiceMockRecorder{mock}
+	return mock
 }
 
-// GetResults mocks base method.
-func (m *MockLeaderboardsService) GetResults(name string, epoch int64) ([]domain.LeaderboardScores, error) {
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrizesService) EXPECT() *MockPrizesServiceMockRecorder {
+	return m.recorder
+}
+
+// ClaimPrize mocks base method.
+func (m *MockPrizesService) ClaimPrize(entryID, name string, epoch int64, claimID string) (domain.EntryPrize, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetResults", name, epoch)
-	ret0, _ := ret[0].([]domain.LeaderboardScores)
+	ret := m.ctrl.Call(m, "ClaimPrize", entryID, name, epoch, claimID)
+	ret0, _ := ret[0].(domain.EntryPrize)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetResults indicates an expected call of GetResults.
-func (mr *MockLeaderboardsServiceMockRecorder) GetResults(name, epoch any) *gomock.Call {
+// ClaimPrize indicates an expected call of ClaimPrize.
+func (mr *MockPrizesServiceMockRecorder) ClaimPrize(entryID, name, epoch, claimID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResults", reflect.TypeOf((*MockLeaderboardsService)(nil).GetResults), name, epoch)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPrize", reflect.TypeOf((*MockPrizesService)(nil).ClaimPrize), entryID, name, epoch, claimID)
 }
 
-// GetResultsWithMetadata mocks base method.
-func (m *MockLeaderboardsService) GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, error) {
+// CloseEpochs mocks base method.
+func (m *MockPrizesService) CloseEpochs(now time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetResultsWithMetadata", name, epoch, meta)
-	ret0, _ := ret[0].([]domain.LeaderboardScores)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "CloseEpochs", now)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetResultsWithMetadata indicates an expected call of GetResultsWithMetadata.
-func (mr *MockLeaderboardsServiceMockRecorder) GetResultsWithMetadata(name, epoch, meta any) *gomock.Call {
+// CloseEpochs indicates an expected call of CloseEpochs.
+func (mr *MockPrizesServiceMockRecorder) CloseEpochs(now any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResultsWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).GetResultsWithMetadata), name, epoch, meta)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseEpochs", reflect.TypeOf((*MockPrizesService)(nil).CloseEpochs), now)
 }
 
-// ListScores mocks base method.
-func (m *MockLeaderboardsService) ListScores(name string) ([]domain.LeaderboardScores, int64, error) {
+// DeliverPending mocks base method.
+func (m *MockPrizesService) DeliverPending(now time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListScores", name)
-	ret0, _ := ret[0].([]domain.LeaderboardScores)
-	ret1, _ := ret[1].(int64)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "DeliverPending", now)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListScores indicates an expected call of ListScores.
-func (mr *MockLeaderboardsServiceMockRecorder) ListScores(name any) *gomock.Call {
+// DeliverPending indicates an expected call of DeliverPending.
+func (mr *MockPrizesServiceMockRecorder) DeliverPending(now any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScores", reflect.TypeOf((*MockLeaderboardsService)(nil).ListScores), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverPending", reflect.TypeOf((*MockPrizesService)(nil).DeliverPending), now)
 }
 
-// ListScoresWithMetadata mocks base method.
-func (m *MockLeaderboardsService) ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, int64, error) {
+// GetDeadLetters mocks base method.
+func (m *MockPrizesService) GetDeadLetters() ([]domain.PrizeDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListScoresWithMetadata", name, meta)
-	ret0, _ := ret[0].([]domain.LeaderboardScores)
-	ret1, _ := ret[1].(int64)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "GetDeadLetters")
+	ret0, _ := ret[0].([]domain.PrizeDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ListScoresWithMetadata indicates an expected call of ListScoresWithMetadata.
-func (mr *MockLeaderboardsServiceMockRecorder) ListScoresWithMetadata(name, meta any) *gomock.Call {
+// GetDeadLetters indicates an expected call of GetDeadLetters.
+func (mr *MockPrizesServiceMockRecorder) GetDeadLetters() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScoresWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ListScoresWithMetadata), name, meta)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetters", reflect.TypeOf((*MockPrizesService)(nil).GetDeadLetters))
 }
 
-// ReportScore mocks base method.
-func (m *MockLeaderboardsService) ReportScore(entryID, name string, value float64) (domain.ReportScoreOutput, error) {
+// GetUnclaimedPrizes mocks base method.
+func (m *MockPrizesService) GetUnclaimedPrizes(entryID string) ([]domain.EntryPrize, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReportScore", entryID, name, value)
-	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret := m.ctrl.Call(m, "GetUnclaimedPrizes", entryID)
+	ret0, _ := ret[0].([]domain.EntryPrize)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ReportScore indicates an expected call of ReportScore.
-func (mr *MockLeaderboardsServiceMockRecorder) ReportScore(entryID, name, value any) *gomock.Call {
+// GetUnclaimedPrizes indicates an expected call of GetUnclaimedPrizes.
+func (mr *MockPrizesServiceMockRecorder) GetUnclaimedPrizes(entryID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScore", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportScore), entryID, name, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnclaimedPrizes", reflect.TypeOf((*MockPrizesService)(nil).GetUnclaimedPrizes), entryID)
 }
 
-// ReportScoreWithMetadata mocks base method.
-func (m *MockLeaderboardsService) ReportScoreWithMetadata(entryID, name string, value float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+// Redeliver mocks base method.
+func (m *MockPrizesService) Redeliver(name string, epoch int64) (domain.PrizeDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReportScoreWithMetadata", entryID, name, value, meta)
-	ret0, _ := ret[0].(domain.ReportScoreOutput)
+	ret := m.ctrl.Call(m, "Redeliver", name, epoch)
+	ret0, _ := ret[0].(domain.PrizeDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ReportScoreWithMetadata indicates an expected call of ReportScoreWithMetadata.
-func (mr *MockLeaderboardsServiceMockRecorder) ReportScoreWithMetadata(entryID, name, value, meta any) *gomock.Call {
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockPrizesServiceMockRecorder) Redeliver(name, epoch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScoreWithMetadata", reflect.TypeOf((*MockLeaderboardsService)(nil).ReportScoreWithMetadata), entryID, name, value, meta)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockPrizesService)(nil).Redeliver), name, epoch)
 }
 
 // MockScoreboard is a mock of Scoreboard interface.
@@ -393,6 +1517,20 @@ func (m *MockScoreboard) EXPECT() *MockScoreboardMockRecorder {
 	return m.recorder
 }
 
+// AddExactScore mocks base method.
+func (m *MockScoreboard) AddExactScore(entryID, name string, value domain.ExactScore) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExactScore", entryID, name, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddExactScore indicates an expected call of AddExactScore.
+func (mr *MockScoreboardMockRecorder) AddExactScore(entryID, name, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExactScore", reflect.TypeOf((*MockScoreboard)(nil).AddExactScore), entryID, name, value)
+}
+
 // AddScore mocks base method.
 func (m *MockScoreboard) AddScore(entryID, name string, value float64) error {
 	m.ctrl.T.Helper()
@@ -408,48 +1546,182 @@ func (mr *MockScoreboardMockRecorder) AddScore(entryID, name, value any) *gomock
 }
 
 // Get mocks base method.
-func (m *MockScoreboard) Get(name string) ([]domain.ScoreboardResult, error) {
+func (m *MockScoreboard) Get(name string, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", name)
+	ret := m.ctrl.Call(m, "Get", name, order)
 	ret0, _ := ret[0].([]domain.ScoreboardResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockScoreboardMockRecorder) Get(name any) *gomock.Call {
+func (mr *MockScoreboardMockRecorder) Get(name, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockScoreboard)(nil).Get), name, order)
+}
+
+// GetNeighbours mocks base method.
+func (m *MockScoreboard) GetNeighbours(name, entryID string, above, below int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNeighbours", name, entryID, above, below, order)
+	ret0, _ := ret[0].([]domain.ScoreboardResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNeighbours indicates an expected call of GetNeighbours.
+func (mr *MockScoreboardMockRecorder) GetNeighbours(name, entryID, above, below, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNeighbours", reflect.TypeOf((*MockScoreboard)(nil).GetNeighbours), name, entryID, above, below, order)
+}
+
+// GetRange mocks base method.
+func (m *MockScoreboard) GetRange(name string, start, stop int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRange", name, start, stop, order)
+	ret0, _ := ret[0].([]domain.ScoreboardResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRange indicates an expected call of GetRange.
+func (mr *MockScoreboardMockRecorder) GetRange(name, start, stop, order any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockScoreboard)(nil).Get), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRange", reflect.TypeOf((*MockScoreboard)(nil).GetRange), name, start, stop, order)
 }
 
 // GetRank mocks base method.
-func (m *MockScoreboard) GetRank(entryID, name string) (uint64, error) {
+func (m *MockScoreboard) GetRank(entryID, name string, order domain.SortOrder) (uint64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRank", entryID, name)
+	ret := m.ctrl.Call(m, "GetRank", entryID, name, order)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRank indicates an expected call of GetRank.
-func (mr *MockScoreboardMockRecorder) GetRank(entryID, name any) *gomock.Call {
+func (mr *MockScoreboardMockRecorder) GetRank(entryID, name, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRank", reflect.TypeOf((*MockScoreboard)(nil).GetRank), entryID, name, order)
+}
+
+// GetScores mocks base method.
+func (m *MockScoreboard) GetScores(name string, entryIDs []string) ([]domain.ScoreboardResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScores", name, entryIDs)
+	ret0, _ := ret[0].([]domain.ScoreboardResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScores indicates an expected call of GetScores.
+func (mr *MockScoreboardMockRecorder) GetScores(name, entryIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScores", reflect.TypeOf((*MockScoreboard)(nil).GetScores), name, entryIDs)
+}
+
+// GetStanding mocks base method.
+func (m *MockScoreboard) GetStanding(name, entryID string, nextBand bool, order domain.SortOrder) (domain.ScoreboardStanding, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStanding", name, entryID, nextBand, order)
+	ret0, _ := ret[0].(domain.ScoreboardStanding)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStanding indicates an expected call of GetStanding.
+func (mr *MockScoreboardMockRecorder) GetStanding(name, entryID, nextBand, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStanding", reflect.TypeOf((*MockScoreboard)(nil).GetStanding), name, entryID, nextBand, order)
+}
+
+// GetStats mocks base method.
+func (m *MockScoreboard) GetStats(name string, buckets []float64) (domain.ScoreboardStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", name, buckets)
+	ret0, _ := ret[0].(domain.ScoreboardStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockScoreboardMockRecorder) GetStats(name, buckets any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRank", reflect.TypeOf((*MockScoreboard)(nil).GetRank), entryID, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockScoreboard)(nil).GetStats), name, buckets)
 }
 
 // GetTopN mocks base method.
-func (m *MockScoreboard) GetTopN(name string, n int64) ([]domain.ScoreboardResult, error) {
+func (m *MockScoreboard) GetTopN(name string, n int64, order domain.SortOrder) ([]domain.ScoreboardResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTopN", name, n)
+	ret := m.ctrl.Call(m, "GetTopN", name, n, order)
 	ret0, _ := ret[0].([]domain.ScoreboardResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTopN indicates an expected call of GetTopN.
-func (mr *MockScoreboardMockRecorder) GetTopN(name, n any) *gomock.Call {
+func (mr *MockScoreboardMockRecorder) GetTopN(name, n, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopN", reflect.TypeOf((*MockScoreboard)(nil).GetTopN), name, n, order)
+}
+
+// Keys mocks base method.
+func (m *MockScoreboard) Keys(pattern string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", pattern)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockScoreboardMockRecorder) Keys(pattern any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockScoreboard)(nil).Keys), pattern)
+}
+
+// RemoveScore mocks base method.
+func (m *MockScoreboard) RemoveScore(entryID, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveScore", entryID, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveScore indicates an expected call of RemoveScore.
+func (mr *MockScoreboardMockRecorder) RemoveScore(entryID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveScore", reflect.TypeOf((*MockScoreboard)(nil).RemoveScore), entryID, name)
+}
+
+// Rename mocks base method.
+func (m *MockScoreboard) Rename(from, to string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", from, to)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockScoreboardMockRecorder) Rename(from, to any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopN", reflect.TypeOf((*MockScoreboard)(nil).GetTopN), name, n)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockScoreboard)(nil).Rename), from, to)
+}
+
+// TopN mocks base method.
+func (m *MockScoreboard) TopN() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopN")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// TopN indicates an expected call of TopN.
+func (mr *MockScoreboardMockRecorder) TopN() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopN", reflect.TypeOf((*MockScoreboard)(nil).TopN))
 }
 
 // MockProvider is a mock of Provider interface.
@@ -540,6 +1812,20 @@ func (mr *MockConfigProviderMockRecorder) Refresh() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockConfigProvider)(nil).Refresh))
 }
 
+// Status mocks base method.
+func (m *MockConfigProvider) Status() domain.ConfigStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(domain.ConfigStatus)
+	return ret0
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockConfigProviderMockRecorder) Status() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockConfigProvider)(nil).Status))
+}
+
 // MockConfigGetter is a mock of ConfigGetter interface.
 type MockConfigGetter struct {
 	ctrl     *gomock.Controller
@@ -578,6 +1864,194 @@ func (mr *MockConfigGetterMockRecorder) GetConfig() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockConfigGetter)(nil).GetConfig))
 }
 
+// MockConfigStore is a mock of ConfigStore interface.
+type MockConfigStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockConfigStoreMockRecorder
+}
+
+// MockConfigStoreMockRecorder is the mock recorder for MockConfigStore.
+type MockConfigStoreMockRecorder struct {
+	mock *MockConfigStore
+}
+
+// NewMockConfigStore creates a new mock instance.
+func NewMockConfigStore(ctrl *gomock.Controller) *MockConfigStore {
+	mock := &MockConfigStore{ctrl: ctrl}
+	mock.recorder = &MockConfigStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConfigStore) EXPECT() *MockConfigStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateConfig mocks base method.
+func (m *MockConfigStore) CreateConfig(config domain.LeaderboardConfig, audit domain.LifecycleAudit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateConfig", config, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateConfig indicates an expected call of CreateConfig.
+func (mr *MockConfigStoreMockRecorder) CreateConfig(config, audit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConfig", reflect.TypeOf((*MockConfigStore)(nil).CreateConfig), config, audit)
+}
+
+// GetConfig mocks base method.
+func (m *MockConfigStore) GetConfig() (domain.LeaderboardsConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfig")
+	ret0, _ := ret[0].(domain.LeaderboardsConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfig indicates an expected call of GetConfig.
+func (mr *MockConfigStoreMockRecorder) GetConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockConfigStore)(nil).GetConfig))
+}
+
+// ReplaceConfig mocks base method.
+func (m *MockConfigStore) ReplaceConfig(config domain.LeaderboardConfig, read domain.LeaderboardLifecycle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceConfig", config, read)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceConfig indicates an expected call of ReplaceConfig.
+func (mr *MockConfigStoreMockRecorder) ReplaceConfig(config, read any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceConfig", reflect.TypeOf((*MockConfigStore)(nil).ReplaceConfig), config, read)
+}
+
+// MockArchiveStore is a mock of ArchiveStore interface.
+type MockArchiveStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockArchiveStoreMockRecorder
+}
+
+// MockArchiveStoreMockRecorder is the mock recorder for MockArchiveStore.
+type MockArchiveStoreMockRecorder struct {
+	mock *MockArchiveStore
+}
+
+// NewMockArchiveStore creates a new mock instance.
+func NewMockArchiveStore(ctrl *gomock.Controller) *MockArchiveStore {
+	mock := &MockArchiveStore{ctrl: ctrl}
+	mock.recorder = &MockArchiveStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArchiveStore) EXPECT() *MockArchiveStoreMockRecorder {
+	return m.recorder
+}
+
+// GetArchive mocks base method.
+func (m *MockArchiveStore) GetArchive(name string, epoch int64, scoreboards []string, n int64) (domain.EpochArchive, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchive", name, epoch, scoreboards, n)
+	ret0, _ := ret[0].(domain.EpochArchive)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetArchive indicates an expected call of GetArchive.
+func (mr *MockArchiveStoreMockRecorder) GetArchive(name, epoch, scoreboards, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchive", reflect.TypeOf((*MockArchiveStore)(nil).GetArchive), name, epoch, scoreboards, n)
+}
+
+// GetArchivedEpoch mocks base method.
+func (m *MockArchiveStore) GetArchivedEpoch(name string) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivedEpoch", name)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetArchivedEpoch indicates an expected call of GetArchivedEpoch.
+func (mr *MockArchiveStoreMockRecorder) GetArchivedEpoch(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivedEpoch", reflect.TypeOf((*MockArchiveStore)(nil).GetArchivedEpoch), name)
+}
+
+// HasArchive mocks base method.
+func (m *MockArchiveStore) HasArchive(name string, epoch int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasArchive", name, epoch)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasArchive indicates an expected call of HasArchive.
+func (mr *MockArchiveStoreMockRecorder) HasArchive(name, epoch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasArchive", reflect.TypeOf((*MockArchiveStore)(nil).HasArchive), name, epoch)
+}
+
+// SaveArchive mocks base method.
+func (m *MockArchiveStore) SaveArchive(archive domain.EpochArchive) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveArchive", archive)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveArchive indicates an expected call of SaveArchive.
+func (mr *MockArchiveStoreMockRecorder) SaveArchive(archive any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveArchive", reflect.TypeOf((*MockArchiveStore)(nil).SaveArchive), archive)
+}
+
+// MockRateLimiter is a mock of RateLimiter interface.
+type MockRateLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimiterMockRecorder
+}
+
+// MockRateLimiterMockRecorder is the mock recorder for MockRateLimiter.
+type MockRateLimiterMockRecorder struct {
+	mock *MockRateLimiter
+}
+
+// NewMockRateLimiter creates a new mock instance.
+func NewMockRateLimiter(ctrl *gomock.Controller) *MockRateLimiter {
+	mock := &MockRateLimiter{ctrl: ctrl}
+	mock.recorder = &MockRateLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimiter) EXPECT() *MockRateLimiterMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockRateLimiter) Allow(key string, interval time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", key, interval)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockRateLimiterMockRecorder) Allow(key, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockRateLimiter)(nil).Allow), key, interval)
+}
+
 // MockResetLocker is a mock of ResetLocker interface.
 type MockResetLocker struct {
 	ctrl     *gomock.Controller
@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"io"
 	"time"
 
 	"github.com/posilva/simpleboards/internal/core/domain"
@@ -16,6 +17,56 @@ type Repository interface {
 	MaxWithMetadata(entry string, leaderboard string, value float64, meta domain.Metadata) (domain.ScoreUpdate, error)
 	MinWithMetadata(entry string, leaderboard string, value float64, meta domain.Metadata) (domain.ScoreUpdate, error)
 	LastWithMetadata(entry string, leaderboard string, value float64, meta domain.Metadata) (domain.ScoreUpdate, error)
+	DecayWithMetadata(entry string, leaderboard string, value domain.DecayedScore, meta domain.Metadata) (domain.ScoreUpdate, error)
+	CompositeWithMetadata(entry string, leaderboard string, value domain.CompositeScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error)
+	ExactWithMetadata(entry string, leaderboard string, value domain.ExactScore, function domain.LeaderboardFunctionType, meta domain.Metadata) (domain.ScoreUpdate, error)
+	GetExactScores(leaderboard string, entries []string) (map[string]string, error)
+	GetEntries(leaderboard string, entries []string) (map[string]domain.StoredEntry, error)
+	PutEntries(leaderboard string, entries map[string]domain.StoredEntry) error
+	ReplaceEntries(leaderboard string, entries map[string]domain.StoredEntry, counters map[string]uint64) ([]string, error)
+	DeleteEntry(entry string, leaderboard string) (bool, error)
+	GetFriends(entry string) ([]string, error)
+	SetFriends(entry string, friends []string) error
+	IncrementSubmissions(leaderboard string) error
+	GetSubmissions(leaderboard string) (uint64, error)
+	MigrateEntries(from string, to string, entries []string) (int, int, error)
+	GetEpoch(leaderboard string) (int64, error)
+	AdvanceEpoch(leaderboard string) (int64, error)
+	TransitionLifecycle(name string, lifecycle domain.LeaderboardLifecycle, audit domain.LifecycleAudit) error
+	GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error)
+	CreatePrizeDelivery(delivery domain.PrizeDelivery) (bool, error)
+	UpdatePrizeDelivery(delivery domain.PrizeDelivery, attempts int) (bool, error)
+	GetPrizeDelivery(leaderboard string, epoch int64) (domain.PrizeDelivery, bool, error)
+	GetPrizeDeliveries(state domain.DeliveryState) ([]domain.PrizeDelivery, error)
+	GetClosedEpoch(leaderboard string) (int64, bool, error)
+	SetClosedEpoch(leaderboard string, epoch int64) error
+	CreateEntryPrizes(batch domain.AwardBatch) error
+	GetUnclaimedPrizes(entry string) ([]domain.EntryPrize, error)
+	GetEntryPrize(entry string, leaderboard string, epoch int64) (domain.EntryPrize, bool, error)
+	ClaimPrize(entry string, leaderboard string, epoch int64, claimID string, at time.Time) (bool, error)
+	// WithOutbox returns a repository whose score writes store the events in the outbox in the
+	// same write as the score, so they are durable once the score is
+	WithOutbox(events ...domain.Event) Repository
+}
+
+// PrizeNotifier defines the interface to deliver the prizes of closed epochs
+type PrizeNotifier interface {
+	Notify(webhook domain.WebhookConfig, batch domain.AwardBatch) error
+}
+
+// EventPublisher defines the interface to publish the score and epoch events
+type EventPublisher interface {
+	Publish(events ...domain.Event) error
+}
+
+// EventOutbox defines the interface to keep the events until the event bus acknowledges them
+type EventOutbox interface {
+	// SaveEvents stores the events, replacing the stored events with the same ID
+	SaveEvents(events ...domain.Event) error
+	// GetPendingEvents returns up to limit events stored before a time in ID order
+	GetPendingEvents(before time.Time, limit int) ([]domain.Event, error)
+	// DeleteEvents removes the events acknowledged by the event bus
+	DeleteEvents(ids ...string) error
 }
 
 // Logger defines a basic logger interface
@@ -28,21 +79,84 @@ type Logger interface {
 // LeaderboardsService defines the leaderboard service interface
 type LeaderboardsService interface {
 	GetConfig(name string) (domain.LeaderboardConfig, error)
+	GetConfigStatus() domain.ConfigStatus
 	ReportScore(entryID string, name string, value float64) (domain.ReportScoreOutput, error)
 	ReportScoreWithMetadata(entryID string, name string, value float64, meta domain.Metadata) (domain.ReportScoreOutput, error)
-	ListScores(name string) ([]domain.LeaderboardScores, int64, error)
-	ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, int64, error)
+	ReportDecimalScoreWithMetadata(entryID string, name string, value string, meta domain.Metadata) (domain.ReportScoreOutput, error)
+	ReportComponentsWithMetadata(entryID string, name string, components []float64, meta domain.Metadata) (domain.ReportScoreOutput, error)
+	ListScores(name string) ([]domain.LeaderboardScores, domain.EpochInfo, error)
+	ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error)
 	// TODO: we may have a dedicated data type to return in this call
-	GetResults(name string, epoch int64) ([]domain.LeaderboardScores, error)
-	GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, error)
+	GetResults(name string, epoch int64) ([]domain.LeaderboardScores, domain.EpochInfo, error)
+	GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error)
+	GetFriendsScores(entryID string, name string, epoch int64, friends []string) (domain.LeaderboardScores, domain.EpochInfo, error)
+	SetFriends(entryID string, friends []string) error
+	GetStandingsWithMetadata(entryID string, name string, meta domain.Metadata, nextBand bool) ([]domain.LeaderboardStanding, domain.EpochInfo, error)
+	GetEpochInfo(name string, epoch int64) (domain.EpochInfo, error)
+	GetStats(name string, epoch int64, buckets []float64) (domain.LeaderboardStats, error)
+	MigrateLegacyEpochs(name string, legacyEpochs []int64) ([]domain.EpochMigration, error)
+	AdvanceEpoch(name string) (domain.EpochInfo, error)
+	TransitionLifecycle(name string, to domain.LeaderboardState, activateAt *time.Time, actor string, reason string) (domain.LeaderboardLifecycle, error)
+	GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error)
+	ExportStandings(name string, epoch int64, scoreboards domain.Metadata, out StandingsWriter) (domain.EpochInfo, error)
+	ImportScores(name string, in RecordReader, options domain.ImportOptions, checkpoint ImportCheckpoint) (domain.ImportReport, error)
+	SetScore(entryID string, name string, epoch int64, score string, meta domain.Metadata) (domain.ScoreUpdate, domain.EpochInfo, error)
+	RemoveScore(entryID string, name string, epoch int64) ([]string, domain.EpochInfo, error)
+}
+
+// RecordReader defines the interface to read the records of an import, Read returns io.EOF
+// after the last record
+type RecordReader interface {
+	Read() (domain.ImportRecord, error)
+}
+
+// ImportCheckpoint defines the interface to keep the progress of an import so it can be resumed
+type ImportCheckpoint interface {
+	Load() (domain.ImportReport, bool, error)
+	Save(report domain.ImportReport) error
+}
+
+// StandingsWriter defines the interface to write the rows of a standings export
+type StandingsWriter interface {
+	Write(row domain.StandingsRow) error
+	Flush() error
+}
+
+// StandingsFormats defines the interface to create the standings writers of the export formats
+type StandingsFormats interface {
+	NewWriter(format string, w io.Writer) (StandingsWriter, error)
+	ContentType(format string) string
+}
+
+// PrizesService defines the service that awards and delivers the prizes of closed epochs
+type PrizesService interface {
+	CloseEpochs(now time.Time) error
+	DeliverPending(now time.Time) error
+	GetDeadLetters() ([]domain.PrizeDelivery, error)
+	Redeliver(name string, epoch int64) (domain.PrizeDelivery, error)
+	GetUnclaimedPrizes(entryID string) ([]domain.EntryPrize, error)
+	ClaimPrize(entryID string, name string, epoch int64, claimID string) (domain.EntryPrize, error)
 }
 
 // Scoreboard ...
 type Scoreboard interface {
-	Get(name string) ([]domain.ScoreboardResult, error)
-	GetTopN(name string, n int64) ([]domain.ScoreboardResult, error)
+	Get(name string, order domain.SortOrder) ([]domain.ScoreboardResult, error)
+	// TopN returns the number of entries Get returns
+	TopN() int64
+	GetTopN(name string, n int64, order domain.SortOrder) ([]domain.ScoreboardResult, error)
 	AddScore(entryID string, name string, value float64) error
-	GetRank(entryID string, name string) (uint64, error)
+	// AddExactScore sets the exact score of an entry, which is ranked by its value among the
+	// entries that share its float64 score
+	AddExactScore(entryID string, name string, value domain.ExactScore) error
+	RemoveScore(entryID string, name string) (bool, error)
+	GetRank(entryID string, name string, order domain.SortOrder) (uint64, error)
+	GetNeighbours(name string, entryID string, above int64, below int64, order domain.SortOrder) ([]domain.ScoreboardResult, error)
+	GetRange(name string, start int64, stop int64, order domain.SortOrder) ([]domain.ScoreboardResult, error)
+	GetScores(name string, entryIDs []string) ([]domain.ScoreboardResult, error)
+	GetStanding(name string, entryID string, nextBand bool, order domain.SortOrder) (domain.ScoreboardStanding, error)
+	GetStats(name string, buckets []float64) (domain.ScoreboardStats, error)
+	Keys(pattern string) ([]string, error)
+	Rename(from string, to string) (bool, error)
 }
 
 // Provider generic interface
@@ -54,6 +168,8 @@ type Provider[T any] interface {
 type ConfigProvider interface {
 	Provider[domain.LeaderboardsConfigMap]
 	Refresh()
+	// Status returns the version of the loaded configurations and the ones which failed to load
+	Status() domain.ConfigStatus
 }
 
 // ConfigGetter defines the interface to retrieve configs
@@ -61,6 +177,36 @@ type ConfigGetter interface {
 	GetConfig() (domain.LeaderboardsConfigMap, error)
 }
 
+// ConfigStore defines the interface to retrieve and store configs
+type ConfigStore interface {
+	ConfigGetter
+	// CreateConfig stores a new configuration along with the audit of its initial lifecycle, it
+	// fails when the configuration is already stored
+	CreateConfig(config domain.LeaderboardConfig, audit domain.LifecycleAudit) error
+	// ReplaceConfig stores a configuration, it fails when the stored lifecycle is not the one
+	// read before so concurrent transitions are not overwritten
+	ReplaceConfig(config domain.LeaderboardConfig, read domain.LeaderboardLifecycle) error
+}
+
+// ArchiveStore defines the interface to store the final standings of closed epochs
+type ArchiveStore interface {
+	SaveArchive(archive domain.EpochArchive) error
+	// GetArchive returns the top n standings of the named scoreboards of an archived epoch in the
+	// order they are named, scoreboards that were not archived are omitted. It returns false when
+	// the epoch was not archived
+	GetArchive(name string, epoch int64, scoreboards []string, n int64) (domain.EpochArchive, bool, error)
+	// HasArchive returns true when the epoch was archived without reading its standings
+	HasArchive(name string, epoch int64) (bool, error)
+	// GetArchivedEpoch returns the last archived epoch of a leaderboard, it returns false when no
+	// epoch was archived
+	GetArchivedEpoch(name string) (int64, bool, error)
+}
+
+// RateLimiter defines the interface to limit how often an action happens
+type RateLimiter interface {
+	Allow(key string, interval time.Duration) (bool, error)
+}
+
 // ResetLocker defines the interface to lock during the Reset
 type ResetLocker interface {
 	ResetLock(leaderboar string, epoch int, duration time.Duration) (bool, error)
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+const (
+	// archivePageSize is the number of entries read from the scoreboards on every request
+	archivePageSize = 1000
+	// archiveCatchUpEpochs is the number of closed epochs archived when the archives of past
+	// epochs were missed, older epochs are skipped
+	archiveCatchUpEpochs = 24
+)
+
+// ArchiverService snapshots the final standings of closed epochs, which are still mutable and
+// eventually expire in the scoreboards
+type ArchiverService struct {
+	repository    ports.Repository
+	scoreboard    ports.Scoreboard
+	configuration ports.Provider[domain.LeaderboardsConfigMap]
+	store         ports.ArchiveStore
+	log           ports.Logger
+}
+
+// NewArchiverService creates a new archiver service
+func NewArchiverService(
+	repo ports.Repository,
+	scoreboard ports.Scoreboard,
+	configProvider ports.ConfigProvider,
+	store ports.ArchiveStore,
+	log ports.Logger,
+) *ArchiverService {
+	return &ArchiverService{
+		repository:    repo,
+		scoreboard:    scoreboard,
+		configuration: configProvider,
+		store:         store,
+		log:           log,
+	}
+}
+
+// Run archives the closed epochs on every interval until the context is done
+func (s *ArchiverService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.ArchiveClosedEpochs(time.Now())
+			if err != nil {
+				_ = s.log.Error("failed to archive epochs: %v", err)
+			}
+		}
+	}
+}
+
+// ArchiveClosedEpochs archives the closed epochs of every leaderboard since its last archived
+// epoch which are not archived yet
+func (s *ArchiverService) ArchiveClosedEpochs(now time.Time) error {
+	configMap, err := s.configuration.Provide()
+	if err != nil {
+		return fmt.Errorf("failed to provide configuration: %v", err)
+	}
+	var errs []error
+	for name, config := range configMap {
+		err = s.archiveEpochs(name, config, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// archiveEpochs archives the epochs closed since the last archived epoch, so the epochs closed
+// while the archiver was down are not missed
+func (s *ArchiverService) archiveEpochs(name string, config domain.LeaderboardConfig, now time.Time) error {
+	last, closedAt, ok, err := closedEpoch(s.repository, name, config, now)
+	if err != nil || !ok {
+		return err
+	}
+	first := last
+	archived, found, err := s.store.GetArchivedEpoch(name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch archived epoch: %v", err)
+	}
+	if found && archived >= last {
+		return nil
+	}
+	if found && config.ResetExpression.Type != domain.Window {
+		first = max(archived+1, last-archiveCatchUpEpochs+1)
+		if first > archived+1 && s.log != nil {
+			_ = s.log.Error("skipping the archives of epochs %d to %d of %v", archived+1, first-1, name)
+		}
+	}
+	for epoch := first; epoch <= last; epoch++ {
+		at := closedAt
+		if epoch != last && !config.CronExpression.IsManual() {
+			at = config.CronExpression.GetEpochEnd(epoch)
+		}
+		err = s.archiveEpoch(name, config, epoch, at, now)
+		if err != nil {
+			return fmt.Errorf("epoch %d: %v", epoch, err)
+		}
+	}
+	return nil
+}
+
+// archiveEpoch archives a closed epoch unless it has an archive
+func (s *ArchiverService) archiveEpoch(name string, config domain.LeaderboardConfig, epoch int64, closedAt time.Time, now time.Time) error {
+	found, err := s.store.HasArchive(name, epoch)
+	if err != nil {
+		return fmt.Errorf("failed to check archive: %v", err)
+	}
+	if found {
+		return nil
+	}
+	archive, err := s.snapshot(name, config, epoch, closedAt)
+	if err != nil {
+		return err
+	}
+	archive.ArchivedAt = now.UTC()
+	err = s.store.SaveArchive(archive)
+	if err != nil {
+		return fmt.Errorf("failed to save archive: %v", err)
+	}
+	return nil
+}
+
+// snapshot returns the full standings of the global scoreboard and every scoreboard of an epoch
+func (s *ArchiverService) snapshot(name string, config domain.LeaderboardConfig, epoch int64, closedAt time.Time) (domain.EpochArchive, error) {
+	leaderboard := getNameWithEpoch(name, epoch)
+	boards := []string{leaderboard}
+	for _, sb := range config.Scoreboards {
+		keys, err := s.scoreboard.Keys(sbNameFromType(name, epoch, sb, "*"))
+		if err != nil {
+			return domain.EpochArchive{}, fmt.Errorf("failed to list scoreboards: %v", err)
+		}
+		boards = append(boards, keys...)
+	}
+
+	archive := domain.EpochArchive{Leaderboard: name, Epoch: epoch, Scoreboards: []domain.LeaderboardScores{}}
+	decay := decayAt(config, epoch, closedAt)
+	components := componentsOf(config)
+	for _, board := range boards {
+		standings := domain.LeaderboardScores{Name: board, Scores: []domain.LeaderboardEntry{}}
+		for start := int64(0); ; start += archivePageSize {
+			scores, err := s.scoreboard.GetRange(board, start, start+archivePageSize-1, config.Order())
+			if err != nil {
+				return domain.EpochArchive{}, fmt.Errorf("failed to fetch scores of %v: %v", board, err)
+			}
+			exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
+			if err != nil {
+				return domain.EpochArchive{}, err
+			}
+			for _, score := range scores {
+				standings.Scores = append(standings.Scores, domain.LeaderboardEntry{
+					EntryID:    score.EntryID,
+					Score:      decay(score.Score),
+					Rank:       score.Rank,
+					Components: components(score.Score),
+					ExactScore: exact[score.EntryID],
+				})
+			}
+			if len(scores) < archivePageSize {
+				break
+			}
+		}
+		archive.Scoreboards = append(archive.Scoreboards, standings)
+	}
+	return archive, nil
+}
+
+// archivedScoreboards returns the names of the scoreboards of an epoch read for the metadata like
+// GetResultsWithMetadata does, the global scoreboard first
+func archivedScoreboards(name string, epoch int64, config domain.LeaderboardConfig, meta domain.Metadata) []string {
+	names := []string{getNameWithEpoch(name, epoch)}
+	for _, sb := range config.Scoreboards {
+		names = append(names, sbNameFromType(name, epoch, sb, meta[sb.Field]))
+	}
+	return names
+}
+
+// archivedResults returns the results of the named scoreboards of an archive, the scoreboards
+// which were not archived have no scores
+func archivedResults(archive domain.EpochArchive, names []string) []domain.LeaderboardScores {
+	results := []domain.LeaderboardScores{}
+	for _, name := range names {
+		standings, ok := archive.Scoreboard(name)
+		if !ok {
+			standings = domain.LeaderboardScores{Name: name}
+		}
+		results = append(results, standings)
+	}
+	return results
+}
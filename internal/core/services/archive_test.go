@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestArchiveClosedEpochs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	store := mocks.NewMockArchiveStore(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	config.Scoreboards = config.Scoreboards[1:]
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	archiver := NewArchiverService(repo, scoreboard, configProvider, store, logging.NewSimpleLogger())
+
+	now := time.Now()
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(now.Add(-prizeGracePeriod).Unix()) - 1
+	leaderboard := getNameWithEpoch(lbName, epoch)
+	country := sbNameFromType(lbName, epoch, config.Scoreboards[0], "pt")
+
+	store.EXPECT().GetArchivedEpoch(lbName).Return(int64(0), false, nil)
+	store.EXPECT().HasArchive(lbName, epoch).Return(false, nil)
+	scoreboard.EXPECT().Keys(sbNameFromType(lbName, epoch, config.Scoreboards[0], "*")).Return([]string{country}, nil)
+	page := make([]domain.ScoreboardResult, archivePageSize)
+	for i := range page {
+		page[i] = domain.ScoreboardResult{EntryID: testutil.NewID(), Score: float64(archivePageSize - i), Rank: int64(i + 1)}
+	}
+	scoreboard.EXPECT().GetRange(leaderboard, int64(0), int64(archivePageSize-1), domain.Descending).Return(page, nil)
+	scoreboard.EXPECT().GetRange(leaderboard, int64(archivePageSize), int64(2*archivePageSize-1), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: "last", Score: 0, Rank: archivePageSize + 1}}, nil)
+	scoreboard.EXPECT().GetRange(country, int64(0), int64(archivePageSize-1), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: "p1", Score: 5, Rank: 1}}, nil)
+	store.EXPECT().SaveArchive(gomock.Any()).DoAndReturn(func(archive domain.EpochArchive) error {
+		assert.Equal(t, lbName, archive.Leaderboard)
+		assert.Equal(t, epoch, archive.Epoch)
+		assert.Len(t, archive.Scoreboards, 2)
+		assert.Equal(t, leaderboard, archive.Scoreboards[0].Name)
+		assert.Len(t, archive.Scoreboards[0].Scores, archivePageSize+1)
+		assert.Equal(t, "last", archive.Scoreboards[0].Scores[archivePageSize].EntryID)
+		assert.Equal(t, []domain.LeaderboardEntry{{EntryID: "p1", Score: 5, Rank: 1}}, archive.Scoreboards[1].Scores)
+		return nil
+	})
+	assert.NoError(t, archiver.ArchiveClosedEpochs(now))
+
+	// archived epochs are skipped
+	store.EXPECT().GetArchivedEpoch(lbName).Return(epoch, true, nil)
+	assert.NoError(t, archiver.ArchiveClosedEpochs(now))
+	store.EXPECT().GetArchivedEpoch(lbName).Return(epoch-1, true, nil)
+	store.EXPECT().HasArchive(lbName, epoch).Return(true, nil)
+	assert.NoError(t, archiver.ArchiveClosedEpochs(now))
+}
+
+func TestArchiveClosedEpochsCatchUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	store := mocks.NewMockArchiveStore(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	config.Scoreboards = nil
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	archiver := NewArchiverService(mocks.NewMockRepository(ctrl), scoreboard, configProvider, store, logging.NewSimpleLogger())
+
+	now := time.Now()
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(now.Add(-prizeGracePeriod).Unix()) - 1
+
+	// the epochs closed since the last archived epoch are archived in order
+	store.EXPECT().GetArchivedEpoch(lbName).Return(epoch-3, true, nil)
+	var archived []int64
+	for _, e := range []int64{epoch - 2, epoch - 1, epoch} {
+		store.EXPECT().HasArchive(lbName, e).Return(e == epoch-1, nil)
+		scoreboard.EXPECT().GetRange(getNameWithEpoch(lbName, e), int64(0), int64(archivePageSize-1), domain.Descending).Return(nil, nil).MaxTimes(1)
+	}
+	store.EXPECT().SaveArchive(gomock.Any()).DoAndReturn(func(archive domain.EpochArchive) error {
+		archived = append(archived, archive.Epoch)
+		return nil
+	}).Times(2)
+	assert.NoError(t, archiver.ArchiveClosedEpochs(now))
+	assert.Equal(t, []int64{epoch - 2, epoch}, archived)
+
+	// only the last epochs are caught up after a long downtime
+	store.EXPECT().GetArchivedEpoch(lbName).Return(epoch-100, true, nil)
+	for e := epoch - archiveCatchUpEpochs + 1; e <= epoch; e++ {
+		store.EXPECT().HasArchive(lbName, e).Return(true, nil)
+	}
+	assert.NoError(t, archiver.ArchiveClosedEpochs(now))
+}
+
+func TestGetResultsArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	store := mocks.NewMockArchiveStore(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	lbSrv := NewLeaderboardsServiceWithOptions(mocks.NewMockRepository(ctrl), scoreboard, configProvider, LeaderboardsOptions{Archive: store})
+
+	country := sbNameFromType(lbName, 3, config.Scoreboards[1], "pt")
+	archive := domain.EpochArchive{
+		Leaderboard: lbName,
+		Epoch:       3,
+		Scoreboards: []domain.LeaderboardScores{
+			{Name: getNameWithEpoch(lbName, 3), Scores: []domain.LeaderboardEntry{{EntryID: "p1", Score: 10, Rank: 1}}},
+			{Name: country, Scores: []domain.LeaderboardEntry{{EntryID: "p1", Score: 10, Rank: 1}}},
+		},
+	}
+	league := sbNameFromType(lbName, 3, config.Scoreboards[0], "gold")
+	store.EXPECT().GetArchive(lbName, int64(3), []string{getNameWithEpoch(lbName, 3), league, country}, int64(1)).Return(archive, true, nil)
+	scoreboard.EXPECT().TopN().Return(int64(1))
+
+	// the scoreboards are not read once the epoch is archived and only the top entries of the
+	// requested scoreboards are read from the archive
+	v, info, err := lbSrv.GetResultsWithMetadata(lbName, 3, domain.Metadata{"country": "PT", "league": "gold"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Epoch)
+	assert.Len(t, v, 3)
+	assert.Equal(t, archive.Scoreboards[0].Name, v[0].Name)
+	assert.Equal(t, archive.Scoreboards[0], v[0])
+	assert.Equal(t, league, v[1].Name)
+	assert.Empty(t, v[1].Scores)
+	assert.Equal(t, archive.Scoreboards[1], v[2])
+}
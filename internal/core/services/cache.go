@@ -0,0 +1,50 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlCacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// ttlCache is an in memory cache where entries expire after a fixed duration
+type ttlCache[T any] struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[string]ttlCacheEntry[T]
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{
+		ttl:     ttl,
+		entries: make(map[string]ttlCacheEntry[T]),
+	}
+}
+
+// Get returns the value of a key if it exists and is not expired
+func (c *ttlCache[T]) Get(key string) (T, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores a value and removes the expired entries
+func (c *ttlCache[T]) Set(key string, value T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ttlCacheEntry[T]{value: value, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+// configActor is the actor of the lifecycle audit of the leaderboards created by an apply
+const configActor = "config apply"
+
+// ConfigsService manages the stored leaderboard configurations from configurations kept as
+// code. Stored leaderboards which are not applied are never deleted and the lifecycle of a
+// stored leaderboard is only changed by its transitions
+type ConfigsService struct {
+	store ports.ConfigStore
+}
+
+// NewConfigsService creates a new configs service
+func NewConfigsService(store ports.ConfigStore) *ConfigsService {
+	return &ConfigsService{store: store}
+}
+
+// List returns the stored configurations sorted by name
+func (s *ConfigsService) List() ([]domain.LeaderboardConfig, error) {
+	stored, err := s.store.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	configs := make([]domain.LeaderboardConfig, 0, len(stored))
+	for _, config := range stored {
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs, nil
+}
+
+// Plan validates the configurations and returns the changes applying them makes to the stored
+// ones
+func (s *ConfigsService) Plan(configs []domain.LeaderboardConfig) ([]domain.ConfigChange, error) {
+	changes, _, err := s.plan(configs)
+	return changes, err
+}
+
+// Apply validates the configurations and stores the created and updated ones, nothing is
+// stored in a dry run. Created leaderboards are audited as lifecycle transitions and updates
+// fail when the lifecycle changed since it was read
+func (s *ConfigsService) Apply(configs []domain.LeaderboardConfig, dryRun bool) ([]domain.ConfigChange, error) {
+	changes, planned, err := s.plan(configs)
+	if err != nil || dryRun {
+		return changes, err
+	}
+	for _, change := range changes {
+		config := planned[change.Name]
+		switch change.Action {
+		case domain.ConfigCreate:
+			now := time.Now().UTC()
+			config.Lifecycle, err = domain.InitialLifecycle(config.Lifecycle.State, now)
+			if err != nil {
+				return changes, fmt.Errorf("%v: %v", change.Name, err)
+			}
+			err = s.store.CreateConfig(config, domain.LifecycleAudit{
+				Name:  config.Name,
+				To:    config.Lifecycle.StateAt(now),
+				Actor: configActor,
+				At:    now,
+			})
+		case domain.ConfigUpdate:
+			err = s.store.ReplaceConfig(config, config.Lifecycle)
+		default:
+			continue
+		}
+		if err != nil {
+			return changes, fmt.Errorf("failed to store %v: %v", change.Name, err)
+		}
+	}
+	return changes, nil
+}
+
+func (s *ConfigsService) plan(configs []domain.LeaderboardConfig) ([]domain.ConfigChange, map[string]domain.LeaderboardConfig, error) {
+	err := domain.ValidateConfigs(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, err := s.store.GetConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+
+	// names are unique regardless of their case, so the stored configurations are matched by
+	// their lower case names
+	byName := make(map[string]domain.LeaderboardConfig, len(stored))
+	for _, config := range stored {
+		byName[strings.ToLower(config.Name)] = config
+	}
+	changes := []domain.ConfigChange{}
+	planned := make(map[string]domain.LeaderboardConfig, len(configs))
+	applied := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		applied[strings.ToLower(config.Name)] = true
+		current, ok := byName[strings.ToLower(config.Name)]
+		if ok && current.Name != config.Name {
+			return nil, nil, fmt.Errorf("%v: name differs only in case from the stored leaderboard %v", config.Name, current.Name)
+		}
+		config.Webhook, err = keepSecret(current.Webhook, config.Webhook)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: %v", config.Name, err)
+		}
+		if !ok {
+			// leaderboards start as drafts or active, the other states are reached by transitions
+			_, err = domain.InitialLifecycle(config.Lifecycle.State, time.Now())
+			if err != nil {
+				return nil, nil, fmt.Errorf("%v: %v", config.Name, err)
+			}
+			config.Lifecycle = domain.LeaderboardLifecycle{State: config.Lifecycle.State}
+			fields, err := diffConfigs(nil, config)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, domain.ConfigChange{Name: config.Name, Action: domain.ConfigCreate, Fields: fields})
+			planned[config.Name] = config
+			continue
+		}
+		config.Lifecycle = current.Lifecycle
+		fields, err := diffConfigs(&current, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		action := domain.ConfigUpdate
+		if len(fields) == 0 {
+			action = domain.ConfigUnchanged
+		}
+		changes = append(changes, domain.ConfigChange{Name: config.Name, Action: action, Fields: fields})
+		planned[config.Name] = config
+	}
+	for name := range stored {
+		if !applied[strings.ToLower(name)] {
+			changes = append(changes, domain.ConfigChange{Name: name, Action: domain.ConfigUnmanaged})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, planned, nil
+}
+
+// keepSecret returns the applied webhook with the stored secret when the applied one omits it
+// or is redacted, so secrets do not have to live in the configuration files
+func keepSecret(stored *domain.WebhookConfig, applied *domain.WebhookConfig) (*domain.WebhookConfig, error) {
+	if applied == nil || (applied.Secret != "" && applied.Secret != domain.RedactedSecret) {
+		return applied, nil
+	}
+	webhook := *applied
+	webhook.Secret = ""
+	if stored != nil {
+		webhook.Secret = stored.Secret
+	}
+	if applied.Secret == domain.RedactedSecret && webhook.Secret == "" {
+		return nil, fmt.Errorf("webhook secret is redacted and there is no stored secret to keep")
+	}
+	return &webhook, nil
+}
+
+// diffConfigs returns the fields that changed between the JSON representations of two
+// configurations sorted by path, unset and empty fields are the same. The values of secrets are
+// redacted
+func diffConfigs(from *domain.LeaderboardConfig, to domain.LeaderboardConfig) ([]domain.ConfigFieldChange, error) {
+	before := map[string]string{}
+	if from != nil {
+		err := flattenConfig(*from, before)
+		if err != nil {
+			return nil, err
+		}
+	}
+	after := map[string]string{}
+	err := flattenConfig(to, after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []domain.ConfigFieldChange{}
+	for path, v := range after {
+		if before[path] != v {
+			fields = append(fields, domain.ConfigFieldChange{Path: path, From: before[path], To: v})
+		}
+	}
+	for path, v := range before {
+		if _, ok := after[path]; !ok {
+			fields = append(fields, domain.ConfigFieldChange{Path: path, From: v})
+		}
+	}
+	for i, field := range fields {
+		if secretPaths[field.Path] {
+			fields[i].From, fields[i].To = redact(field.From), redact(field.To)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields, nil
+}
+
+// secretPaths are the paths of the configuration fields which are never shown
+var secretPaths = map[string]bool{"webhook.secret": true}
+
+func redact(v string) string {
+	if v == "" {
+		return v
+	}
+	return strconv.Quote(domain.RedactedSecret)
+}
+
+func flattenConfig(config domain.LeaderboardConfig, out map[string]string) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	err = dec.Decode(&v)
+	if err != nil {
+		return fmt.Errorf("failed to parse configuration: %v", err)
+	}
+	flattenJSON("", v, out)
+	return nil
+}
+
+// flattenJSON collects the scalar values of a JSON value by their dotted paths
+func flattenJSON(path string, v interface{}, out map[string]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch t := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, e := range t {
+			flattenJSON(join(k), e, out)
+		}
+	case []interface{}:
+		for i, e := range t {
+			flattenJSON(join(strconv.Itoa(i)), e, out)
+		}
+	default:
+		data, _ := json.Marshal(t)
+		out[path] = string(data)
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfigsApply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mocks.NewMockConfigStore(ctrl)
+	configs := NewConfigsService(store)
+
+	closedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stored := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Daily, domain.Max)
+	stored.Lifecycle = domain.LeaderboardLifecycle{State: domain.Closed, ClosedAt: &closedAt}
+	unchanged := testutil.NewLeaderboardConfigWithScoreboards("monthly", domain.Monthly, domain.Sum)
+	unchanged.ResetExpression.Anchor = "2024-01-01"
+	legacy := testutil.NewLeaderboardConfigWithScoreboards("legacy", domain.Weekly, domain.Sum)
+	store.EXPECT().GetConfig().Return(domain.LeaderboardsConfigMap{"daily": stored, "monthly": unchanged, "legacy": legacy}, nil).Times(2)
+
+	created := testutil.NewLeaderboardConfigWithScoreboards("weekly", domain.Weekly, domain.Sum)
+	updated := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Hourly, domain.Max)
+	updated.StatsBuckets = []float64{10}
+
+	// a dry run does not store the configurations
+	changes, err := configs.Apply([]domain.LeaderboardConfig{created, updated, unchanged}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ConfigChange{
+		{Name: "daily", Action: domain.ConfigUpdate, Fields: []domain.ConfigFieldChange{
+			{Path: "reset.reset_type", From: "2", To: "1"},
+			{Path: "stats_buckets.0", To: "10"},
+		}},
+		{Name: "legacy", Action: domain.ConfigUnmanaged},
+		{Name: "monthly", Action: domain.ConfigUnchanged, Fields: []domain.ConfigFieldChange{}},
+		{Name: "weekly", Action: domain.ConfigCreate, Fields: changes[3].Fields},
+	}, changes)
+	assert.Contains(t, changes[3].Fields, domain.ConfigFieldChange{Path: "name", To: `"weekly"`})
+
+	// the stored lifecycle is kept and is the condition of the update, created leaderboards are
+	// audited
+	expected := updated
+	expected.Lifecycle = stored.Lifecycle
+	store.EXPECT().ReplaceConfig(expected, stored.Lifecycle).Return(nil)
+	store.EXPECT().CreateConfig(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(config domain.LeaderboardConfig, audit domain.LifecycleAudit) error {
+			assert.Equal(t, created.Name, config.Name)
+			assert.Equal(t, domain.Active, config.Lifecycle.StateAt(audit.At))
+			assert.Equal(t, &audit.At, config.Lifecycle.ActivatedAt)
+			assert.Equal(t, domain.LifecycleAudit{Name: "weekly", To: domain.Active, Actor: "config apply", At: audit.At}, audit)
+			return nil
+		})
+	_, err = configs.Apply([]domain.LeaderboardConfig{created, updated, unchanged}, false)
+	assert.NoError(t, err)
+}
+
+func TestConfigsApplyNames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mocks.NewMockConfigStore(ctrl)
+	configs := NewConfigsService(store)
+	stored := testutil.NewLeaderboardConfigWithScoreboards("weekly", domain.Weekly, domain.Max)
+	store.EXPECT().GetConfig().Return(domain.LeaderboardsConfigMap{"weekly": stored}, nil).AnyTimes()
+
+	// names only differing in case match the stored leaderboard and are not created
+	applied := testutil.NewLeaderboardConfigWithScoreboards("Weekly", domain.Weekly, domain.Max)
+	_, err := configs.Apply([]domain.LeaderboardConfig{applied}, false)
+	assert.ErrorContains(t, err, "differs only in case")
+
+	// leaderboards are created as drafts or active
+	created := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Daily, domain.Max)
+	created.Lifecycle = domain.LeaderboardLifecycle{State: domain.Closed}
+	_, err = configs.Plan([]domain.LeaderboardConfig{stored, created})
+	assert.Error(t, err)
+
+	activatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	created.Lifecycle = domain.LeaderboardLifecycle{State: domain.Draft, ActivatedAt: &activatedAt}
+	store.EXPECT().CreateConfig(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(config domain.LeaderboardConfig, audit domain.LifecycleAudit) error {
+			assert.Equal(t, domain.LeaderboardLifecycle{State: domain.Draft, UpdatedAt: &audit.At}, config.Lifecycle)
+			assert.Equal(t, domain.Draft, audit.To)
+			return nil
+		})
+	_, err = configs.Apply([]domain.LeaderboardConfig{stored, created}, false)
+	assert.NoError(t, err)
+}
+
+func TestConfigsApplySecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mocks.NewMockConfigStore(ctrl)
+	configs := NewConfigsService(store)
+
+	stored := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Daily, domain.Max)
+	stored.Webhook = &domain.WebhookConfig{URL: "http://prizes", Secret: "stored"}
+	store.EXPECT().GetConfig().Return(domain.LeaderboardsConfigMap{"daily": stored}, nil).AnyTimes()
+
+	// omitted and redacted secrets keep the stored one
+	for _, secret := range []string{"", domain.RedactedSecret} {
+		applied := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Daily, domain.Max)
+		applied.Webhook = &domain.WebhookConfig{URL: "http://prizes", Secret: secret}
+		changes, err := configs.Plan([]domain.LeaderboardConfig{applied})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ConfigUnchanged, changes[0].Action)
+		assert.Equal(t, secret, applied.Webhook.Secret)
+	}
+
+	// changed secrets are stored but not shown
+	applied := testutil.NewLeaderboardConfigWithScoreboards("daily", domain.Daily, domain.Max)
+	applied.Webhook = &domain.WebhookConfig{URL: "http://prizes", Secret: "rotated"}
+	store.EXPECT().ReplaceConfig(applied, stored.Lifecycle).Return(nil)
+	changes, err := configs.Apply([]domain.LeaderboardConfig{applied}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ConfigFieldChange{{Path: "webhook.secret", From: `"<redacted>"`, To: `"<redacted>"`}}, changes[0].Fields)
+
+	// a redacted secret needs a stored one
+	created := testutil.NewLeaderboardConfigWithScoreboards("weekly", domain.Weekly, domain.Max)
+	created.Webhook = &domain.WebhookConfig{URL: "http://prizes", Secret: domain.RedactedSecret}
+	_, err = configs.Plan([]domain.LeaderboardConfig{created})
+	assert.ErrorContains(t, err, "redacted")
+}
+
+func TestConfigsService_ApplyInvalid(t *testing.T) {
+	decay := testutil.NewLeaderboardConfigWithScoreboards("decay", domain.Weekly, domain.Decay)
+
+	// nothing is stored when a configuration is invalid
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, err := NewConfigsService(mocks.NewMockConfigStore(ctrl)).Apply([]domain.LeaderboardConfig{decay}, false)
+	assert.Error(t, err)
+}
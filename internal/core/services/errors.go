@@ -1,13 +0,0 @@
-package services
-
-import "fmt"
-
-// LeaderboardNotFoundError ...
-type LeaderboardNotFoundError struct {
-	Name string
-}
-
-// Error interface implementation
-func (e *LeaderboardNotFoundError) Error() string {
-	return fmt.Sprintf("%s not found", e.Name)
-}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/segmentio/ksuid"
+)
+
+// publishEvents publishes events with a new ID unless they have one, so consumers dedupe the
+// retries of the publisher. Failures are logged and do not fail the operation that raised the
+// events, the accepted event of a score is already stored with it
+func publishEvents(publisher ports.EventPublisher, log ports.Logger, events ...domain.Event) {
+	if publisher == nil || len(events) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	for i := range events {
+		if events[i].ID == "" {
+			events[i].ID = ksuid.New().String()
+		}
+		if events[i].At.IsZero() {
+			events[i].At = now
+		}
+	}
+	err := publisher.Publish(events...)
+	if err != nil && log != nil {
+		_ = log.Error("failed to publish %d events: %v", len(events), err)
+	}
+}
+
+// entryStanding is the score and rank of an entry in a scoreboard and the entries above it
+type entryStanding struct {
+	score float64
+	rank  uint64
+	above []domain.ScoreboardResult
+}
+
+// standingOf returns the standing of an entry in the global scoreboard of a leaderboard or nil
+// when the entry has no score, the entries above it are only fetched to find overtakes
+func (s *LeaderboardsService) standingOf(entryID string, leaderboard string, config domain.LeaderboardConfig) (*entryStanding, error) {
+	scores, err := s.scoreboard.GetScores(leaderboard, []string{entryID})
+	if err != nil || len(scores) == 0 {
+		return nil, err
+	}
+	rank, err := s.scoreboard.GetRank(leaderboard, entryID, config.Order())
+	if err != nil {
+		return nil, err
+	}
+	standing := &entryStanding{score: scores[0].Score, rank: rank}
+	if config.Overtakes != nil {
+		standing.above, err = s.scoreboard.GetNeighbours(leaderboard, entryID, config.Overtakes.EntriesAbove(), 0, config.Order())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return standing, nil
+}
+
+// scoreEvents returns the events of a stored score given the standing of the entry before it
+func (s *LeaderboardsService) scoreEvents(event domain.Event, config domain.LeaderboardConfig, leaderboard string, previous *entryStanding, decay func(float64) float64) []domain.Event {
+	if previous != nil {
+		score := decay(previous.score)
+		event.PreviousScore = &score
+		event.PreviousRank = int64(previous.rank)
+	}
+	rank, err := s.scoreboard.GetRank(leaderboard, event.EntryID, config.Order())
+	if err != nil && s.log != nil {
+		_ = s.log.Error("failed to fetch rank of %v in %v: %v", event.EntryID, leaderboard, err)
+	}
+	event.Rank = int64(rank)
+
+	accepted := event
+	accepted.Type = domain.ScoreAccepted
+	events := []domain.Event{accepted}
+	switch config.Function {
+	case domain.Max, domain.Min, domain.Decay:
+		// these functions only store scores which improve the best score
+		best := event
+		best.Type = domain.PersonalBest
+		events = append(events, best)
+	}
+	if err == nil && event.Rank != event.PreviousRank {
+		changed := event
+		changed.Type = domain.RankChanged
+		events = append(events, changed)
+	}
+	if err == nil && previous != nil && event.Rank < event.PreviousRank {
+		events = append(events, s.overtakeEvents(event, config, leaderboard, previous.above)...)
+	}
+	return events
+}
+
+// overtakeEvents returns the Overtaken events of the entries ranked above an entry before its
+// score which are ranked below it after. An overtaken entry gets one event per interval
+func (s *LeaderboardsService) overtakeEvents(event domain.Event, config domain.LeaderboardConfig, leaderboard string, above []domain.ScoreboardResult) []domain.Event {
+	if config.Overtakes == nil || len(above) == 0 {
+		return nil
+	}
+	below, err := s.scoreboard.GetNeighbours(leaderboard, event.EntryID, 0, int64(len(above)), config.Order())
+	if err != nil {
+		if s.log != nil {
+			_ = s.log.Error("failed to fetch entries below %v in %v: %v", event.EntryID, leaderboard, err)
+		}
+		return nil
+	}
+	wasAbove := make(map[string]bool, len(above))
+	for _, r := range above {
+		wasAbove[r.EntryID] = true
+	}
+
+	events := []domain.Event{}
+	for _, r := range below {
+		if !wasAbove[r.EntryID] {
+			continue
+		}
+		if s.limiter != nil {
+			key := strings.ToLower(fmt.Sprintf("overtaken::%s::%s", leaderboard, r.EntryID))
+			allowed, err := s.limiter.Allow(key, config.Overtakes.NotifyInterval())
+			if err != nil && s.log != nil {
+				_ = s.log.Error("failed to rate limit overtakes of %v: %v", r.EntryID, err)
+			}
+			if !allowed {
+				continue
+			}
+		}
+		overtaken := event
+		overtaken.Type = domain.Overtaken
+		overtaken.OvertakenEntryID = r.EntryID
+		events = append(events, overtaken)
+	}
+	return events
+}
+
+// rejectionReason returns the reason of the ScoreRejected event of an error reporting a score,
+// it returns false for the errors which are not a rejection
+func rejectionReason(err error) (string, bool) {
+	var invalid *domain.InvalidScoreError
+	var closed *domain.LeaderboardClosedError
+	var state *domain.LeaderboardStateError
+	switch {
+	case errors.As(err, &invalid):
+		return domain.RejectedInvalid, true
+	case errors.As(err, &closed), errors.As(err, &state):
+		return domain.RejectedClosed, true
+	}
+	return "", false
+}
+
+// reportedScore returns the score of a rejected report, components are not summed up
+func reportedScore(decimal string) float64 {
+	score, _ := strconv.ParseFloat(decimal, 64)
+	return score
+}
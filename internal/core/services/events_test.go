@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/adapters/output/events"
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReportScoreEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Function = domain.Max
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	publisher := events.NewMemoryPublisher()
+	lbSrv := NewLeaderboardsServiceWithOptions(repo, scoreboard, configProvider, LeaderboardsOptions{Events: publisher, Logger: logging.NewSimpleLogger()})
+	nameEpoch, epoch, err := GetLeaderboardNameWithEpoch(lbName, config.CronExpression)
+	assert.NoError(t, err)
+
+	// the accepted event is stored with the score and published with the same ID once ranked
+	var stored domain.Event
+	repo.EXPECT().WithOutbox(gomock.Any()).DoAndReturn(func(events ...domain.Event) ports.Repository {
+		assert.Len(t, events, 1)
+		stored = events[0]
+		return repo
+	}).Times(2)
+
+	// a new best score which climbs from the second to the first rank
+	scoreboard.EXPECT().GetScores(nameEpoch, []string{entryID}).Return([]domain.ScoreboardResult{{EntryID: entryID, Score: 50}}, nil)
+	scoreboard.EXPECT().GetRank(nameEpoch, entryID, domain.Descending).Return(uint64(2), nil)
+	repo.EXPECT().MaxWithMetadata(entryID, nameEpoch, 100.0, nil).Return(domain.ScoreUpdate{Score: 100, Done: true}, nil)
+	scoreboard.EXPECT().AddScore(entryID, nameEpoch, 100.0).Return(nil)
+	repo.EXPECT().IncrementSubmissions(nameEpoch).Return(nil)
+	scoreboard.EXPECT().GetRank(nameEpoch, entryID, domain.Descending).Return(uint64(1), nil)
+	_, err = lbSrv.ReportScore(entryID, lbName, 100)
+	assert.NoError(t, err)
+
+	published := publisher.Events()
+	assert.Len(t, published, 3)
+	ids := map[string]bool{}
+	for i, e := range published {
+		assert.Equal(t, []domain.EventType{domain.ScoreAccepted, domain.PersonalBest, domain.RankChanged}[i], e.Type)
+		assert.Equal(t, lbName, e.Leaderboard)
+		assert.Equal(t, epoch, e.Epoch)
+		assert.Equal(t, 100.0, e.Score)
+		assert.Equal(t, 50.0, *e.PreviousScore)
+		assert.Equal(t, int64(1), e.Rank)
+		assert.Equal(t, int64(2), e.PreviousRank)
+		assert.False(t, e.At.IsZero())
+		ids[e.ID] = true
+	}
+	assert.Len(t, ids, 3)
+	assert.Equal(t, stored.ID, published[0].ID)
+	assert.Equal(t, domain.ScoreAccepted, stored.Type)
+	assert.Equal(t, 100.0, stored.Score)
+
+	// a score which does not improve the best one
+	scoreboard.EXPECT().GetScores(nameEpoch, []string{entryID}).Return([]domain.ScoreboardResult{{EntryID: entryID, Score: 100}}, nil)
+	scoreboard.EXPECT().GetRank(nameEpoch, entryID, domain.Descending).Return(uint64(1), nil)
+	repo.EXPECT().MaxWithMetadata(entryID, nameEpoch, 10.0, nil).Return(domain.ScoreUpdate{Score: 100, Done: false}, nil)
+	_, err = lbSrv.ReportScore(entryID, lbName, 10)
+	assert.NoError(t, err)
+
+	published = publisher.Events()
+	assert.Len(t, published, 4)
+	assert.Equal(t, domain.ScoreRejected, published[3].Type)
+	assert.Equal(t, domain.RejectedNotImproved, published[3].Reason)
+	assert.Equal(t, 10.0, published[3].Score)
+}
+
+func TestReportScoreEventsInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	publisher := mocks.NewMockEventPublisher(ctrl)
+	repo := mocks.NewMockRepository(ctrl)
+	repo.EXPECT().WithOutbox(gomock.Any()).Return(repo).AnyTimes()
+	lbSrv := NewLeaderboardsServiceWithOptions(repo, mocks.NewMockScoreboard(ctrl), defaultConfigProviderMock(ctrl, lbName), LeaderboardsOptions{Events: publisher, Logger: logging.NewSimpleLogger()})
+
+	// a failed publish is logged and does not change the result of the report
+	publisher.EXPECT().Publish(gomock.Any()).DoAndReturn(func(events ...domain.Event) error {
+		assert.Equal(t, domain.ScoreRejected, events[0].Type)
+		assert.Equal(t, domain.RejectedInvalid, events[0].Reason)
+		assert.NotEmpty(t, events[0].ID)
+		return fmt.Errorf("unavailable")
+	})
+	_, err := lbSrv.ReportDecimalScoreWithMetadata("p1", lbName, "1e", nil)
+	var invalid *domain.InvalidScoreError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestReportScoreOvertakes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	limiter := mocks.NewMockRateLimiter(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Function = domain.Max
+	config.Overtakes = &domain.OvertakeConfig{Depth: 3, Interval: "5m"}
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	publisher := events.NewMemoryPublisher()
+	repo.EXPECT().WithOutbox(gomock.Any()).Return(repo)
+	lbSrv := NewLeaderboardsServiceWithOptions(repo, scoreboard, configProvider, LeaderboardsOptions{Events: publisher, Limiter: limiter, Logger: logging.NewSimpleLogger()})
+	nameEpoch, epoch, err := GetLeaderboardNameWithEpoch(lbName, config.CronExpression)
+	assert.NoError(t, err)
+
+	// p4 climbs from the fourth to the second rank past p3 and p2, p2 was notified recently
+	scoreboard.EXPECT().GetScores(nameEpoch, []string{"p4"}).Return([]domain.ScoreboardResult{{EntryID: "p4", Score: 10}}, nil)
+	scoreboard.EXPECT().GetRank(nameEpoch, "p4", domain.Descending).Return(uint64(4), nil)
+	scoreboard.EXPECT().GetNeighbours(nameEpoch, "p4", int64(3), int64(0), domain.Descending).Return([]domain.ScoreboardResult{
+		{EntryID: "p1", Score: 50, Rank: 1},
+		{EntryID: "p2", Score: 30, Rank: 2},
+		{EntryID: "p3", Score: 20, Rank: 3},
+	}, nil)
+	repo.EXPECT().MaxWithMetadata("p4", nameEpoch, 40.0, nil).Return(domain.ScoreUpdate{Score: 40, Done: true}, nil)
+	scoreboard.EXPECT().AddScore("p4", nameEpoch, 40.0).Return(nil)
+	repo.EXPECT().IncrementSubmissions(nameEpoch).Return(nil)
+	scoreboard.EXPECT().GetRank(nameEpoch, "p4", domain.Descending).Return(uint64(2), nil)
+	scoreboard.EXPECT().GetNeighbours(nameEpoch, "p4", int64(0), int64(3), domain.Descending).Return([]domain.ScoreboardResult{
+		{EntryID: "p2", Score: 30, Rank: 3},
+		{EntryID: "p3", Score: 20, Rank: 4},
+		{EntryID: "p5", Score: 5, Rank: 5},
+	}, nil)
+	limiter.EXPECT().Allow("overtaken::"+nameEpoch+"::p2", 5*time.Minute).Return(false, nil)
+	limiter.EXPECT().Allow("overtaken::"+nameEpoch+"::p3", 5*time.Minute).Return(true, nil)
+	_, err = lbSrv.ReportScore("p4", lbName, 40)
+	assert.NoError(t, err)
+
+	overtakes := []domain.Event{}
+	for _, e := range publisher.Events() {
+		if e.Type == domain.Overtaken {
+			overtakes = append(overtakes, e)
+		}
+	}
+	assert.Len(t, overtakes, 1)
+	assert.Equal(t, "p4", overtakes[0].EntryID)
+	assert.Equal(t, "p3", overtakes[0].OvertakenEntryID)
+	assert.Equal(t, epoch, overtakes[0].Epoch)
+	assert.Equal(t, int64(2), overtakes[0].Rank)
+}
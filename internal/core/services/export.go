@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+// exportPageSize is the number of entries read from the scoreboards on every request of an export
+const exportPageSize = 1000
+
+// exportAllValues selects every scoreboard of a field in an export
+const exportAllValues = "*"
+
+// ExportStandings writes the full standings of the global scoreboard and of the selected
+// scoreboards of an epoch, the scoreboards are selected by field and value or by field and "*"
+// for all values. The scoreboards are paged so the standings are never fully kept in memory and
+// when the epoch is not positive the current epoch is exported. The pages are read from the live
+// scoreboards, so the export of an epoch that still takes scores can repeat or miss the entries
+// whose rank changes between pages, only closed epochs export consistent standings
+func (s *LeaderboardsService) ExportStandings(name string, epoch int64, scoreboards domain.Metadata, out ports.StandingsWriter) (domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return domain.EpochInfo{}, err
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	boards, err := s.exportedBoards(name, epoch, config, scoreboards)
+	if err != nil {
+		return domain.EpochInfo{}, err
+	}
+
+	decay := decayAt(config, epoch, time.Now())
+	for _, board := range append([]string{leaderboard}, boards...) {
+		for start := int64(0); ; start += exportPageSize {
+			scores, err := s.scoreboard.GetRange(board, start, start+exportPageSize-1, config.Order())
+			if err != nil {
+				return domain.EpochInfo{}, fmt.Errorf("failed to fetch scores of %v: %v", board, err)
+			}
+			entries := make([]string, 0, len(scores))
+			for _, score := range scores {
+				entries = append(entries, score.EntryID)
+			}
+			stored, err := s.repository.GetEntries(leaderboard, entries)
+			if err != nil {
+				return domain.EpochInfo{}, fmt.Errorf("failed to fetch entries: %v", err)
+			}
+			for _, score := range scores {
+				entry := stored[score.EntryID]
+				row := domain.StandingsRow{
+					Scoreboard: board,
+					Rank:       score.Rank,
+					EntryID:    score.EntryID,
+					Score:      decay(score.Score),
+					Counter:    entry.Counter,
+					Metadata:   entry.Metadata,
+				}
+				if config.IsExact() && entry.Score != "" {
+					exact, err := config.FormatScore(entry.Score)
+					if err != nil {
+						return domain.EpochInfo{}, err
+					}
+					row.ExactScore = exact.Value
+				}
+				err = out.Write(row)
+				if err != nil {
+					return domain.EpochInfo{}, fmt.Errorf("failed to write standings: %v", err)
+				}
+			}
+			err = out.Flush()
+			if err != nil {
+				return domain.EpochInfo{}, fmt.Errorf("failed to write standings: %v", err)
+			}
+			if len(scores) < exportPageSize {
+				break
+			}
+		}
+	}
+	return newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// exportedBoards returns the names of the selected scoreboards of an epoch sorted by field
+func (s *LeaderboardsService) exportedBoards(name string, epoch int64, config domain.LeaderboardConfig, scoreboards domain.Metadata) ([]string, error) {
+	fields := make([]string, 0, len(scoreboards))
+	for field := range scoreboards {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	boards := []string{}
+	for _, field := range fields {
+		sb, ok := scoreboardOf(config, field)
+		if !ok {
+			return nil, &domain.ScoreboardNotFoundError{Name: name, Field: field}
+		}
+		value := scoreboards[field]
+		if value != exportAllValues {
+			boards = append(boards, sbNameFromType(name, epoch, sb, value))
+			continue
+		}
+		keys, err := s.scoreboard.Keys(sbNameFromType(name, epoch, sb, exportAllValues))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scoreboards: %v", err)
+		}
+		sort.Strings(keys)
+		boards = append(boards, keys...)
+	}
+	return boards, nil
+}
+
+// scoreboardOf returns the scoreboard configuration of a metadata field
+func scoreboardOf(config domain.LeaderboardConfig, field string) (domain.LeaderboardScoreBoardConfig, bool) {
+	for _, sb := range config.Scoreboards {
+		if sb.Field == field {
+			return sb, true
+		}
+	}
+	return domain.LeaderboardScoreBoardConfig{}, false
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportStandings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	out := mocks.NewMockStandingsWriter(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	pt := sbNameFromType(lbName, 3, config.Scoreboards[1], "pt")
+	es := sbNameFromType(lbName, 3, config.Scoreboards[1], "es")
+	gold := sbNameFromType(lbName, 3, config.Scoreboards[0], "gold")
+
+	page := make([]domain.ScoreboardResult, exportPageSize)
+	ids := make([]string, exportPageSize)
+	for i := range page {
+		ids[i] = testutil.NewID()
+		page[i] = domain.ScoreboardResult{EntryID: ids[i], Score: float64(exportPageSize - i), Rank: int64(i + 1)}
+	}
+	stored := map[string]domain.StoredEntry{ids[0]: {Score: "1000", Counter: 4, Metadata: domain.Metadata{"country": "pt"}}}
+
+	gomock.InOrder(
+		scoreboard.EXPECT().Keys(sbNameFromType(lbName, 3, config.Scoreboards[1], "*")).Return([]string{pt, es}, nil),
+		// the global scoreboard is paged until a page is not full
+		scoreboard.EXPECT().GetRange(leaderboard, int64(0), int64(exportPageSize-1), domain.Descending).Return(page, nil),
+		repo.EXPECT().GetEntries(leaderboard, ids).Return(stored, nil),
+		scoreboard.EXPECT().GetRange(leaderboard, int64(exportPageSize), int64(2*exportPageSize-1), domain.Descending).Return(nil, nil),
+		repo.EXPECT().GetEntries(leaderboard, []string{}).Return(nil, nil),
+		// the scoreboards follow sorted by field and value
+		scoreboard.EXPECT().GetRange(es, int64(0), int64(exportPageSize-1), domain.Descending).Return(nil, nil),
+		repo.EXPECT().GetEntries(leaderboard, []string{}).Return(nil, nil),
+		scoreboard.EXPECT().GetRange(pt, int64(0), int64(exportPageSize-1), domain.Descending).Return(page[:1], nil),
+		repo.EXPECT().GetEntries(leaderboard, ids[:1]).Return(stored, nil),
+		scoreboard.EXPECT().GetRange(gold, int64(0), int64(exportPageSize-1), domain.Descending).Return(nil, nil),
+		repo.EXPECT().GetEntries(leaderboard, []string{}).Return(nil, nil),
+	)
+
+	var rows []domain.StandingsRow
+	out.EXPECT().Write(gomock.Any()).DoAndReturn(func(row domain.StandingsRow) error {
+		rows = append(rows, row)
+		return nil
+	}).Times(exportPageSize + 1)
+	out.EXPECT().Flush().Return(nil).Times(5)
+
+	info, err := lbSrv.ExportStandings(lbName, 3, domain.Metadata{"country": "*", "league": "gold"}, out)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Epoch)
+	assert.Len(t, rows, exportPageSize+1)
+	first := domain.StandingsRow{Scoreboard: leaderboard, Rank: 1, EntryID: ids[0], Score: exportPageSize, Counter: 4, Metadata: domain.Metadata{"country": "pt"}}
+	assert.Equal(t, first, rows[0])
+	assert.Equal(t, domain.StandingsRow{Scoreboard: leaderboard, Rank: 2, EntryID: ids[1], Score: exportPageSize - 1}, rows[1])
+	first.Scoreboard = pt
+	assert.Equal(t, first, rows[exportPageSize])
+}
+
+func TestExportStandingsUnknownScoreboard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), configProvider)
+
+	// nothing is written when the selection is invalid
+	_, err := lbSrv.ExportStandings(lbName, 3, domain.Metadata{"platform": "ios"}, mocks.NewMockStandingsWriter(ctrl))
+	var notFound *domain.ScoreboardNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "platform", notFound.Field)
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// defaultImportConcurrency is the number of workers writing the records of an import
+	defaultImportConcurrency = 8
+	// defaultImportChunkSize is the number of records written between checkpoints
+	defaultImportChunkSize = 1000
+	// maxImportIssues is the number of issues kept in the report of an import
+	maxImportIssues = 1000
+	// importAttempts is the number of times the records of entries that changed while they
+	// were applied are read and applied again
+	importAttempts = 3
+)
+
+// importedScore is a valid record of an import
+type importedScore struct {
+	line     int64
+	entryID  string
+	score    float64
+	exact    domain.ExactScore
+	metadata domain.Metadata
+}
+
+// ImportScores writes the records of an import into a leaderboard epoch and its scoreboards.
+// Records are written in chunks by workers that own the entries, so the records of an entry keep
+// their order, and the report is saved in the checkpoint after every chunk. A resumed import
+// skips the records of the checkpoint and the records of a chunk that did not finish are written
+// again, which the entries ignore in apply mode since they keep the last record of the import
+// applied to them. Imported scores are not submissions and do not publish events
+func (s *LeaderboardsService) ImportScores(name string, in ports.RecordReader, options domain.ImportOptions, checkpoint ports.ImportCheckpoint) (domain.ImportReport, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.ImportReport{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	if len(config.Components) > 0 {
+		return domain.ImportReport{}, fmt.Errorf("leaderboards with components can not be imported")
+	}
+	mode := options.Mode
+	switch mode {
+	case "":
+		mode = domain.ImportApply
+	case domain.ImportApply:
+	case domain.ImportSet:
+		if config.Function == domain.Decay {
+			return domain.ImportReport{}, fmt.Errorf("decay leaderboards can not be imported in %s mode", mode)
+		}
+	default:
+		return domain.ImportReport{}, fmt.Errorf("unsupported import mode: %s", mode)
+	}
+	epoch := options.Epoch
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.ImportReport{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultImportChunkSize
+	}
+
+	report := domain.ImportReport{ID: ksuid.New().String(), Leaderboard: name, Epoch: epoch, Mode: mode, DryRun: options.DryRun}
+	if options.DryRun {
+		checkpoint = nil
+	}
+	if checkpoint != nil {
+		saved, found, err := checkpoint.Load()
+		if err != nil {
+			return domain.ImportReport{}, fmt.Errorf("failed to load checkpoint: %v", err)
+		}
+		if found {
+			if saved.Leaderboard != name || saved.Epoch != epoch || saved.Mode != mode {
+				return domain.ImportReport{}, fmt.Errorf("checkpoint is of %s epoch %d in %s mode", saved.Leaderboard, saved.Epoch, saved.Mode)
+			}
+			if saved.Done {
+				return saved, nil
+			}
+			if saved.ID == "" {
+				saved.ID = report.ID
+			}
+			report = saved
+		}
+	}
+	for i := int64(0); i < report.Records; i++ {
+		_, err = in.Read()
+		if errors.Is(err, io.EOF) {
+			return report, fmt.Errorf("input has less than the %d records of the checkpoint", report.Records)
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read records: %v", err)
+		}
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	for {
+		records, err := readChunk(in, chunkSize)
+		if err != nil {
+			return report, fmt.Errorf("failed to read records: %v", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+		scores := s.validateImport(&report, config, records)
+		if !options.DryRun {
+			s.writeImport(&report, name, leaderboard, epoch, config, concurrency, scores)
+		}
+		report.Records += int64(len(records))
+		if checkpoint != nil {
+			err = checkpoint.Save(report)
+			if err != nil {
+				return report, fmt.Errorf("failed to save checkpoint: %v", err)
+			}
+		}
+	}
+	report.Done = true
+	if checkpoint != nil {
+		err = checkpoint.Save(report)
+		if err != nil {
+			return report, fmt.Errorf("failed to save checkpoint: %v", err)
+		}
+	}
+	return report, nil
+}
+
+// readChunk reads up to size records, it returns less records only at the end of the input
+func readChunk(in ports.RecordReader, size int) ([]domain.ImportRecord, error) {
+	records := make([]domain.ImportRecord, 0, size)
+	for len(records) < size {
+		record, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// validateImport returns the valid records and reports the invalid ones
+func (s *LeaderboardsService) validateImport(report *domain.ImportReport, config domain.LeaderboardConfig, records []domain.ImportRecord) []importedScore {
+	scores := make([]importedScore, 0, len(records))
+	for _, record := range records {
+		score, err := importedScoreOf(config, record)
+		if err != nil {
+			report.Invalid++
+			addImportIssue(report, domain.ImportIssue{Line: record.Line, EntryID: record.EntryID, Error: err.Error()})
+			continue
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+func importedScoreOf(config domain.LeaderboardConfig, record domain.ImportRecord) (importedScore, error) {
+	if record.Err != nil {
+		return importedScore{}, record.Err
+	}
+	if record.EntryID == "" {
+		return importedScore{}, fmt.Errorf("entry is missing")
+	}
+	for _, sb := range config.Scoreboards {
+		if record.Metadata[sb.Field] == "" {
+			return importedScore{}, fmt.Errorf("metadata %s of a scoreboard is missing", sb.Field)
+		}
+	}
+	score := importedScore{line: record.Line, entryID: record.EntryID, metadata: record.Metadata}
+	if config.IsExact() {
+		exact, err := config.ParseScore(record.Score)
+		if err != nil {
+			return importedScore{}, err
+		}
+		score.exact = exact
+		score.score = exact.Score
+		return score, nil
+	}
+	value, err := strconv.ParseFloat(record.Score, 64)
+	if err != nil {
+		return importedScore{}, fmt.Errorf("score must be a number: %v", record.Score)
+	}
+	score.score = value
+	return score, nil
+}
+
+// writeImport writes the scores with a worker per partition of the entries
+func (s *LeaderboardsService) writeImport(report *domain.ImportReport, name string, leaderboard string, epoch int64, config domain.LeaderboardConfig, concurrency int, scores []importedScore) {
+	partitions := make([][]importedScore, concurrency)
+	for _, score := range scores {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(score.entryID))
+		i := h.Sum32() % uint32(concurrency)
+		partitions[i] = append(partitions[i], score)
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		if len(partition) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(partition []importedScore) {
+			defer wg.Done()
+			var result domain.ImportReport
+			if report.Mode == domain.ImportSet {
+				result = s.setImported(name, leaderboard, epoch, config, partition)
+			} else {
+				result = s.applyImported(report.ID, name, leaderboard, epoch, config, partition)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			report.Applied += result.Applied
+			report.NotImproved += result.NotImproved
+			report.Failed += result.Failed
+			for _, issue := range result.Issues {
+				addImportIssue(report, issue)
+			}
+		}(partition)
+	}
+	wg.Wait()
+}
+
+// applyImported applies the scores with the function of the leaderboard to the records of their
+// entries, which are read and written in batches. A record is replaced only when it did not change
+// since it was read and it keeps the line of the last score of the import applied to it, so the
+// scores of a resumed import that were already applied are skipped
+func (s *LeaderboardsService) applyImported(importID string, name string, leaderboard string, epoch int64, config domain.LeaderboardConfig, scores []importedScore) domain.ImportReport {
+	var result domain.ImportReport
+	fail := func(score importedScore, err error) {
+		result.Failed++
+		result.Issues = append(result.Issues, domain.ImportIssue{Line: score.line, EntryID: score.entryID, Error: err.Error()})
+	}
+
+	byEntry := make(map[string][]importedScore, len(scores))
+	pending := []string{}
+	for _, score := range scores {
+		if len(byEntry[score.entryID]) == 0 {
+			pending = append(pending, score.entryID)
+		}
+		byEntry[score.entryID] = append(byEntry[score.entryID], score)
+	}
+
+	now := time.Now()
+	for attempt := 0; attempt < importAttempts && len(pending) > 0; attempt++ {
+		stored, err := s.repository.GetEntries(leaderboard, pending)
+		if err != nil {
+			for _, entryID := range pending {
+				for _, score := range byEntry[entryID] {
+					fail(score, fmt.Errorf("failed to get entries: %v", err))
+				}
+			}
+			return result
+		}
+
+		entries := make(map[string]domain.StoredEntry, len(pending))
+		counters := make(map[string]uint64, len(pending))
+		applied := make(map[string]domain.StoredEntry, len(pending))
+		outcomes := make(map[string]domain.ImportReport, len(pending))
+		for _, entryID := range pending {
+			entry := stored[entryID]
+			counters[entryID] = entry.Counter
+			outcome, changed, updated := s.applyToEntry(importID, config, epoch, now, entry, byEntry[entryID])
+			if changed {
+				entries[entryID] = updated
+			}
+			if outcome.Applied > 0 {
+				applied[entryID] = updated
+			}
+			outcomes[entryID] = outcome
+		}
+
+		changed := []string{}
+		if len(entries) > 0 {
+			changed, err = s.repository.ReplaceEntries(leaderboard, entries, counters)
+			if err != nil {
+				for _, entryID := range pending {
+					for _, score := range byEntry[entryID] {
+						fail(score, fmt.Errorf("failed to replace entries: %v", err))
+					}
+				}
+				return result
+			}
+		}
+		retry := make(map[string]bool, len(changed))
+		for _, entryID := range changed {
+			retry[entryID] = true
+		}
+		for _, entryID := range pending {
+			if retry[entryID] {
+				continue
+			}
+			outcome := outcomes[entryID]
+			result.NotImproved += outcome.NotImproved
+			result.Failed += outcome.Failed
+			result.Issues = append(result.Issues, outcome.Issues...)
+			entry, ok := applied[entryID]
+			if !ok {
+				continue
+			}
+			// the scoreboards are set again for the skipped scores, which were stored by an
+			// import that stopped before setting them
+			last := byEntry[entryID][len(byEntry[entryID])-1]
+			err = s.addToScoreboards(entryID, name, epoch, config, entry.Metadata, storedScore(config, entry), entry.Score)
+			if err != nil {
+				result.Failed += outcome.Applied
+				result.Issues = append(result.Issues, domain.ImportIssue{Line: last.line, EntryID: entryID, Error: err.Error()})
+				continue
+			}
+			result.Applied += outcome.Applied
+		}
+		pending = changed
+	}
+	for _, entryID := range pending {
+		for _, score := range byEntry[entryID] {
+			fail(score, fmt.Errorf("entry changed while it was imported"))
+		}
+	}
+	return result
+}
+
+// applyToEntry applies the scores of an entry in order to its record, the scores up to the line
+// of the import stored in the record are counted as applied. It returns whether the record changed
+func (s *LeaderboardsService) applyToEntry(importID string, config domain.LeaderboardConfig, epoch int64, now time.Time, entry domain.StoredEntry, scores []importedScore) (domain.ImportReport, bool, domain.StoredEntry) {
+	var outcome domain.ImportReport
+	changed := false
+	for _, score := range scores {
+		if entry.ImportID == importID && score.line <= entry.ImportLine {
+			outcome.Applied++
+			continue
+		}
+		value := strconv.FormatFloat(score.score, 'f', -1, 64)
+		if config.IsExact() {
+			value = score.exact.Value
+		}
+		if config.Function == domain.Decay {
+			value = strconv.FormatFloat(config.Decay.Encode(score.score, now, config.CronExpression.GetEpochStart(epoch)), 'f', -1, 64)
+		}
+		updated, done, err := config.ApplyToEntry(entry, value, score.metadata)
+		switch {
+		case err != nil:
+			outcome.Failed++
+			outcome.Issues = append(outcome.Issues, domain.ImportIssue{Line: score.line, EntryID: score.entryID, Error: err.Error()})
+		case !done:
+			outcome.NotImproved++
+		default:
+			if config.Function == domain.Decay {
+				updated.Raw, updated.ReportedAt = score.score, now.Unix()
+			}
+			updated.ImportID, updated.ImportLine = importID, score.line
+			entry = updated
+			changed = true
+			outcome.Applied++
+		}
+	}
+	return outcome, changed, entry
+}
+
+// storedScore returns the scoreboard score of a stored record
+func storedScore(config domain.LeaderboardConfig, entry domain.StoredEntry) float64 {
+	if config.IsExact() {
+		exact, err := config.FormatScore(entry.Score)
+		if err == nil {
+			return exact.Score
+		}
+	}
+	score, _ := strconv.ParseFloat(entry.Score, 64)
+	return score
+}
+
+// setImported stores the scores replacing the stored ones, the last score of an entry wins
+func (s *LeaderboardsService) setImported(name string, leaderboard string, epoch int64, config domain.LeaderboardConfig, scores []importedScore) domain.ImportReport {
+	var result domain.ImportReport
+	fail := func(score importedScore, err error) {
+		result.Failed++
+		result.Issues = append(result.Issues, domain.ImportIssue{Line: score.line, EntryID: score.entryID, Error: err.Error()})
+	}
+
+	entries := make(map[string]domain.StoredEntry, len(scores))
+	last := make(map[string]importedScore, len(scores))
+	for _, score := range scores {
+		stored := domain.StoredEntry{Score: strconv.FormatFloat(score.score, 'f', -1, 64), Counter: 1, Metadata: score.metadata}
+		if config.IsExact() {
+			stored.Score = score.exact.Value
+		}
+		entries[score.entryID] = stored
+		last[score.entryID] = score
+	}
+	err := s.repository.PutEntries(leaderboard, entries)
+	if err != nil {
+		for _, score := range scores {
+			fail(score, fmt.Errorf("failed to put entries: %v", err))
+		}
+		return result
+	}
+	for _, score := range scores {
+		if last[score.entryID].line != score.line {
+			result.Applied++
+			continue
+		}
+		err = s.addToScoreboards(score.entryID, name, epoch, config, score.metadata, score.score, score.exact.Value)
+		if err != nil {
+			fail(score, err)
+			continue
+		}
+		result.Applied++
+	}
+	return result
+}
+
+func addImportIssue(report *domain.ImportReport, issue domain.ImportIssue) {
+	if len(report.Issues) < maxImportIssues {
+		report.Issues = append(report.Issues, issue)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"io"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// sliceReader reads the records of a slice
+type sliceReader struct {
+	records []domain.ImportRecord
+}
+
+func (r *sliceReader) Read() (domain.ImportRecord, error) {
+	if len(r.records) == 0 {
+		return domain.ImportRecord{}, io.EOF
+	}
+	record := r.records[0]
+	r.records = r.records[1:]
+	return record, nil
+}
+
+func importRecords() []domain.ImportRecord {
+	return []domain.ImportRecord{
+		{Line: 2, EntryID: "p1", Score: "10", Metadata: domain.Metadata{"country": "pt", "league": "gold"}},
+		{Line: 3, EntryID: "p2", Score: "ten", Metadata: domain.Metadata{"country": "pt", "league": "gold"}},
+		{Line: 4, EntryID: "p3", Score: "5", Metadata: domain.Metadata{"league": "gold"}},
+		{Line: 5, EntryID: "p1", Score: "12", Metadata: domain.Metadata{"country": "pt", "league": "gold"}},
+	}
+}
+
+func TestImportScoresApply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	checkpoint := mocks.NewMockImportCheckpoint(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Max)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	meta := domain.Metadata{"country": "pt", "league": "gold"}
+	var importID string
+	gomock.InOrder(
+		repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).Return(map[string]domain.StoredEntry{}, nil),
+		repo.EXPECT().ReplaceEntries(leaderboard, gomock.Any(), map[string]uint64{"p1": 0}).DoAndReturn(
+			func(_ string, entries map[string]domain.StoredEntry, _ map[string]uint64) ([]string, error) {
+				importID = entries["p1"].ImportID
+				assert.NotEmpty(t, importID)
+				assert.Equal(t, domain.StoredEntry{Score: "10", Counter: 1, Metadata: meta, ImportID: importID, ImportLine: 2}, entries["p1"])
+				return nil, nil
+			}),
+		scoreboard.EXPECT().AddScore("p1", leaderboard, float64(10)).Return(nil),
+		scoreboard.EXPECT().AddScore("p1", sbNameFromType(lbName, 3, config.Scoreboards[0], "gold"), float64(10)).Return(nil),
+		scoreboard.EXPECT().AddScore("p1", sbNameFromType(lbName, 3, config.Scoreboards[1], "pt"), float64(10)).Return(nil),
+		// the record changed before it was replaced and the score is applied again
+		repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).DoAndReturn(func(string, []string) (map[string]domain.StoredEntry, error) {
+			return map[string]domain.StoredEntry{"p1": {Score: "10", Counter: 1, Metadata: meta, ImportID: importID, ImportLine: 2}}, nil
+		}),
+		repo.EXPECT().ReplaceEntries(leaderboard, gomock.Any(), map[string]uint64{"p1": 1}).Return([]string{"p1"}, nil),
+		repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).Return(map[string]domain.StoredEntry{"p1": {Score: "15", Counter: 2, Metadata: meta}}, nil),
+	)
+	checkpoint.EXPECT().Load().Return(domain.ImportReport{}, false, nil)
+	var saved []domain.ImportReport
+	checkpoint.EXPECT().Save(gomock.Any()).DoAndReturn(func(report domain.ImportReport) error {
+		saved = append(saved, report)
+		return nil
+	}).Times(3)
+
+	report, err := lbSrv.ImportScores(lbName, &sliceReader{records: importRecords()}, domain.ImportOptions{Epoch: 3, ChunkSize: 3}, checkpoint)
+	assert.NoError(t, err)
+	assert.True(t, report.Done)
+	assert.Equal(t, domain.ImportApply, report.Mode)
+	assert.Equal(t, int64(4), report.Records)
+	assert.Equal(t, int64(1), report.Applied)
+	assert.Equal(t, int64(1), report.NotImproved)
+	assert.Equal(t, int64(2), report.Invalid)
+	assert.Equal(t, []int64{3, 4, 4}, []int64{saved[0].Records, saved[1].Records, saved[2].Records})
+	assert.Equal(t, []domain.ImportIssue{
+		{Line: 3, EntryID: "p2", Error: "score must be a number: ten"},
+		{Line: 4, EntryID: "p3", Error: "metadata country of a scoreboard is missing"},
+	}, report.Issues)
+}
+
+func TestImportScoresApplyResumed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	checkpoint := mocks.NewMockImportCheckpoint(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	meta := domain.Metadata{"country": "pt", "league": "gold"}
+	// the import stopped after storing the first record of p1 and before saving the checkpoint
+	checkpoint.EXPECT().Load().Return(domain.ImportReport{ID: "import", Leaderboard: lbName, Epoch: 3, Mode: domain.ImportApply}, true, nil)
+	repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).Return(map[string]domain.StoredEntry{
+		"p1": {Score: "10", Counter: 1, Metadata: meta, ImportID: "import", ImportLine: 2},
+	}, nil)
+	repo.EXPECT().ReplaceEntries(leaderboard, map[string]domain.StoredEntry{
+		"p1": {Score: "22", Counter: 2, Metadata: meta, ImportID: "import", ImportLine: 5},
+	}, map[string]uint64{"p1": 1}).Return(nil, nil)
+	scoreboard.EXPECT().AddScore("p1", gomock.Any(), float64(22)).Return(nil).Times(3)
+	checkpoint.EXPECT().Save(gomock.Any()).Return(nil).Times(2)
+
+	report, err := lbSrv.ImportScores(lbName, &sliceReader{records: importRecords()}, domain.ImportOptions{Epoch: 3}, checkpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, "import", report.ID)
+	assert.Equal(t, int64(2), report.Applied)
+	assert.Equal(t, int64(2), report.Invalid)
+}
+
+func TestImportScoresSetResumed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	checkpoint := mocks.NewMockImportCheckpoint(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	meta := domain.Metadata{"country": "pt", "league": "gold"}
+	// the first three records were imported before
+	checkpoint.EXPECT().Load().Return(domain.ImportReport{Leaderboard: lbName, Epoch: 3, Mode: domain.ImportSet, Records: 3, Applied: 1, Invalid: 2}, true, nil)
+	repo.EXPECT().PutEntries(leaderboard, map[string]domain.StoredEntry{"p1": {Score: "12", Counter: 1, Metadata: meta}}).Return(nil)
+	scoreboard.EXPECT().AddScore("p1", gomock.Any(), float64(12)).Return(nil).Times(3)
+	checkpoint.EXPECT().Save(gomock.Any()).Return(nil).Times(2)
+
+	report, err := lbSrv.ImportScores(lbName, &sliceReader{records: importRecords()}, domain.ImportOptions{Epoch: 3, Mode: domain.ImportSet}, checkpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), report.Records)
+	assert.Equal(t, int64(2), report.Applied)
+	assert.Equal(t, int64(2), report.Invalid)
+
+	// a checkpoint of another import is not resumed
+	checkpoint.EXPECT().Load().Return(domain.ImportReport{Leaderboard: lbName, Epoch: 2, Mode: domain.ImportSet}, true, nil)
+	_, err = lbSrv.ImportScores(lbName, &sliceReader{records: importRecords()}, domain.ImportOptions{Epoch: 3, Mode: domain.ImportSet}, checkpoint)
+	assert.Error(t, err)
+}
+
+func TestImportScoresDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Sum)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), configProvider)
+
+	// nothing is written nor checkpointed
+	report, err := lbSrv.ImportScores(lbName, &sliceReader{records: importRecords()}, domain.ImportOptions{Epoch: 3, DryRun: true}, mocks.NewMockImportCheckpoint(ctrl))
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(4), report.Records)
+	assert.Equal(t, int64(0), report.Applied)
+	assert.Equal(t, int64(2), report.Invalid)
+}
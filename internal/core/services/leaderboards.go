@@ -2,18 +2,32 @@ package services
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/core/ports"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	statsCacheTTL = 10 * time.Second
+	// migratePageSize is the number of entries read per page to migrate their records
+	migratePageSize = 1000
 )
 
 // LeaderboardsService ...
 type LeaderboardsService struct {
 	repository    ports.Repository
 	scoreboard    ports.Scoreboard
-	configuration ports.Provider[domain.LeaderboardsConfigMap]
+	configuration ports.ConfigProvider
+	statsCache    *ttlCache[domain.LeaderboardStats]
+	events        ports.EventPublisher
+	limiter       ports.RateLimiter
+	archive       ports.ArchiveStore
+	log           ports.Logger
 }
 
 // NewLeaderboardsService creates a new leaderboards service
@@ -26,9 +40,38 @@ func NewLeaderboardsService(
 		repository:    repo,
 		scoreboard:    scoreboard,
 		configuration: configProvider,
+		statsCache:    newTTLCache[domain.LeaderboardStats](statsCacheTTL),
 	}
 }
 
+// LeaderboardsOptions holds the optional dependencies of the leaderboards service, new optional
+// dependencies are added here so the constructors do not change
+type LeaderboardsOptions struct {
+	// Events publishes the score events, publishing failures are logged
+	Events ports.EventPublisher
+	// Limiter throttles the overtake events
+	Limiter ports.RateLimiter
+	// Archive holds the final standings the results of archived epochs are read from
+	Archive ports.ArchiveStore
+	// Logger logs the failures which do not fail the requests
+	Logger ports.Logger
+}
+
+// NewLeaderboardsServiceWithOptions creates a new leaderboards service with optional dependencies
+func NewLeaderboardsServiceWithOptions(
+	repo ports.Repository,
+	scoreboard ports.Scoreboard,
+	configProvider ports.ConfigProvider,
+	options LeaderboardsOptions,
+) *LeaderboardsService {
+	s := NewLeaderboardsService(repo, scoreboard, configProvider)
+	s.events = options.Events
+	s.limiter = options.Limiter
+	s.archive = options.Archive
+	s.log = options.Logger
+	return s
+}
+
 // GetConfig returns the config for a given leaderboard identified by name
 func (s *LeaderboardsService) GetConfig(name string) (domain.LeaderboardConfig, error) {
 	configMap, err := s.configuration.Provide()
@@ -42,6 +85,11 @@ func (s *LeaderboardsService) GetConfig(name string) (domain.LeaderboardConfig,
 	return config, nil
 }
 
+// GetConfigStatus returns the state of the loaded leaderboard configurations
+func (s *LeaderboardsService) GetConfigStatus() domain.ConfigStatus {
+	return s.configuration.Status()
+}
+
 // ReportScore ...
 func (s *LeaderboardsService) ReportScore(entryID string, name string, score float64) (domain.ReportScoreOutput, error) {
 	return s.ReportScoreWithMetadata(entryID, name, score, nil)
@@ -49,48 +97,202 @@ func (s *LeaderboardsService) ReportScore(entryID string, name string, score flo
 
 // ReportScoreWithMetadata ...
 func (s *LeaderboardsService) ReportScoreWithMetadata(entryID string, name string, score float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	return s.reportScore(entryID, name, strconv.FormatFloat(score, 'f', -1, 64), nil, meta)
+}
+
+// ReportDecimalScoreWithMetadata reports a score given as a decimal, which is stored exactly by
+// leaderboards with an integer or fixed-point score type
+func (s *LeaderboardsService) ReportDecimalScoreWithMetadata(entryID string, name string, score string, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	return s.reportScore(entryID, name, score, nil, meta)
+}
+
+// ReportComponentsWithMetadata reports the components of a composite score which are packed in
+// the score of the scoreboards
+func (s *LeaderboardsService) ReportComponentsWithMetadata(entryID string, name string, components []float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
+	return s.reportScore(entryID, name, "", components, meta)
+}
 
+func (s *LeaderboardsService) reportScore(entryID string, name string, decimal string, components []float64, meta domain.Metadata) (domain.ReportScoreOutput, error) {
 	// ReportScore  register a new score to a given entry on a leaderboard
 	config, err := s.GetConfig(name)
 	if err != nil {
 		return domain.ReportScoreOutput{}, fmt.Errorf("failed to fetch configs: %v", err)
 	}
+	var epoch int64
+	reject := func(err error) (domain.ReportScoreOutput, error) {
+		if reason, ok := rejectionReason(err); ok {
+			publishEvents(s.events, s.log, domain.Event{
+				Type:        domain.ScoreRejected,
+				Leaderboard: name,
+				Epoch:       epoch,
+				EntryID:     entryID,
+				Score:       reportedScore(decimal),
+				Reason:      reason,
+				Metadata:    meta,
+			})
+		}
+		return domain.ReportScoreOutput{}, err
+	}
+	err = checkWritable(name, config)
+	if err != nil {
+		return reject(err)
+	}
 
-	leaderboard, epoch, err := GetLeaderboardNameWithEpoch(name, config.CronExpression)
+	leaderboard, epoch, err := s.getLeaderboardNameWithEpoch(name, config)
 
 	if err != nil {
 		return domain.ReportScoreOutput{}, fmt.Errorf("failed to generate name from configs: %v", err)
 	}
 
-	lbFn := s.applyFunction(entryID, leaderboard, score, config.Function, meta)
+	if !config.CronExpression.IsOpen(time.Now()) {
+		return reject(&domain.LeaderboardClosedError{
+			Name:  name,
+			Start: config.CronExpression.GetEpochStart(epoch),
+			End:   config.CronExpression.GetEpochEnd(epoch),
+		})
+	}
+
+	now := time.Now()
+	repo := s.repository
+	// the accepted event is stored with the score and replaced once the score is ranked
+	var accepted domain.Event
+	if s.events != nil {
+		accepted = domain.Event{
+			ID:          ksuid.New().String(),
+			Type:        domain.ScoreAccepted,
+			Leaderboard: name,
+			Epoch:       epoch,
+			EntryID:     entryID,
+			Score:       reportedScore(decimal),
+			ExactScore:  decimal,
+			Metadata:    meta,
+			At:          now.UTC(),
+		}
+		if !config.IsExact() {
+			accepted.ExactScore = ""
+		}
+		repo = s.repository.WithOutbox(accepted)
+	}
+	var lbFn func() (domain.ScoreUpdate, error)
+	switch {
+	case len(config.Components) > 0:
+		if components == nil {
+			return reject(&domain.InvalidScoreError{Name: name, Err: fmt.Errorf("scores must be reported with components")})
+		}
+		composite, err := config.Components.Encode(components)
+		if err != nil {
+			return reject(&domain.InvalidScoreError{Name: name, Err: err})
+		}
+		lbFn = func() (domain.ScoreUpdate, error) {
+			return repo.CompositeWithMetadata(entryID, leaderboard, composite, config.Function, meta)
+		}
+	case components != nil:
+		return reject(&domain.InvalidScoreError{Name: name, Err: fmt.Errorf("leaderboard does not have components")})
+	case config.IsExact():
+		exact, err := config.ParseScore(decimal)
+		if err != nil {
+			return reject(&domain.InvalidScoreError{Name: name, Err: err})
+		}
+		lbFn = func() (domain.ScoreUpdate, error) {
+			return repo.ExactWithMetadata(entryID, leaderboard, exact, config.Function, meta)
+		}
+	default:
+		score, err := strconv.ParseFloat(decimal, 64)
+		if err != nil {
+			return reject(&domain.InvalidScoreError{Name: name, Err: fmt.Errorf("score must be a number: %v", decimal)})
+		}
+		lbFn = applyFunction(repo, entryID, leaderboard, score, config, epoch, now, meta)
+	}
+	var previous *entryStanding
+	if s.events != nil {
+		previous, err = s.standingOf(entryID, leaderboard, config)
+		if err != nil && s.log != nil {
+			_ = s.log.Error("failed to fetch standing of %v in %v: %v", entryID, leaderboard, err)
+		}
+	}
 	v, err := lbFn()
 	if err != nil {
 		return domain.ReportScoreOutput{}, fmt.Errorf("failed to apply functoin to the  score: %v", err)
 	}
 
 	if v.Done {
-		// Global scoreboard
-		err = s.scoreboard.AddScore(entryID, leaderboard, v.Score)
-		if err != nil {
-			return domain.ReportScoreOutput{}, fmt.Errorf("failed to add score to scoreboard: %v", err)
-		}
-		// add to other scoreboards
-		if config.Scoreboards != nil && len(config.Scoreboards) > 0 {
-			for _, sb := range config.Scoreboards {
-				// TODO: we may enforce to exist the config fields in the meta for correctness
-				lb := s.sbNameFromType(name, epoch, sb, meta[sb.Field])
-				err = s.scoreboard.AddScore(entryID, lb, v.Score)
-				if err != nil {
-					return domain.ReportScoreOutput{}, fmt.Errorf("failed to add score to scoreboard: %v", err)
-				}
+		err = s.addToScoreboards(entryID, name, epoch, config, meta, v.Score, v.ExactScore)
+		if err != nil {
+			return domain.ReportScoreOutput{}, err
+		}
+		// the score is stored, so a failed count is not retried by failing the report
+		err = s.repository.IncrementSubmissions(leaderboard)
+		if err != nil && s.log != nil {
+			_ = s.log.Error("failed to increment submissions of %v: %v", leaderboard, err)
+		}
+		v.Score = decayAt(config, epoch, now)(v.Score)
+		if config.IsExact() {
+			exact, err := config.FormatScore(v.ExactScore)
+			if err != nil {
+				return domain.ReportScoreOutput{}, fmt.Errorf("failed to format score: %v", err)
 			}
+			v.ExactScore = exact.Value
+		}
+	}
+	if s.events != nil {
+		event := domain.Event{
+			Leaderboard: name,
+			Epoch:       epoch,
+			EntryID:     entryID,
+			Score:       v.Score,
+			ExactScore:  v.ExactScore,
+			Metadata:    meta,
+			At:          accepted.At,
+		}
+		if v.Done {
+			events := s.scoreEvents(event, config, leaderboard, previous, decayAt(config, epoch, now))
+			events[0].ID = accepted.ID
+			publishEvents(s.events, s.log, events...)
+		} else {
+			event.Type = domain.ScoreRejected
+			event.Score = reportedScore(decimal)
+			event.ExactScore = ""
+			event.Reason = domain.RejectedNotImproved
+			publishEvents(s.events, s.log, event)
 		}
 	}
 
-	return domain.ReportScoreOutput{Update: v, Epoch: epoch}, nil
+	return domain.ReportScoreOutput{Update: v, Epoch: newEpochInfo(config.CronExpression, epoch)}, nil
+}
+
+// addToScoreboards sets the score of an entry in the global scoreboard and in the scoreboards of
+// its metadata, the scores of exact leaderboards are set from the stored decimal
+func (s *LeaderboardsService) addToScoreboards(entryID string, name string, epoch int64, config domain.LeaderboardConfig, meta domain.Metadata, score float64, exact string) error {
+	add := func(board string) error {
+		return s.scoreboard.AddScore(entryID, board, score)
+	}
+	if config.IsExact() {
+		value, err := config.FormatScore(exact)
+		if err != nil {
+			return fmt.Errorf("failed to format score: %v", err)
+		}
+		add = func(board string) error {
+			return s.scoreboard.AddExactScore(entryID, board, value)
+		}
+	}
+	// Global scoreboard
+	err := add(getNameWithEpoch(name, epoch))
+	if err != nil {
+		return fmt.Errorf("failed to add score to scoreboard: %v", err)
+	}
+	// add to other scoreboards
+	for _, sb := range config.Scoreboards {
+		// TODO: we may enforce to exist the config fields in the meta for correctness
+		lb := sbNameFromType(name, epoch, sb, meta[sb.Field])
+		err = add(lb)
+		if err != nil {
+			return fmt.Errorf("failed to add score to scoreboard: %v", err)
+		}
+	}
+	return nil
 }
 
-func (s *LeaderboardsService) sbNameFromType(lb string, epoch int64, sb domain.LeaderboardScoreBoardConfig, value string) string {
+func sbNameFromType(lb string, epoch int64, sb domain.LeaderboardScoreBoardConfig, value string) string {
 	name := fmt.Sprintf("%s::%d", lb, epoch)
 	switch sb.Type {
 	case domain.League:
@@ -101,23 +303,33 @@ func (s *LeaderboardsService) sbNameFromType(lb string, epoch int64, sb domain.L
 	return strings.ToLower(name)
 }
 
-func (s *LeaderboardsService) applyFunction(entryID string, leaderboard string, score float64, configFunction domain.LeaderboardFunctionType, meta domain.Metadata) func() (domain.ScoreUpdate, error) {
+// applyFunction returns the write of a score with the function of the leaderboard
+func applyFunction(repo ports.Repository, entryID string, leaderboard string, score float64, config domain.LeaderboardConfig, epoch int64, now time.Time, meta domain.Metadata) func() (domain.ScoreUpdate, error) {
 	lbFn := func() (domain.ScoreUpdate, error) {
-		return s.repository.AddWithMetadata(entryID, leaderboard, score, meta)
+		return repo.AddWithMetadata(entryID, leaderboard, score, meta)
 	}
 
-	switch configFunction {
+	switch config.Function {
 	case domain.Max:
 		lbFn = func() (domain.ScoreUpdate, error) {
-			return s.repository.MaxWithMetadata(entryID, leaderboard, score, meta)
+			return repo.MaxWithMetadata(entryID, leaderboard, score, meta)
 		}
 	case domain.Min:
 		lbFn = func() (domain.ScoreUpdate, error) {
-			return s.repository.MinWithMetadata(entryID, leaderboard, score, meta)
+			return repo.MinWithMetadata(entryID, leaderboard, score, meta)
 		}
 	case domain.Last:
 		lbFn = func() (domain.ScoreUpdate, error) {
-			return s.repository.LastWithMetadata(entryID, leaderboard, score, meta)
+			return repo.LastWithMetadata(entryID, leaderboard, score, meta)
+		}
+	case domain.Decay:
+		lbFn = func() (domain.ScoreUpdate, error) {
+			value := domain.DecayedScore{
+				Raw:   score,
+				At:    now,
+				Score: config.Decay.Encode(score, now, config.CronExpression.GetEpochStart(epoch)),
+			}
+			return repo.DecayWithMetadata(entryID, leaderboard, value, meta)
 		}
 	}
 	return lbFn
@@ -125,19 +337,29 @@ func (s *LeaderboardsService) applyFunction(entryID string, leaderboard string,
 }
 
 // ListScoresWithMetadata returns a list of scores from leaderboards with metadata
-func (s *LeaderboardsService) ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, int64, error) {
+func (s *LeaderboardsService) ListScoresWithMetadata(name string, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
 	config, err := s.GetConfig(name)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch configs: %v", err)
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return nil, domain.EpochInfo{}, err
 	}
-	leaderboard, epoch, err := GetLeaderboardNameWithEpoch(name, config.CronExpression)
+	leaderboard, epoch, err := s.getLeaderboardNameWithEpoch(name, config)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to generate name from configs: %v", err)
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
 	}
 
-	scores, err := s.scoreboard.Get(leaderboard)
+	scores, err := s.scoreboard.Get(leaderboard, config.Order())
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch scores: %v", err)
+	}
+	decay := decayAt(config, epoch, time.Now())
+	components := componentsOf(config)
+	exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch scores: %v", err)
+		return nil, domain.EpochInfo{}, err
 	}
 	var allLeaderboardScores []domain.LeaderboardScores
 
@@ -145,58 +367,86 @@ func (s *LeaderboardsService) ListScoresWithMetadata(name string, meta domain.Me
 	resultScores.Name = leaderboard
 	for _, score := range scores {
 		resultScores.Scores = append(resultScores.Scores, domain.LeaderboardEntry{
-			EntryID: score.EntryID,
-			Score:   score.Score,
-			Rank:    score.Rank,
+			EntryID:    score.EntryID,
+			Score:      decay(score.Score),
+			Rank:       score.Rank,
+			Components: components(score.Score),
+			ExactScore: exact[score.EntryID],
 		})
 	}
 	allLeaderboardScores = append(allLeaderboardScores, resultScores)
 
 	if config.Scoreboards != nil && len(config.Scoreboards) > 0 {
 		for _, sb := range config.Scoreboards {
-			lb := s.sbNameFromType(name, epoch, sb, meta[sb.Field])
-			scores, err := s.scoreboard.Get(lb)
+			lb := sbNameFromType(name, epoch, sb, meta[sb.Field])
+			scores, err := s.scoreboard.Get(lb, config.Order())
 			if err != nil {
-				return nil, 0, fmt.Errorf("failed to fetch scores for scoreboard: %v: %v", lb, err)
+				return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch scores for scoreboard: %v: %v", lb, err)
+			}
+			exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
+			if err != nil {
+				return nil, domain.EpochInfo{}, err
 			}
 			resultScores := domain.LeaderboardScores{}
 			resultScores.Name = lb
 			for _, score := range scores {
 				resultScores.Scores = append(resultScores.Scores, domain.LeaderboardEntry{
-					EntryID: score.EntryID,
-					Score:   score.Score,
-					Rank:    score.Rank,
+					EntryID:    score.EntryID,
+					Score:      decay(score.Score),
+					Rank:       score.Rank,
+					Components: components(score.Score),
+					ExactScore: exact[score.EntryID],
 				})
 			}
 			allLeaderboardScores = append(allLeaderboardScores, resultScores)
 		}
 	}
-	return allLeaderboardScores, epoch, nil
+	return allLeaderboardScores, newEpochInfo(config.CronExpression, epoch), nil
 
 }
 
 // ListScores returns a list of scores from leaderboards
-func (s *LeaderboardsService) ListScores(name string) ([]domain.LeaderboardScores, int64, error) {
+func (s *LeaderboardsService) ListScores(name string) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
 	return s.ListScoresWithMetadata(name, nil)
 }
 
 // GetResults returns a list of scores from leaderboards
-func (s *LeaderboardsService) GetResults(name string, epoch int64) ([]domain.LeaderboardScores, error) {
+func (s *LeaderboardsService) GetResults(name string, epoch int64) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
 	return s.GetResultsWithMetadata(name, epoch, nil)
 }
 
 // GetResultsWithMetadata returns a list of scores from leaderboards
-func (s *LeaderboardsService) GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, error) {
+func (s *LeaderboardsService) GetResultsWithMetadata(name string, epoch int64, meta domain.Metadata) ([]domain.LeaderboardScores, domain.EpochInfo, error) {
 	config, err := s.GetConfig(name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch configs: %v", err)
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return nil, domain.EpochInfo{}, err
+	}
+	if s.archive != nil {
+		names := archivedScoreboards(name, epoch, config, meta)
+		archive, found, err := s.archive.GetArchive(name, epoch, names, s.scoreboard.TopN())
+		if err != nil {
+			return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch archive: %v", err)
+		}
+		if found {
+			return archivedResults(archive, names), newEpochInfo(config.CronExpression, epoch), nil
+		}
 	}
 	allResults := []domain.LeaderboardScores{}
+	decay := decayAt(config, epoch, time.Now())
+	components := componentsOf(config)
 
 	leaderboard := getNameWithEpoch(name, epoch)
-	scores, err := s.scoreboard.Get(leaderboard)
+	scores, err := s.scoreboard.Get(leaderboard, config.Order())
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch scores: %v", err)
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch scores: %v", err)
+	}
+	exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
+	if err != nil {
+		return nil, domain.EpochInfo{}, err
 	}
 
 	// TODO: should get the score boards
@@ -204,28 +454,36 @@ func (s *LeaderboardsService) GetResultsWithMetadata(name string, epoch int64, m
 	resultScores.Name = leaderboard
 	for _, score := range scores {
 		resultScores.Scores = append(resultScores.Scores, domain.LeaderboardEntry{
-			EntryID: score.EntryID,
-			Score:   score.Score,
-			Rank:    score.Rank,
+			EntryID:    score.EntryID,
+			Score:      decay(score.Score),
+			Rank:       score.Rank,
+			Components: components(score.Score),
+			ExactScore: exact[score.EntryID],
 		})
 	}
 	allResults = append(allResults, resultScores)
 
 	if config.Scoreboards != nil && len(config.Scoreboards) > 0 {
 		for _, sb := range config.Scoreboards {
-			leaderboard = s.sbNameFromType(name, epoch, sb, meta[sb.Field])
-			scores, err := s.scoreboard.Get(leaderboard)
+			lb := sbNameFromType(name, epoch, sb, meta[sb.Field])
+			scores, err := s.scoreboard.Get(lb, config.Order())
+			if err != nil {
+				return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch scores: %v", err)
+			}
+			exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
 			if err != nil {
-				return nil, fmt.Errorf("failed to fetch scores: %v", err)
+				return nil, domain.EpochInfo{}, err
 			}
 
 			resultScores := domain.LeaderboardScores{}
-			resultScores.Name = leaderboard
+			resultScores.Name = lb
 			for _, score := range scores {
 				resultScores.Scores = append(resultScores.Scores, domain.LeaderboardEntry{
-					EntryID: score.EntryID,
-					Score:   score.Score,
-					Rank:    score.Rank,
+					EntryID:    score.EntryID,
+					Score:      decay(score.Score),
+					Rank:       score.Rank,
+					Components: components(score.Score),
+					ExactScore: exact[score.EntryID],
 				})
 			}
 
@@ -234,7 +492,486 @@ func (s *LeaderboardsService) GetResultsWithMetadata(name string, epoch int64, m
 
 	}
 
-	return allResults, nil
+	return allResults, newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// GetFriendsScores returns the scores of an entry and its friends ranked relative to each other.
+// When no friends are given the stored friend list of the entry is used and when the epoch is
+// not positive the current epoch is used
+func (s *LeaderboardsService) GetFriendsScores(entryID string, name string, epoch int64, friends []string) (domain.LeaderboardScores, domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.LeaderboardScores{}, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return domain.LeaderboardScores{}, domain.EpochInfo{}, err
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.LeaderboardScores{}, domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+
+	if len(friends) == 0 {
+		friends, err = s.repository.GetFriends(entryID)
+		if err != nil {
+			return domain.LeaderboardScores{}, domain.EpochInfo{}, fmt.Errorf("failed to fetch friends: %v", err)
+		}
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	scores, err := s.scoreboard.GetScores(leaderboard, uniqueEntries(append([]string{entryID}, friends...)))
+	if err != nil {
+		return domain.LeaderboardScores{}, domain.EpochInfo{}, fmt.Errorf("failed to fetch scores: %v", err)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if config.Order() == domain.Ascending {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].Score > scores[j].Score
+	})
+
+	decay := decayAt(config, epoch, time.Now())
+	components := componentsOf(config)
+	exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
+	if err != nil {
+		return domain.LeaderboardScores{}, domain.EpochInfo{}, err
+	}
+	resultScores := domain.LeaderboardScores{}
+	resultScores.Name = leaderboard
+	for i, score := range scores {
+		resultScores.Scores = append(resultScores.Scores, domain.LeaderboardEntry{
+			EntryID:    score.EntryID,
+			Score:      decay(score.Score),
+			Rank:       int64(i + 1),
+			Components: components(score.Score),
+			ExactScore: exact[score.EntryID],
+		})
+	}
+	return resultScores, newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// GetStandingsWithMetadata returns the rank, total of entries and percentiles of an entry in the
+// global scoreboard and in each configured scoreboard of the current epoch
+func (s *LeaderboardsService) GetStandingsWithMetadata(entryID string, name string, meta domain.Metadata, nextBand bool) ([]domain.LeaderboardStanding, domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return nil, domain.EpochInfo{}, err
+	}
+	leaderboard, epoch, err := s.getLeaderboardNameWithEpoch(name, config)
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+	}
+
+	names := []string{leaderboard}
+	for _, sb := range config.Scoreboards {
+		names = append(names, sbNameFromType(name, epoch, sb, meta[sb.Field]))
+	}
+
+	decay := decayAt(config, epoch, time.Now())
+	components := componentsOf(config)
+	exact, err := exactScoresOf(s.repository, config, leaderboard, []domain.ScoreboardResult{{EntryID: entryID}})
+	if err != nil {
+		return nil, domain.EpochInfo{}, err
+	}
+	standings := []domain.LeaderboardStanding{}
+	for _, lb := range names {
+		st, err := s.scoreboard.GetStanding(lb, entryID, nextBand, config.Order())
+		if err != nil {
+			return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch standing for scoreboard: %v: %v", lb, err)
+		}
+		standings = append(standings, domain.LeaderboardStanding{
+			Name:               lb,
+			EntryID:            st.EntryID,
+			Score:              decay(st.Score),
+			Rank:               st.Rank,
+			Total:              st.Total,
+			TopPercent:         st.TopPercent,
+			Percentile:         st.Percentile,
+			NextBandTopPercent: st.NextBandTopPercent,
+			NextBandScore:      decay(st.NextBandScore),
+			Components:         components(st.Score),
+			ExactScore:         exact[st.EntryID],
+		})
+	}
+	return standings, newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// GetEpochInfo returns the timing of an epoch of a leaderboard, when the epoch is not positive
+// the current epoch is used
+func (s *LeaderboardsService) GetEpochInfo(name string, epoch int64) (domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+	return newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// GetStats returns the aggregated statistics of the global scoreboard of a leaderboard epoch,
+// the buckets override the configured histogram buckets and results are cached for a short period
+func (s *LeaderboardsService) GetStats(name string, epoch int64, buckets []float64) (domain.LeaderboardStats, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.LeaderboardStats{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	err = checkReadable(name, config)
+	if err != nil {
+		return domain.LeaderboardStats{}, err
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.LeaderboardStats{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+	if len(buckets) == 0 {
+		buckets = config.StatsBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		return domain.LeaderboardStats{}, fmt.Errorf("histogram buckets must be sorted: %v", buckets)
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	cacheKey := fmt.Sprintf("%s::%v", leaderboard, buckets)
+	if stats, ok := s.statsCache.Get(cacheKey); ok {
+		return stats, nil
+	}
+
+	// decayed scores are stored normalised so the buckets are normalised to query them
+	now := time.Now()
+	decay := decayAt(config, epoch, now)
+	sbBuckets := buckets
+	if config.Function == domain.Decay {
+		at, ref := decayTimes(config, epoch, now)
+		sbBuckets = make([]float64, len(buckets))
+		for i, b := range buckets {
+			sbBuckets[i] = config.Decay.Encode(b, at, ref)
+		}
+	}
+	sbStats, err := s.scoreboard.GetStats(leaderboard, sbBuckets)
+	if err != nil {
+		return domain.LeaderboardStats{}, fmt.Errorf("failed to fetch scoreboard stats: %v", err)
+	}
+	if config.Function == domain.Decay {
+		for i := range sbStats.Histogram {
+			sbStats.Histogram[i] = histogramBucket(buckets, i, sbStats.Histogram[i].Count)
+		}
+	}
+	submissions, err := s.repository.GetSubmissions(leaderboard)
+	if err != nil {
+		return domain.LeaderboardStats{}, fmt.Errorf("failed to fetch submissions: %v", err)
+	}
+
+	stats := domain.LeaderboardStats{
+		Name:         leaderboard,
+		Epoch:        epoch,
+		Participants: sbStats.Participants,
+		Submissions:  submissions,
+		Min:          decay(sbStats.Min),
+		Max:          decay(sbStats.Max),
+		Mean:         decay(sbStats.Mean),
+		Median:       decay(sbStats.Median),
+		Histogram:    sbStats.Histogram,
+	}
+	s.statsCache.Set(cacheKey, stats)
+	return stats, nil
+}
+
+// AdvanceEpoch starts the next epoch of a manually reset leaderboard
+func (s *LeaderboardsService) AdvanceEpoch(name string) (domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	if !config.CronExpression.IsManual() {
+		return domain.EpochInfo{}, fmt.Errorf("leaderboard %s is not reset manually", name)
+	}
+	epoch, err := s.repository.AdvanceEpoch(strings.ToLower(name))
+	if err != nil {
+		return domain.EpochInfo{}, fmt.Errorf("failed to advance epoch: %v", err)
+	}
+	return newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// TransitionLifecycle moves a leaderboard to a lifecycle state and records it in the audit log,
+// the activation time is only used when scheduling
+func (s *LeaderboardsService) TransitionLifecycle(name string, to domain.LeaderboardState, activateAt *time.Time, actor string, reason string) (domain.LeaderboardLifecycle, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.LeaderboardLifecycle{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	now := time.Now().UTC()
+	lifecycle, err := config.Lifecycle.Transition(to, now, activateAt)
+	if err != nil {
+		return domain.LeaderboardLifecycle{}, &domain.LifecycleTransitionError{Name: name, Err: err}
+	}
+	audit := domain.LifecycleAudit{
+		Name:       name,
+		From:       config.Lifecycle.StateAt(now),
+		To:         to,
+		ActivateAt: lifecycle.ActivateAt,
+		Actor:      actor,
+		Reason:     reason,
+		At:         now,
+	}
+	err = s.repository.TransitionLifecycle(name, lifecycle, audit)
+	if err != nil {
+		return domain.LeaderboardLifecycle{}, fmt.Errorf("failed to store lifecycle: %v", err)
+	}
+	return lifecycle, nil
+}
+
+// GetLifecycleAudit returns the lifecycle transitions of a leaderboard
+func (s *LeaderboardsService) GetLifecycleAudit(name string) ([]domain.LifecycleAudit, error) {
+	_, err := s.GetConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	audit, err := s.repository.GetLifecycleAudit(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lifecycle audit: %v", err)
+	}
+	return audit, nil
+}
+
+// MigrateLegacyEpochs moves the scoreboards and entries of legacy epochs to the epochs counted
+// from the anchor, when no epochs are given the current legacy epoch is migrated. Scoreboards and
+// entries that already exist in the new epoch are left in place and reported as conflicts
+func (s *LeaderboardsService) MigrateLegacyEpochs(name string, legacyEpochs []int64) ([]domain.EpochMigration, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	now := time.Now()
+	if len(legacyEpochs) == 0 {
+		legacyEpochs = []int64{config.CronExpression.GetLegacyEpochFromReferenceUnixTimestamp(now.Unix())}
+	}
+
+	migrations := []domain.EpochMigration{}
+	for _, legacy := range legacyEpochs {
+		epoch := config.CronExpression.MigrateLegacyEpoch(legacy, now)
+		migration := domain.EpochMigration{LegacyEpoch: legacy, Epoch: epoch, Scoreboards: []string{}}
+		if epoch == legacy {
+			migrations = append(migrations, migration)
+			continue
+		}
+
+		from := getNameWithEpoch(name, legacy)
+		to := getNameWithEpoch(name, epoch)
+		boards, err := s.scoreboard.Keys(from)
+		if err != nil {
+			return migrations, fmt.Errorf("failed to list scoreboards of epoch %d: %v", legacy, err)
+		}
+		for _, sb := range config.Scoreboards {
+			keys, err := s.scoreboard.Keys(sbNameFromType(name, legacy, sb, "*"))
+			if err != nil {
+				return migrations, fmt.Errorf("failed to list scoreboards of epoch %d: %v", legacy, err)
+			}
+			boards = append(boards, keys...)
+		}
+		for _, board := range boards {
+			suffix := fmt.Sprintf("::%d", legacy)
+			renamed, err := s.scoreboard.Rename(board, strings.TrimSuffix(board, suffix)+fmt.Sprintf("::%d", epoch))
+			if err != nil {
+				return migrations, fmt.Errorf("failed to migrate scoreboard %v: %v", board, err)
+			}
+			if renamed {
+				migration.Scoreboards = append(migration.Scoreboards, board)
+			} else {
+				migration.Conflicts++
+			}
+		}
+
+		// the entries are read from both epochs so the records left behind by a scoreboard
+		// renamed in a previous migration are moved too
+		entries, err := s.boardEntries([]string{from, to}, config.Order())
+		if err != nil {
+			return migrations, fmt.Errorf("failed to list entries of epoch %d: %v", legacy, err)
+		}
+		moved, conflicts, err := s.repository.MigrateEntries(from, to, entries)
+		if err != nil {
+			return migrations, fmt.Errorf("failed to migrate entries of epoch %d: %v", legacy, err)
+		}
+		migration.Entries = moved
+		migration.Conflicts += conflicts
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// boardEntries returns the entries of the scoreboards without duplicates
+func (s *LeaderboardsService) boardEntries(boards []string, order domain.SortOrder) ([]string, error) {
+	entries := []string{}
+	for _, board := range boards {
+		for start := int64(0); ; start += migratePageSize {
+			scores, err := s.scoreboard.GetRange(board, start, start+migratePageSize-1, order)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch scores of %v: %v", board, err)
+			}
+			for _, score := range scores {
+				entries = append(entries, score.EntryID)
+			}
+			if len(scores) < migratePageSize {
+				break
+			}
+		}
+	}
+	return uniqueEntries(entries), nil
+}
+
+// SetFriends stores the friend list of an entry
+func (s *LeaderboardsService) SetFriends(entryID string, friends []string) error {
+	list := []string{}
+	for _, f := range uniqueEntries(friends) {
+		if f != entryID {
+			list = append(list, f)
+		}
+	}
+	err := s.repository.SetFriends(entryID, list)
+	if err != nil {
+		return fmt.Errorf("failed to store friends: %v", err)
+	}
+	return nil
+}
+
+func uniqueEntries(entries []string) []string {
+	seen := make(map[string]struct{}, len(entries))
+	unique := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := seen[e]; ok || e == "" {
+			continue
+		}
+		seen[e] = struct{}{}
+		unique = append(unique, e)
+	}
+	return unique
+}
+
+// newEpochInfo returns the timing of an epoch along with the next reset and the server time
+func newEpochInfo(reset domain.CronExpression, epoch int64) domain.EpochInfo {
+	now := time.Now().UTC()
+	return domain.EpochInfo{
+		Epoch:      epoch,
+		Start:      reset.GetEpochStart(epoch),
+		End:        reset.GetEpochEnd(epoch),
+		NextReset:  reset.GetNexFromRefUTC(now),
+		ServerTime: now,
+	}
+}
+
+// histogramBucket returns the bucket at an index of the histogram of the boundaries, where the
+// first and last buckets are unbounded
+func histogramBucket(boundaries []float64, i int, count int64) domain.HistogramBucket {
+	b := domain.HistogramBucket{Count: count}
+	if i > 0 {
+		b.From = &boundaries[i-1]
+	}
+	if i < len(boundaries) {
+		b.To = &boundaries[i]
+	}
+	return b
+}
+
+// exactScoresOf returns the exact decimal scores of the entries of a scoreboard, read from the
+// leaderboard epoch where they are stored. It returns nil for leaderboards with float scores
+func exactScoresOf(repository ports.Repository, config domain.LeaderboardConfig, leaderboard string, scores []domain.ScoreboardResult) (map[string]string, error) {
+	if !config.IsExact() || len(scores) == 0 {
+		return nil, nil
+	}
+	entries := make([]string, 0, len(scores))
+	for _, score := range scores {
+		entries = append(entries, score.EntryID)
+	}
+	stored, err := repository.GetExactScores(leaderboard, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exact scores: %v", err)
+	}
+	exact := make(map[string]string, len(stored))
+	for entry, v := range stored {
+		value, err := config.FormatScore(v)
+		if err != nil {
+			return nil, err
+		}
+		exact[entry] = value.Value
+	}
+	return exact, nil
+}
+
+// componentsOf returns the function that unpacks the components of the scoreboard values, it
+// returns nil for leaderboards without components
+func componentsOf(config domain.LeaderboardConfig) func(float64) []float64 {
+	if len(config.Components) == 0 {
+		return func(float64) []float64 { return nil }
+	}
+	return config.Components.Decode
+}
+
+// decayAt returns the function that converts the scoreboard values of an epoch to their value at
+// a time, the values of leaderboards without decay are returned as they are. Linear decay is
+// clamped per entry so the converted mean of an epoch is an approximation
+func decayAt(config domain.LeaderboardConfig, epoch int64, now time.Time) func(float64) float64 {
+	if config.Function != domain.Decay || config.Decay == nil {
+		return func(v float64) float64 { return v }
+	}
+	at, ref := decayTimes(config, epoch, now)
+	return func(v float64) float64 {
+		return config.Decay.Decode(v, at, ref)
+	}
+}
+
+// decayTimes returns the time values of an epoch decay to, which stops at the end of the epoch,
+// and the start of the epoch the values are normalised to
+func decayTimes(config domain.LeaderboardConfig, epoch int64, now time.Time) (time.Time, time.Time) {
+	at := now
+	end := config.CronExpression.GetEpochEnd(epoch)
+	if !end.IsZero() && end.Before(at) {
+		at = end
+	}
+	return at, config.CronExpression.GetEpochStart(epoch)
+}
+
+// checkReadable returns an error when the lifecycle state of a leaderboard does not allow reads
+func checkReadable(name string, config domain.LeaderboardConfig) error {
+	now := time.Now()
+	if !config.Lifecycle.IsReadable(now) {
+		return &domain.LeaderboardStateError{Name: name, State: config.Lifecycle.StateAt(now), Operation: "reads"}
+	}
+	return nil
+}
+
+// checkWritable returns an error when the lifecycle state of a leaderboard does not allow scores
+func checkWritable(name string, config domain.LeaderboardConfig) error {
+	now := time.Now()
+	if !config.Lifecycle.IsWritable(now) {
+		return &domain.LeaderboardStateError{Name: name, State: config.Lifecycle.StateAt(now), Operation: "scores"}
+	}
+	return nil
+}
+
+// getLeaderboardNameWithEpoch returns the name and the current epoch of a leaderboard, read from
+// the repository when the leaderboard is reset manually
+func (s *LeaderboardsService) getLeaderboardNameWithEpoch(name string, config domain.LeaderboardConfig) (string, int64, error) {
+	if !config.CronExpression.IsManual() {
+		return GetLeaderboardNameWithEpoch(name, config.CronExpression)
+	}
+	epoch, err := s.repository.GetEpoch(strings.ToLower(name))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch current epoch: %v", err)
+	}
+	return getNameWithEpoch(name, epoch), epoch, nil
 }
 
 func GetLeaderboardNameWithEpoch(name string, reset domain.CronExpression) (string, int64, error) {
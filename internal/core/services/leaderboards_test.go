@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
 	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/core/ports/mocks"
 	"github.com/posilva/simpleboards/internal/testutil"
@@ -36,12 +38,42 @@ func TestReportScore(t *testing.T) {
 	assert.NoError(t, err)
 	repo.EXPECT().AddWithMetadata(entryID, nameEpoch, value, nil).Return(domain.ScoreUpdate{Score: value, Done: true}, nil)
 	scoreboard.EXPECT().AddScore(entryID, nameEpoch, value).Return(nil)
+	repo.EXPECT().IncrementSubmissions(nameEpoch).Return(nil)
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
 	v, err := lbSrv.ReportScore(entryID, lbName, value)
 	assert.NoError(t, err)
 	assert.Nil(t, nil)
 	assert.Equal(t, value, v.Update.Score)
+	assert.True(t, v.Epoch.NextReset.After(v.Epoch.ServerTime))
+	assert.Equal(t, v.Epoch.End, v.Epoch.NextReset)
+}
+
+func TestReportScoreSubmissionsFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	value := 100.0
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	configProvider := defaultConfigProviderMock(ctrl, lbName)
+	ce, err := domain.NewCronExpression(domain.ResetExpression{Type: domain.Hourly})
+	assert.NoError(t, err)
+	nameEpoch, _, err := GetLeaderboardNameWithEpoch(lbName, ce)
+	assert.NoError(t, err)
+
+	// a failed submissions count does not fail the stored score, so a retry does not apply it twice
+	repo.EXPECT().AddWithMetadata(entryID, nameEpoch, value, nil).Return(domain.ScoreUpdate{Score: value, Done: true}, nil)
+	scoreboard.EXPECT().AddScore(entryID, nameEpoch, value).Return(nil)
+	repo.EXPECT().IncrementSubmissions(nameEpoch).Return(fmt.Errorf("throttled"))
+	lbSrv := NewLeaderboardsServiceWithOptions(repo, scoreboard, configProvider, LeaderboardsOptions{Logger: logging.NewSimpleLogger()})
+
+	v, err := lbSrv.ReportScore(entryID, lbName, value)
+	assert.NoError(t, err)
+	assert.Equal(t, value, v.Update.Score)
 }
 
 func TestReportScoreWithScoreboards(t *testing.T) {
@@ -64,6 +96,7 @@ func TestReportScoreWithScoreboards(t *testing.T) {
 	assert.NoError(t, err)
 	repo.EXPECT().AddWithMetadata(entryID, gomock.Any(), value, nil).Return(domain.ScoreUpdate{Score: value, Done: true}, nil).AnyTimes()
 	scoreboard.EXPECT().AddScore(entryID, gomock.Any(), value).Return(nil).AnyTimes()
+	repo.EXPECT().IncrementSubmissions(gomock.Any()).Return(nil)
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
 	v, err := lbSrv.ReportScore(entryID, lbName, value)
@@ -72,6 +105,276 @@ func TestReportScoreWithScoreboards(t *testing.T) {
 	assert.Equal(t, value, v.Update.Score)
 }
 
+func TestReportScoreClosedWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+
+	r := domain.ResetExpression{Type: domain.Window, Start: "2024-07-01", End: "2024-07-08"}
+	ce, err := domain.NewCronExpression(r)
+	assert.NoError(t, err)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.ResetExpression = r
+	config.CronExpression = ce
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil)
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	_, err = lbSrv.ReportScore(testutil.NewID(), lbName, 100.0)
+	var closed *domain.LeaderboardClosedError
+	assert.ErrorAs(t, err, &closed)
+	assert.Equal(t, time.Date(2024, time.July, 8, 0, 0, 0, 0, time.UTC), closed.End)
+}
+
+func TestReportScoreManualEpoch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	value := 100.0
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{
+		lbName: testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Manually, domain.Sum),
+	}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	nameEpoch := strings.ToLower(lbName) + "::3"
+	repo.EXPECT().GetEpoch(strings.ToLower(lbName)).Return(int64(3), nil)
+	repo.EXPECT().AddWithMetadata(entryID, nameEpoch, value, nil).Return(domain.ScoreUpdate{Score: value, Done: true}, nil)
+	scoreboard.EXPECT().AddScore(entryID, nameEpoch, value).Return(nil)
+	scoreboard.EXPECT().AddScore(entryID, gomock.Any(), value).Return(nil).Times(2)
+	repo.EXPECT().IncrementSubmissions(nameEpoch).Return(nil)
+
+	v, err := lbSrv.ReportScore(entryID, lbName, value)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), v.Epoch.Epoch)
+	assert.True(t, v.Epoch.NextReset.IsZero())
+
+	repo.EXPECT().AdvanceEpoch(strings.ToLower(lbName)).Return(int64(4), nil)
+	info, err := lbSrv.AdvanceEpoch(lbName)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), info.Epoch)
+}
+
+func TestAdvanceEpochNotManual(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), defaultConfigProviderMock(ctrl, lbName))
+
+	_, err := lbSrv.AdvanceEpoch(lbName)
+	assert.Error(t, err)
+}
+
+func lifecycleConfigProviderMock(ctrl *gomock.Controller, lbName string, state domain.LeaderboardState) *mocks.MockConfigProvider {
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Lifecycle = domain.LeaderboardLifecycle{State: state}
+	cp := mocks.NewMockConfigProvider(ctrl)
+	cp.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	return cp
+}
+
+func TestReportScorePaused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	configProvider := lifecycleConfigProviderMock(ctrl, lbName, domain.Paused)
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), configProvider)
+
+	_, err := lbSrv.ReportScore(testutil.NewID(), lbName, 100.0)
+	var state *domain.LeaderboardStateError
+	assert.ErrorAs(t, err, &state)
+	assert.Equal(t, domain.Paused, state.State)
+}
+
+func TestListScoresDraft(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	configProvider := lifecycleConfigProviderMock(ctrl, lbName, domain.Draft)
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), configProvider)
+
+	_, _, err := lbSrv.ListScores(lbName)
+	var state *domain.LeaderboardStateError
+	assert.ErrorAs(t, err, &state)
+}
+
+func TestTransitionLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	configProvider := lifecycleConfigProviderMock(ctrl, lbName, domain.Active)
+	lbSrv := NewLeaderboardsService(repo, mocks.NewMockScoreboard(ctrl), configProvider)
+
+	repo.EXPECT().TransitionLifecycle(lbName, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, lifecycle domain.LeaderboardLifecycle, audit domain.LifecycleAudit) error {
+			assert.Equal(t, domain.Paused, lifecycle.State)
+			assert.Equal(t, domain.Active, audit.From)
+			assert.Equal(t, domain.Paused, audit.To)
+			assert.Equal(t, "ops", audit.Actor)
+			return nil
+		})
+	l, err := lbSrv.TransitionLifecycle(lbName, domain.Paused, nil, "ops", "incident")
+	assert.NoError(t, err)
+	assert.NotNil(t, l.PausedAt)
+
+	_, err = lbSrv.TransitionLifecycle(lbName, domain.Draft, nil, "ops", "")
+	var transition *domain.LifecycleTransitionError
+	assert.ErrorAs(t, err, &transition)
+}
+
+func TestReportScoreDecay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Function = domain.Decay
+	config.Decay = &domain.DecayConfig{Type: domain.ExponentialDecay, HalfLife: "1h"}
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	var stored float64
+	repo.EXPECT().DecayWithMetadata(entryID, gomock.Any(), gomock.Any(), nil).DoAndReturn(
+		func(_ string, _ string, value domain.DecayedScore, _ domain.Metadata) (domain.ScoreUpdate, error) {
+			assert.Equal(t, 100.0, value.Raw)
+			assert.GreaterOrEqual(t, value.Score, value.Raw)
+			stored = value.Score
+			return domain.ScoreUpdate{Score: value.Score, Done: true, Counter: 1}, nil
+		})
+	scoreboard.EXPECT().AddScore(entryID, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, _ string, score float64) error {
+			assert.Equal(t, stored, score)
+			return nil
+		})
+	repo.EXPECT().IncrementSubmissions(gomock.Any()).Return(nil)
+
+	v, err := lbSrv.ReportScore(entryID, lbName, 100.0)
+	assert.NoError(t, err)
+	assert.InDelta(t, 100.0, v.Update.Score, 1e-6)
+
+	scoreboard.EXPECT().Get(gomock.Any(), domain.Descending).DoAndReturn(func(_ string, _ domain.SortOrder) ([]domain.ScoreboardResult, error) {
+		return []domain.ScoreboardResult{{EntryID: entryID, Score: stored, Rank: 1}}, nil
+	})
+	scores, _, err := lbSrv.ListScores(lbName)
+	assert.NoError(t, err)
+	assert.InDelta(t, 100.0, scores[0].Scores[0].Score, 1e-3)
+}
+
+func TestReportComponents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Function = domain.Max
+	config.Components = domain.ScoreComponents{
+		{Name: "laps", Order: domain.Descending, Digits: 3},
+		{Name: "time", Order: domain.Ascending, Digits: 6},
+	}
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	composite, err := config.Components.Encode([]float64{3, 61000})
+	assert.NoError(t, err)
+	repo.EXPECT().CompositeWithMetadata(entryID, gomock.Any(), composite, domain.Max, nil).
+		Return(domain.ScoreUpdate{Score: composite.Score, Done: true, Counter: 1, Components: composite.Components}, nil)
+	scoreboard.EXPECT().AddScore(entryID, gomock.Any(), composite.Score).Return(nil)
+	repo.EXPECT().IncrementSubmissions(gomock.Any()).Return(nil)
+
+	v, err := lbSrv.ReportComponentsWithMetadata(entryID, lbName, []float64{3, 61000}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{3, 61000}, v.Update.Components)
+
+	_, err = lbSrv.ReportScore(entryID, lbName, 10)
+	var invalid *domain.InvalidScoreError
+	assert.ErrorAs(t, err, &invalid)
+	_, err = lbSrv.ReportComponentsWithMetadata(entryID, lbName, []float64{3, 0.5}, nil)
+	assert.ErrorAs(t, err, &invalid)
+
+	scoreboard.EXPECT().Get(gomock.Any(), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: entryID, Score: composite.Score, Rank: 1}}, nil)
+	scores, _, err := lbSrv.ListScores(lbName)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{3, 61000}, scores[0].Scores[0].Components)
+}
+
+func TestReportExactScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test")
+	config.Function = domain.Sum
+	config.ScoreType = domain.FixedPointScore
+	config.ScoreDecimals = 2
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	// totals keep the whole int64 range of units and are ranked by their exact value
+	repo.EXPECT().ExactWithMetadata(entryID, gomock.Any(), domain.ExactScore{Value: "0.20", Score: 0.2}, domain.Sum, nil).
+		Return(domain.ScoreUpdate{Score: 92233720368547758.07, ExactScore: "92233720368547758.07", Done: true, Counter: 2}, nil)
+	scoreboard.EXPECT().AddExactScore(entryID, gomock.Any(), domain.ExactScore{Value: "92233720368547758.07", Score: 92233720368547758.07}).Return(nil)
+	repo.EXPECT().IncrementSubmissions(gomock.Any()).Return(nil)
+
+	v, err := lbSrv.ReportDecimalScoreWithMetadata(entryID, lbName, "0.2", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "92233720368547758.07", v.Update.ExactScore)
+
+	_, err = lbSrv.ReportDecimalScoreWithMetadata(entryID, lbName, "0.125", nil)
+	var invalid *domain.InvalidScoreError
+	assert.ErrorAs(t, err, &invalid)
+
+	scoreboard.EXPECT().Get(gomock.Any(), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: entryID, Score: 92233720368547758.07, Rank: 1}}, nil)
+	repo.EXPECT().GetExactScores(gomock.Any(), []string{entryID}).Return(map[string]string{entryID: "92233720368547758"}, nil)
+	scores, _, err := lbSrv.ListScores(lbName)
+	assert.NoError(t, err)
+	assert.Equal(t, "92233720368547758.00", scores[0].Scores[0].ExactScore)
+}
+
+func TestListScoresAscending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{
+		lbName: testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Min),
+	}, nil)
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), scoreboard, configProvider)
+
+	scoreboard.EXPECT().Get(gomock.Any(), domain.Ascending).Return([]domain.ScoreboardResult{}, nil).Times(3)
+	_, _, err := lbSrv.ListScores(lbName)
+	assert.NoError(t, err)
+}
+
 func TestListScores(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -87,7 +390,7 @@ func TestListScores(t *testing.T) {
 	scoreboard := mocks.NewMockScoreboard(ctrl)
 	configProvider := defaultConfigProviderMock(ctrl, lbName)
 
-	scoreboard.EXPECT().Get(nameEpoch).Return([]domain.ScoreboardResult{}, nil)
+	scoreboard.EXPECT().Get(nameEpoch, domain.Descending).Return([]domain.ScoreboardResult{}, nil)
 
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
@@ -111,7 +414,7 @@ func TestListScoresWithMeta(t *testing.T) {
 	scoreboard := mocks.NewMockScoreboard(ctrl)
 	configProvider := defaultConfigProviderMock(ctrl, lbName)
 
-	scoreboard.EXPECT().Get(nameEpoch).Return([]domain.ScoreboardResult{}, nil)
+	scoreboard.EXPECT().Get(nameEpoch, domain.Descending).Return([]domain.ScoreboardResult{}, nil)
 
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
@@ -138,12 +441,15 @@ func TestGetResults(t *testing.T) {
 	scoreboard := mocks.NewMockScoreboard(ctrl)
 	configProvider := defaultConfigProviderMock(ctrl, lbName)
 
-	scoreboard.EXPECT().Get(nameEpoch).Return([]domain.ScoreboardResult{}, nil)
+	scoreboard.EXPECT().Get(nameEpoch, domain.Descending).Return([]domain.ScoreboardResult{}, nil)
 
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
-	v, err := lbSrv.GetResults(lbName, epoch)
+	v, info, err := lbSrv.GetResults(lbName, epoch)
 	fmt.Println(v)
+	assert.Equal(t, epoch, info.Epoch)
+	assert.Equal(t, ce.GetEpochStart(epoch), info.Start)
+	assert.Equal(t, ce.GetEpochEnd(epoch), info.End)
 	assert.NoError(t, err)
 	assert.Len(t, v, 1)
 	assert.True(t, strings.Contains(v[0].Name, lbName))
@@ -162,11 +468,11 @@ func TestGetResultsWithMetadata(t *testing.T) {
 	scoreboard := mocks.NewMockScoreboard(ctrl)
 	configProvider := defaultConfigProviderMock(ctrl, lbName)
 
-	scoreboard.EXPECT().Get(nameEpoch).Return([]domain.ScoreboardResult{}, nil)
+	scoreboard.EXPECT().Get(nameEpoch, domain.Descending).Return([]domain.ScoreboardResult{}, nil)
 
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
-	v, err := lbSrv.GetResultsWithMetadata(lbName, epoch, domain.Metadata{
+	v, _, err := lbSrv.GetResultsWithMetadata(lbName, epoch, domain.Metadata{
 		"country": "PT",
 		"league":  "gold",
 	})
@@ -190,11 +496,11 @@ func TestGetResultsWithScoreboards(t *testing.T) {
 	scoreboard := mocks.NewMockScoreboard(ctrl)
 	configProvider := defaultConfigProviderMockWithScoreboards(ctrl, lbName)
 
-	scoreboard.EXPECT().Get(gomock.Any()).Return([]domain.ScoreboardResult{}, nil).AnyTimes()
+	scoreboard.EXPECT().Get(gomock.Any(), domain.Descending).Return([]domain.ScoreboardResult{}, nil).AnyTimes()
 
 	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
 
-	v, err := lbSrv.GetResults(lbName, epoch)
+	v, _, err := lbSrv.GetResults(lbName, epoch)
 	assert.NoError(t, err)
 	assert.Len(t, v, 3)
 	assert.True(t, strings.Contains(v[0].Name, lbName))
@@ -217,3 +523,221 @@ func defaultConfigProviderMockWithScoreboards(ctrl *gomock.Controller, lbName st
 	cp.EXPECT().Provide().Return(configMap, nil)
 	return cp
 }
+
+func TestGetFriendsScores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	friend1 := testutil.NewID()
+	friend2 := testutil.NewID()
+	ce, err := domain.NewCronExpression(domain.ResetExpression{Type: domain.Hourly})
+	assert.NoError(t, err)
+	nameEpoch, epoch, err := GetLeaderboardNameWithEpoch(lbName, ce)
+	assert.NoError(t, err)
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	configProvider := defaultConfigProviderMock(ctrl, lbName)
+
+	scoreboard.EXPECT().GetScores(nameEpoch, []string{entryID, friend1, friend2}).Return([]domain.ScoreboardResult{
+		{EntryID: entryID, Score: 10},
+		{EntryID: friend2, Score: 30},
+	}, nil)
+
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	v, e, err := lbSrv.GetFriendsScores(entryID, lbName, 0, []string{friend1, entryID, friend2, friend1})
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, e.Epoch)
+	assert.Equal(t, nameEpoch, v.Name)
+	assert.Len(t, v.Scores, 2)
+	assert.Equal(t, friend2, v.Scores[0].EntryID)
+	assert.Equal(t, int64(1), v.Scores[0].Rank)
+	assert.Equal(t, entryID, v.Scores[1].EntryID)
+	assert.Equal(t, int64(2), v.Scores[1].Rank)
+}
+
+func TestGetFriendsScoresWithStoredFriends(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	friend := testutil.NewID()
+	epoch := int64(10)
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	configProvider := defaultConfigProviderMock(ctrl, lbName)
+
+	repo.EXPECT().GetFriends(entryID).Return([]string{friend}, nil)
+	scoreboard.EXPECT().GetScores(getNameWithEpoch(lbName, epoch), []string{entryID, friend}).Return([]domain.ScoreboardResult{
+		{EntryID: entryID, Score: 10},
+	}, nil)
+
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	v, e, err := lbSrv.GetFriendsScores(entryID, lbName, epoch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, e.Epoch)
+	assert.Len(t, v.Scores, 1)
+	assert.Equal(t, int64(1), v.Scores[0].Rank)
+}
+
+func TestSetFriends(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entryID := testutil.NewID()
+	friend := testutil.NewID()
+
+	repo := mocks.NewMockRepository(ctrl)
+	repo.EXPECT().SetFriends(entryID, []string{friend}).Return(nil)
+
+	lbSrv := NewLeaderboardsService(repo, mocks.NewMockScoreboard(ctrl), mocks.NewMockConfigProvider(ctrl))
+
+	err := lbSrv.SetFriends(entryID, []string{friend, entryID, friend})
+	assert.NoError(t, err)
+}
+
+func TestGetStandingsWithScoreboards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	entryID := testutil.NewID()
+	ce, err := domain.NewCronExpression(domain.ResetExpression{Type: domain.Hourly})
+	assert.NoError(t, err)
+	nameEpoch, epoch, err := GetLeaderboardNameWithEpoch(lbName, ce)
+	assert.NoError(t, err)
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	configProvider := defaultConfigProviderMockWithScoreboards(ctrl, lbName)
+
+	scoreboard.EXPECT().GetStanding(nameEpoch, entryID, false, domain.Descending).Return(domain.NewScoreboardStanding(entryID, 10, 7, 100), nil)
+	scoreboard.EXPECT().GetStanding(gomock.Any(), entryID, false, domain.Descending).Return(domain.NewScoreboardStanding(entryID, 10, 1, 10), nil).Times(2)
+
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	v, e, err := lbSrv.GetStandingsWithMetadata(entryID, lbName, domain.Metadata{"country": "PT", "league": "gold"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, epoch, e.Epoch)
+	assert.Len(t, v, 3)
+	assert.Equal(t, nameEpoch, v[0].Name)
+	assert.Equal(t, float64(7), v[0].TopPercent)
+	assert.Equal(t, int64(100), v[0].Total)
+	assert.Equal(t, float64(10), v[1].TopPercent)
+}
+
+func TestGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	epoch := int64(10)
+	nameEpoch := getNameWithEpoch(lbName, epoch)
+	buckets := []float64{0, 10, 100}
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	cp := mocks.NewMockConfigProvider(ctrl)
+	cp.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{
+		lbName: testutil.NewLeaderboardConfig(lbName, 1, 1, "reward_test"),
+	}, nil).Times(2)
+
+	scoreboard.EXPECT().GetStats(nameEpoch, buckets).Return(domain.ScoreboardStats{
+		Participants: 3,
+		Min:          1,
+		Max:          30,
+		Mean:         12,
+		Median:       5,
+	}, nil)
+	repo.EXPECT().GetSubmissions(nameEpoch).Return(uint64(7), nil)
+
+	lbSrv := NewLeaderboardsService(repo, scoreboard, cp)
+
+	v, err := lbSrv.GetStats(lbName, epoch, buckets)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), v.Participants)
+	assert.Equal(t, uint64(7), v.Submissions)
+	assert.Equal(t, float64(12), v.Mean)
+
+	// the second call is served from the cache
+	v, err = lbSrv.GetStats(lbName, epoch, buckets)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), v.Submissions)
+}
+
+func TestGetStatsUnsortedBuckets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), defaultConfigProviderMock(ctrl, lbName))
+
+	_, err := lbSrv.GetStats(lbName, 1, []float64{10, 0})
+	assert.Error(t, err)
+}
+
+func TestMigrateLegacyEpochs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Monthly, domain.Sum)
+	config.ResetExpression.Anchor = "2024-01-01"
+	ce, err := domain.NewCronExpression(config.ResetExpression)
+	assert.NoError(t, err)
+	config.CronExpression = ce
+	cp := mocks.NewMockConfigProvider(ctrl)
+	cp.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil)
+
+	legacy := config.CronExpression.GetLegacyEpochFromReferenceUnixTimestamp(time.Now().Unix())
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(time.Now().Unix())
+	from := getNameWithEpoch(lbName, legacy)
+	to := getNameWithEpoch(lbName, epoch)
+	league := fmt.Sprintf("%s::league::gold::%d", lbName, legacy)
+
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	scoreboard.EXPECT().Keys(from).Return([]string{from}, nil)
+	scoreboard.EXPECT().Keys(fmt.Sprintf("%s::league::*::%d", lbName, legacy)).Return([]string{league}, nil)
+	scoreboard.EXPECT().Keys(fmt.Sprintf("%s::country::*::%d", lbName, legacy)).Return([]string{}, nil)
+	scoreboard.EXPECT().Rename(from, to).Return(true, nil)
+	scoreboard.EXPECT().Rename(league, fmt.Sprintf("%s::league::gold::%d", lbName, epoch)).Return(false, nil)
+	scoreboard.EXPECT().GetRange(from, int64(0), int64(migratePageSize-1), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: "a"}}, nil)
+	scoreboard.EXPECT().GetRange(to, int64(0), int64(migratePageSize-1), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: "a"}, {EntryID: "b"}}, nil)
+	repo.EXPECT().MigrateEntries(from, to, []string{"a", "b"}).Return(10, 1, nil)
+
+	lbSrv := NewLeaderboardsService(repo, scoreboard, cp)
+
+	v, err := lbSrv.MigrateLegacyEpochs(lbName, nil)
+	assert.NoError(t, err)
+	assert.Len(t, v, 1)
+	assert.Equal(t, legacy, v[0].LegacyEpoch)
+	assert.Equal(t, epoch, v[0].Epoch)
+	assert.Equal(t, []string{from}, v[0].Scoreboards)
+	assert.Equal(t, 10, v[0].Entries)
+	assert.Equal(t, 2, v[0].Conflicts)
+}
+
+func TestGetEpochInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	ce, err := domain.NewCronExpression(domain.ResetExpression{Type: domain.Hourly})
+	assert.NoError(t, err)
+
+	lbSrv := NewLeaderboardsService(mocks.NewMockRepository(ctrl), mocks.NewMockScoreboard(ctrl), defaultConfigProviderMock(ctrl, lbName))
+
+	v, err := lbSrv.GetEpochInfo(lbName, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), v.Epoch)
+	assert.Equal(t, ce.GetEpochStart(10), v.Start)
+	assert.Equal(t, v.Start.Add(time.Hour), v.End)
+	assert.True(t, v.NextReset.After(v.ServerTime))
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+)
+
+// SetScore replaces the stored score of an entry in an epoch and in its scoreboards, it is meant
+// for operators fixing standings so it does not apply the function nor check the lifecycle of
+// the leaderboard. The given metadata is merged into the stored one and the current epoch is
+// used when the epoch is not positive. Archived epochs keep the standings of their archive
+func (s *LeaderboardsService) SetScore(entryID string, name string, epoch int64, score string, meta domain.Metadata) (domain.ScoreUpdate, domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return domain.ScoreUpdate{}, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	if len(config.Components) > 0 || config.Function == domain.Decay {
+		return domain.ScoreUpdate{}, domain.EpochInfo{}, &domain.InvalidScoreError{Name: name, Err: fmt.Errorf("scores of leaderboards with components or decay can not be set")}
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return domain.ScoreUpdate{}, domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+
+	update := domain.ScoreUpdate{Done: true}
+	stored := domain.StoredEntry{}
+	if config.IsExact() {
+		exact, err := config.ParseScore(score)
+		if err != nil {
+			return domain.ScoreUpdate{}, domain.EpochInfo{}, &domain.InvalidScoreError{Name: name, Err: err}
+		}
+		update.Score, update.ExactScore, stored.Score = exact.Score, exact.Value, exact.Value
+	} else {
+		update.Score, err = strconv.ParseFloat(score, 64)
+		if err != nil {
+			return domain.ScoreUpdate{}, domain.EpochInfo{}, &domain.InvalidScoreError{Name: name, Err: fmt.Errorf("score must be a number: %v", score)}
+		}
+		stored.Score = strconv.FormatFloat(update.Score, 'f', -1, 64)
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	entries, err := s.repository.GetEntries(leaderboard, []string{entryID})
+	if err != nil {
+		return domain.ScoreUpdate{}, domain.EpochInfo{}, fmt.Errorf("failed to fetch entries: %v", err)
+	}
+	current, ok := entries[entryID]
+	stored.Counter = max(current.Counter, 1)
+	stored.Metadata = domain.Metadata{}
+	if ok {
+		for k, v := range current.Metadata {
+			stored.Metadata[k] = v
+		}
+	}
+	for k, v := range meta {
+		stored.Metadata[k] = v
+	}
+	for _, sb := range config.Scoreboards {
+		if stored.Metadata[sb.Field] == "" {
+			return domain.ScoreUpdate{}, domain.EpochInfo{}, &domain.InvalidScoreError{Name: name, Err: fmt.Errorf("metadata %s of a scoreboard is missing", sb.Field)}
+		}
+	}
+
+	err = s.repository.PutEntries(leaderboard, map[string]domain.StoredEntry{entryID: stored})
+	if err != nil {
+		return domain.ScoreUpdate{}, domain.EpochInfo{}, fmt.Errorf("failed to put entries: %v", err)
+	}
+	// the entry leaves the scoreboards of the metadata fields that changed
+	for _, sb := range config.Scoreboards {
+		if !ok || current.Metadata[sb.Field] == "" {
+			continue
+		}
+		from := sbNameFromType(name, epoch, sb, current.Metadata[sb.Field])
+		if from == sbNameFromType(name, epoch, sb, stored.Metadata[sb.Field]) {
+			continue
+		}
+		_, err = s.scoreboard.RemoveScore(entryID, from)
+		if err != nil {
+			return domain.ScoreUpdate{}, domain.EpochInfo{}, fmt.Errorf("failed to remove score from %v: %v", from, err)
+		}
+	}
+	err = s.addToScoreboards(entryID, name, epoch, config, stored.Metadata, update.Score, update.ExactScore)
+	if err != nil {
+		return domain.ScoreUpdate{}, domain.EpochInfo{}, err
+	}
+	update.Counter = stored.Counter
+	update.Metadata = stored.Metadata
+	return update, newEpochInfo(config.CronExpression, epoch), nil
+}
+
+// RemoveScore removes an entry from an epoch, deleting its record and its scores in the
+// scoreboards of its stored metadata, and returns the scoreboards it was removed from. The
+// current epoch is used when the epoch is not positive
+func (s *LeaderboardsService) RemoveScore(entryID string, name string, epoch int64) ([]string, domain.EpochInfo, error) {
+	config, err := s.GetConfig(name)
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch configs: %v", err)
+	}
+	if epoch <= 0 {
+		_, epoch, err = s.getLeaderboardNameWithEpoch(name, config)
+		if err != nil {
+			return nil, domain.EpochInfo{}, fmt.Errorf("failed to generate name from configs: %v", err)
+		}
+	}
+
+	leaderboard := getNameWithEpoch(name, epoch)
+	entries, err := s.repository.GetEntries(leaderboard, []string{entryID})
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to fetch entries: %v", err)
+	}
+	boards := []string{leaderboard}
+	for _, sb := range config.Scoreboards {
+		if v := entries[entryID].Metadata[sb.Field]; v != "" {
+			boards = append(boards, sbNameFromType(name, epoch, sb, v))
+		}
+	}
+
+	removed := []string{}
+	for _, board := range boards {
+		ok, err := s.scoreboard.RemoveScore(entryID, board)
+		if err != nil {
+			return nil, domain.EpochInfo{}, fmt.Errorf("failed to remove score from %v: %v", board, err)
+		}
+		if ok {
+			removed = append(removed, board)
+		}
+	}
+	_, err = s.repository.DeleteEntry(entryID, leaderboard)
+	if err != nil {
+		return nil, domain.EpochInfo{}, fmt.Errorf("failed to delete entry: %v", err)
+	}
+	return removed, newEpochInfo(config.CronExpression, epoch), nil
+}
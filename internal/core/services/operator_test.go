@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Max)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	meta := domain.Metadata{"country": "es", "league": "gold"}
+	repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).Return(map[string]domain.StoredEntry{
+		"p1": {Score: "50", Counter: 7, Metadata: domain.Metadata{"country": "pt", "league": "gold"}},
+	}, nil)
+	// the score replaces the stored one keeping the counter and the metadata
+	repo.EXPECT().PutEntries(leaderboard, map[string]domain.StoredEntry{"p1": {Score: "10", Counter: 7, Metadata: meta}}).Return(nil)
+	// the entry moves from the scoreboard of its old country, the league did not change
+	gomock.InOrder(
+		scoreboard.EXPECT().RemoveScore("p1", sbNameFromType(lbName, 3, config.Scoreboards[1], "pt")).Return(true, nil),
+		scoreboard.EXPECT().AddScore("p1", leaderboard, float64(10)).Return(nil),
+	)
+	scoreboard.EXPECT().AddScore("p1", sbNameFromType(lbName, 3, config.Scoreboards[0], "gold"), float64(10)).Return(nil)
+	scoreboard.EXPECT().AddScore("p1", sbNameFromType(lbName, 3, config.Scoreboards[1], "es"), float64(10)).Return(nil)
+
+	v, info, err := lbSrv.SetScore("p1", lbName, 3, "10", domain.Metadata{"country": "es"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Epoch)
+	assert.Equal(t, domain.ScoreUpdate{Score: 10, Done: true, Counter: 7, Metadata: meta}, v)
+
+	// metadata naming the same scoreboard does not move the entry
+	repo.EXPECT().GetEntries(leaderboard, []string{"p3"}).Return(map[string]domain.StoredEntry{
+		"p3": {Score: "50", Counter: 1, Metadata: domain.Metadata{"country": "pt", "league": "gold"}},
+	}, nil)
+	repo.EXPECT().PutEntries(leaderboard, gomock.Any()).Return(nil)
+	scoreboard.EXPECT().AddScore("p3", gomock.Any(), float64(20)).Return(nil).Times(3)
+	_, _, err = lbSrv.SetScore("p3", lbName, 3, "20", domain.Metadata{"country": "PT"})
+	assert.NoError(t, err)
+
+	// new entries need the metadata of the scoreboards
+	repo.EXPECT().GetEntries(leaderboard, []string{"p2"}).Return(map[string]domain.StoredEntry{}, nil)
+	_, _, err = lbSrv.SetScore("p2", lbName, 3, "10", domain.Metadata{"country": "es"})
+	var invalid *domain.InvalidScoreError
+	assert.True(t, errors.As(err, &invalid))
+
+	_, _, err = lbSrv.SetScore("p2", lbName, 3, "ten", nil)
+	assert.True(t, errors.As(err, &invalid))
+}
+
+func TestRemoveScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfigWithScoreboards(lbName, domain.Hourly, domain.Max)
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	lbSrv := NewLeaderboardsService(repo, scoreboard, configProvider)
+
+	leaderboard := getNameWithEpoch(lbName, 3)
+	country := sbNameFromType(lbName, 3, config.Scoreboards[1], "pt")
+	// scoreboards of metadata that is not stored are not touched
+	repo.EXPECT().GetEntries(leaderboard, []string{"p1"}).Return(map[string]domain.StoredEntry{
+		"p1": {Score: "50", Counter: 7, Metadata: domain.Metadata{"country": "pt"}},
+	}, nil)
+	scoreboard.EXPECT().RemoveScore("p1", leaderboard).Return(true, nil)
+	scoreboard.EXPECT().RemoveScore("p1", country).Return(false, nil)
+	repo.EXPECT().DeleteEntry("p1", leaderboard).Return(true, nil)
+
+	removed, info, err := lbSrv.RemoveScore("p1", lbName, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), info.Epoch)
+	assert.Equal(t, []string{leaderboard}, removed)
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports"
+)
+
+const (
+	// prizeGracePeriod is the time after the end of an epoch before its prizes are awarded, which
+	// lets the scores reported at the end of the epoch land
+	prizeGracePeriod = 1 * time.Minute
+	// prizeCatchUpEpochs is the number of closed epochs awarded when the prizes of past epochs
+	// were missed, older epochs are skipped
+	prizeCatchUpEpochs = 24
+)
+
+// PrizesService awards the prizes of closed epochs and delivers them to webhooks
+type PrizesService struct {
+	repository    ports.Repository
+	scoreboard    ports.Scoreboard
+	configuration ports.Provider[domain.LeaderboardsConfigMap]
+	notifier      ports.PrizeNotifier
+	webhook       *domain.WebhookConfig
+	events        ports.EventPublisher
+	log           ports.Logger
+}
+
+// NewPrizesService creates a new prizes service, the webhook is used by the leaderboards without
+// one and the publisher of the epoch events may be nil
+func NewPrizesService(
+	repo ports.Repository,
+	scoreboard ports.Scoreboard,
+	configProvider ports.ConfigProvider,
+	notifier ports.PrizeNotifier,
+	webhook *domain.WebhookConfig,
+	publisher ports.EventPublisher,
+	log ports.Logger,
+) *PrizesService {
+	return &PrizesService{
+		repository:    repo,
+		scoreboard:    scoreboard,
+		configuration: configProvider,
+		notifier:      notifier,
+		webhook:       webhook,
+		events:        publisher,
+		log:           log,
+	}
+}
+
+// Run awards and delivers prizes on every interval until the context is done
+func (s *PrizesService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			err := s.CloseEpochs(now)
+			if err != nil {
+				_ = s.log.Error("failed to award prizes: %v", err)
+			}
+			err = s.DeliverPending(now)
+			if err != nil {
+				_ = s.log.Error("failed to deliver prizes: %v", err)
+			}
+		}
+	}
+}
+
+// CloseEpochs creates the award batches of the closed epochs of the leaderboards with prizes and
+// a webhook or claimed prizes, the epochs closed since the last awarded one are caught up and
+// epochs are only awarded once. When events are published every leaderboard is closed to publish
+// its EpochClosed event once
+func (s *PrizesService) CloseEpochs(now time.Time) error {
+	configMap, err := s.configuration.Provide()
+	if err != nil {
+		return fmt.Errorf("failed to provide configuration: %v", err)
+	}
+	var errs []error
+	for name, config := range configMap {
+		if s.events == nil && !hasPrizeDestination(config, s.webhookFor(config)) {
+			continue
+		}
+		err = s.closeEpoch(name, config, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PrizesService) closeEpoch(name string, config domain.LeaderboardConfig, now time.Time) error {
+	last, closedAt, ok, err := closedEpoch(s.repository, name, config, now)
+	if err != nil || !ok {
+		return err
+	}
+	first := last
+	awarded, found, err := s.repository.GetClosedEpoch(name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch closed epoch: %v", err)
+	}
+	if found && awarded >= last {
+		return nil
+	}
+	if found && config.ResetExpression.Type != domain.Window {
+		first = max(awarded+1, last-prizeCatchUpEpochs+1)
+		if first > awarded+1 && s.log != nil {
+			_ = s.log.Error("skipping the prizes of epochs %d to %d of %v", awarded+1, first-1, name)
+		}
+	}
+	for epoch := first; epoch <= last; epoch++ {
+		at := closedAt
+		if epoch != last && !config.CronExpression.IsManual() {
+			at = config.CronExpression.GetEpochEnd(epoch)
+		}
+		err = s.awardEpoch(name, config, epoch, at, now)
+		if err != nil {
+			return fmt.Errorf("epoch %d: %v", epoch, err)
+		}
+		err = s.repository.SetClosedEpoch(name, epoch)
+		if err != nil {
+			return fmt.Errorf("failed to record closed epoch: %v", err)
+		}
+	}
+	return nil
+}
+
+// awardEpoch creates the award batch of a closed epoch unless it has one
+func (s *PrizesService) awardEpoch(name string, config domain.LeaderboardConfig, epoch int64, closedAt time.Time, now time.Time) error {
+	_, found, err := s.repository.GetPrizeDelivery(name, epoch)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prize delivery: %v", err)
+	}
+	if found {
+		// the epoch is not recorded as closed, so the server may have stopped before the event
+		// was stored, consumers dedupe it by its ID
+		publishEvents(s.events, s.log, epochClosedEvent(name, epoch, closedAt))
+		return nil
+	}
+
+	batch, err := s.awardBatch(name, config, epoch, closedAt)
+	if err != nil {
+		return err
+	}
+	delivery := domain.PrizeDelivery{Batch: batch, State: domain.DeliveryPending, NextAttempt: now, UpdatedAt: now}
+	switch {
+	case config.ClaimPrizes:
+		// the batch is only recorded once the prizes are stored, so a failure is retried
+		err = s.repository.CreateEntryPrizes(batch)
+		if err != nil {
+			return fmt.Errorf("failed to create entry prizes: %v", err)
+		}
+		delivery.State = domain.DeliveryClaimable
+	case !hasPrizeDestination(config, s.webhookFor(config)):
+		delivery.State = domain.DeliveryNone
+	case len(batch.Awards) == 0:
+		delivery.State = domain.DeliveryDelivered
+	}
+	created, err := s.repository.CreatePrizeDelivery(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to create prize delivery: %v", err)
+	}
+	if created {
+		publishEvents(s.events, s.log, epochClosedEvent(name, epoch, closedAt))
+	}
+	return nil
+}
+
+// hasPrizeDestination checks if the prizes of a leaderboard are delivered to a webhook or claimed
+func hasPrizeDestination(config domain.LeaderboardConfig, webhook *domain.WebhookConfig) bool {
+	return len(config.PrizeTable.Table) > 0 && (config.ClaimPrizes || webhook != nil)
+}
+
+// closedEpoch returns the last closed epoch of a leaderboard and when it closed, it returns
+// false when no epoch is closed
+func closedEpoch(repository ports.Repository, name string, config domain.LeaderboardConfig, now time.Time) (int64, time.Time, bool, error) {
+	reset := config.CronExpression
+	if reset.IsManual() {
+		epoch, err := repository.GetEpoch(strings.ToLower(name))
+		if err != nil {
+			return 0, time.Time{}, false, fmt.Errorf("failed to fetch current epoch: %v", err)
+		}
+		return epoch - 1, now, epoch > 1, nil
+	}
+
+	ref := now.Add(-prizeGracePeriod)
+	epoch := reset.GetEpochFromReferenceUnixTimestamp(ref.Unix())
+	end := reset.GetEpochEnd(epoch)
+	if !end.IsZero() && !ref.Before(end) {
+		// a window that ended
+		return epoch, end, true, nil
+	}
+	if config.ResetExpression.Type == domain.Window || epoch <= 1 {
+		return 0, time.Time{}, false, nil
+	}
+	return epoch - 1, reset.GetEpochEnd(epoch - 1), true, nil
+}
+
+// awardBatch returns the prizes of the entries ranked in an epoch
+func (s *PrizesService) awardBatch(name string, config domain.LeaderboardConfig, epoch int64, closedAt time.Time) (domain.AwardBatch, error) {
+	leaderboard := getNameWithEpoch(name, epoch)
+	batch := domain.AwardBatch{ID: leaderboard, Leaderboard: name, Epoch: epoch, ClosedAt: closedAt, Awards: []domain.PrizeAward{}}
+	maxRank := config.PrizeTable.MaxRank()
+	if maxRank == 0 {
+		return batch, nil
+	}
+
+	scores, err := s.scoreboard.GetTopN(leaderboard, int64(maxRank)-1, config.Order())
+	if err != nil {
+		return domain.AwardBatch{}, fmt.Errorf("failed to fetch scores: %v", err)
+	}
+	exact, err := exactScoresOf(s.repository, config, leaderboard, scores)
+	if err != nil {
+		return domain.AwardBatch{}, err
+	}
+	decay := decayAt(config, epoch, closedAt)
+	for _, score := range scores {
+		prize, ok := config.PrizeTable.PrizeFor(uint64(score.Rank))
+		if !ok {
+			continue
+		}
+		batch.Awards = append(batch.Awards, domain.PrizeAward{
+			EntryID:    score.EntryID,
+			Rank:       score.Rank,
+			Score:      decay(score.Score),
+			ExactScore: exact[score.EntryID],
+			Action:     prize.Action,
+			Epoch:      epoch,
+		})
+	}
+	return batch, nil
+}
+
+// DeliverPending posts the pending award batches whose next attempt is due, batches are claimed
+// before they are posted so concurrent instances do not deliver them twice
+func (s *PrizesService) DeliverPending(now time.Time) error {
+	deliveries, err := s.repository.GetPrizeDeliveries(domain.DeliveryPending)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pending prize deliveries: %v", err)
+	}
+	var errs []error
+	for _, d := range deliveries {
+		if d.NextAttempt.After(now) {
+			continue
+		}
+		err = s.deliver(d, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", d.Batch.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PrizesService) deliver(d domain.PrizeDelivery, now time.Time) error {
+	webhook := s.webhook
+	configMap, err := s.configuration.Provide()
+	if err != nil {
+		return fmt.Errorf("failed to provide configuration: %v", err)
+	}
+	if config, ok := configMap[d.Batch.Leaderboard]; ok {
+		webhook = s.webhookFor(config)
+	}
+	if webhook == nil {
+		return fmt.Errorf("no webhook configured")
+	}
+
+	attempts := d.Attempts
+	d.Attempts++
+	d.NextAttempt = now.Add(webhook.RetryAfter(d.Attempts))
+	d.UpdatedAt = now
+	claimed, err := s.repository.UpdatePrizeDelivery(d, attempts)
+	if err != nil {
+		return fmt.Errorf("failed to claim prize delivery: %v", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	err = s.notifier.Notify(*webhook, d.Batch)
+	switch {
+	case err == nil:
+		d.State = domain.DeliveryDelivered
+		d.LastError = ""
+	case d.Attempts >= webhook.Attempts():
+		d.State = domain.DeliveryDead
+		d.LastError = err.Error()
+	default:
+		d.LastError = err.Error()
+	}
+	_, err = s.repository.UpdatePrizeDelivery(d, d.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to update prize delivery: %v", err)
+	}
+	return nil
+}
+
+// GetDeadLetters returns the award batches that exhausted their delivery attempts
+func (s *PrizesService) GetDeadLetters() ([]domain.PrizeDelivery, error) {
+	deliveries, err := s.repository.GetPrizeDeliveries(domain.DeliveryDead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead prize deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver moves the award batch of an epoch back to the pending deliveries with its attempts
+// reset
+func (s *PrizesService) Redeliver(name string, epoch int64) (domain.PrizeDelivery, error) {
+	d, found, err := s.repository.GetPrizeDelivery(name, epoch)
+	if err != nil {
+		return domain.PrizeDelivery{}, fmt.Errorf("failed to fetch prize delivery: %v", err)
+	}
+	if !found {
+		return domain.PrizeDelivery{}, &domain.PrizeDeliveryNotFoundError{Name: name, Epoch: epoch}
+	}
+	attempts := d.Attempts
+	now := time.Now()
+	d.State = domain.DeliveryPending
+	d.Attempts = 0
+	d.NextAttempt = now
+	d.UpdatedAt = now
+	updated, err := s.repository.UpdatePrizeDelivery(d, attempts)
+	if err != nil {
+		return domain.PrizeDelivery{}, fmt.Errorf("failed to update prize delivery: %v", err)
+	}
+	if !updated {
+		return domain.PrizeDelivery{}, fmt.Errorf("prize delivery of %v changed concurrently", d.Batch.ID)
+	}
+	return d, nil
+}
+
+// GetUnclaimedPrizes returns the prizes of an entry across leaderboards and epochs that were not
+// claimed
+func (s *PrizesService) GetUnclaimedPrizes(entryID string) ([]domain.EntryPrize, error) {
+	prizes, err := s.repository.GetUnclaimedPrizes(entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unclaimed prizes: %v", err)
+	}
+	return prizes, nil
+}
+
+// ClaimPrize claims the prize of an entry in a leaderboard epoch. Claims are idempotent for the
+// same claim id, claiming a prize again with another or without a claim id fails
+func (s *PrizesService) ClaimPrize(entryID string, name string, epoch int64, claimID string) (domain.EntryPrize, error) {
+	claimed, err := s.repository.ClaimPrize(entryID, name, epoch, claimID, time.Now().UTC())
+	if err != nil {
+		return domain.EntryPrize{}, fmt.Errorf("failed to claim prize: %v", err)
+	}
+	prize, found, err := s.repository.GetEntryPrize(entryID, name, epoch)
+	if err != nil {
+		return domain.EntryPrize{}, fmt.Errorf("failed to fetch prize: %v", err)
+	}
+	if !found {
+		return domain.EntryPrize{}, &domain.PrizeNotFoundError{EntryID: entryID, Name: name, Epoch: epoch}
+	}
+	if !claimed && (claimID == "" || prize.ClaimID != claimID) {
+		return domain.EntryPrize{}, &domain.PrizeClaimedError{EntryID: entryID, Name: name, Epoch: epoch}
+	}
+	return prize, nil
+}
+
+// webhookFor returns the webhook of a leaderboard or the global one
+func (s *PrizesService) webhookFor(config domain.LeaderboardConfig) *domain.WebhookConfig {
+	if config.Webhook != nil {
+		return config.Webhook
+	}
+	return s.webhook
+}
+
+// epochClosedEvent returns the EpochClosed event of an epoch, its ID is derived from the epoch so
+// the event published again for the same epoch is deduped
+func epochClosedEvent(name string, epoch int64, closedAt time.Time) domain.Event {
+	return domain.Event{
+		ID:          fmt.Sprintf("%s:%s:%d", domain.EpochClosed, strings.ToLower(name), epoch),
+		Type:        domain.EpochClosed,
+		Leaderboard: name,
+		Epoch:       epoch,
+		At:          closedAt,
+	}
+}
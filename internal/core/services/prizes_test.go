@@ -0,0 +1,250 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/posilva/simpleboards/internal/adapters/output/events"
+	"github.com/posilva/simpleboards/internal/adapters/output/logging"
+	"github.com/posilva/simpleboards/internal/core/domain"
+	"github.com/posilva/simpleboards/internal/core/ports/mocks"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloseEpochs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 2, "gems:100")
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	webhook := &domain.WebhookConfig{URL: "http://localhost/prizes"}
+	prizes := NewPrizesService(repo, scoreboard, configProvider, mocks.NewMockPrizeNotifier(ctrl), webhook, nil, logging.NewSimpleLogger())
+
+	now := time.Now()
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(now.Add(-prizeGracePeriod).Unix()) - 1
+	repo.EXPECT().GetClosedEpoch(lbName).Return(int64(0), false, nil)
+	repo.EXPECT().GetPrizeDelivery(lbName, epoch).Return(domain.PrizeDelivery{}, false, nil)
+	scoreboard.EXPECT().GetTopN(getNameWithEpoch(lbName, epoch), int64(1), domain.Descending).Return([]domain.ScoreboardResult{
+		{EntryID: "p1", Score: 30, Rank: 1},
+		{EntryID: "p2", Score: 20, Rank: 2},
+	}, nil)
+	repo.EXPECT().CreatePrizeDelivery(gomock.Any()).DoAndReturn(func(d domain.PrizeDelivery) (bool, error) {
+		assert.Equal(t, domain.DeliveryPending, d.State)
+		assert.Equal(t, getNameWithEpoch(lbName, epoch), d.Batch.ID)
+		assert.Equal(t, []domain.PrizeAward{
+			{EntryID: "p1", Rank: 1, Score: 30, Action: "gems:100", Epoch: epoch},
+			{EntryID: "p2", Rank: 2, Score: 20, Action: "gems:100", Epoch: epoch},
+		}, d.Batch.Awards)
+		return true, nil
+	})
+	repo.EXPECT().SetClosedEpoch(lbName, epoch).Return(nil)
+	assert.NoError(t, prizes.CloseEpochs(now))
+
+	// awarded epochs are skipped
+	repo.EXPECT().GetClosedEpoch(lbName).Return(epoch, true, nil)
+	assert.NoError(t, prizes.CloseEpochs(now))
+	repo.EXPECT().GetClosedEpoch(lbName).Return(int64(0), false, nil)
+	repo.EXPECT().GetPrizeDelivery(lbName, epoch).Return(domain.PrizeDelivery{}, true, nil)
+	repo.EXPECT().SetClosedEpoch(lbName, epoch).Return(nil)
+	assert.NoError(t, prizes.CloseEpochs(now))
+
+	// the epochs closed since the last awarded one are caught up
+	gomock.InOrder(
+		repo.EXPECT().GetClosedEpoch(lbName).Return(epoch-2, true, nil),
+		repo.EXPECT().GetPrizeDelivery(lbName, epoch-1).Return(domain.PrizeDelivery{}, false, nil),
+		scoreboard.EXPECT().GetTopN(getNameWithEpoch(lbName, epoch-1), int64(1), domain.Descending).Return([]domain.ScoreboardResult{}, nil),
+		repo.EXPECT().CreatePrizeDelivery(gomock.Any()).DoAndReturn(func(d domain.PrizeDelivery) (bool, error) {
+			assert.Equal(t, epoch-1, d.Batch.Epoch)
+			assert.Equal(t, config.CronExpression.GetEpochEnd(epoch-1), d.Batch.ClosedAt)
+			return true, nil
+		}),
+		repo.EXPECT().SetClosedEpoch(lbName, epoch-1).Return(nil),
+		repo.EXPECT().GetPrizeDelivery(lbName, epoch).Return(domain.PrizeDelivery{}, true, nil),
+		repo.EXPECT().SetClosedEpoch(lbName, epoch).Return(nil),
+	)
+	assert.NoError(t, prizes.CloseEpochs(now))
+
+	// only the last epochs are caught up after a long outage
+	repo.EXPECT().GetClosedEpoch(lbName).Return(epoch-100, true, nil)
+	repo.EXPECT().GetPrizeDelivery(lbName, gomock.Any()).DoAndReturn(func(_ string, e int64) (domain.PrizeDelivery, bool, error) {
+		assert.Greater(t, e, epoch-prizeCatchUpEpochs)
+		return domain.PrizeDelivery{}, true, nil
+	}).Times(prizeCatchUpEpochs)
+	repo.EXPECT().SetClosedEpoch(lbName, gomock.Any()).Return(nil).Times(prizeCatchUpEpochs)
+	assert.NoError(t, prizes.CloseEpochs(now))
+}
+
+func TestCloseEpochsPublishesEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "gems:100")
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	publisher := events.NewMemoryPublisher()
+	prizes := NewPrizesService(repo, scoreboard, configProvider, mocks.NewMockPrizeNotifier(ctrl), nil, publisher, logging.NewSimpleLogger())
+
+	// leaderboards without a prize destination are closed once to publish the event
+	now := time.Now()
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(now.Add(-prizeGracePeriod).Unix()) - 1
+	repo.EXPECT().GetClosedEpoch(lbName).Return(int64(0), false, nil)
+	repo.EXPECT().GetPrizeDelivery(lbName, epoch).Return(domain.PrizeDelivery{}, false, nil)
+	scoreboard.EXPECT().GetTopN(gomock.Any(), int64(0), domain.Descending).Return([]domain.ScoreboardResult{}, nil)
+	repo.EXPECT().CreatePrizeDelivery(gomock.Any()).DoAndReturn(func(d domain.PrizeDelivery) (bool, error) {
+		assert.Equal(t, domain.DeliveryNone, d.State)
+		return true, nil
+	})
+	repo.EXPECT().SetClosedEpoch(lbName, epoch).Return(nil)
+	assert.NoError(t, prizes.CloseEpochs(now))
+
+	published := publisher.Events()
+	assert.Len(t, published, 1)
+	assert.Equal(t, domain.EpochClosed, published[0].Type)
+	assert.Equal(t, lbName, published[0].Leaderboard)
+	assert.Equal(t, epoch, published[0].Epoch)
+}
+
+func TestDeliverPendingRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	notifier := mocks.NewMockPrizeNotifier(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "gems:100")
+	config.Webhook = &domain.WebhookConfig{URL: "http://localhost/prizes", MaxAttempts: 2, Backoff: "1m"}
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	prizes := NewPrizesService(repo, mocks.NewMockScoreboard(ctrl), configProvider, notifier, nil, nil, logging.NewSimpleLogger())
+
+	now := time.Now()
+	pending := domain.PrizeDelivery{
+		Batch:       domain.AwardBatch{ID: lbName + "::1", Leaderboard: lbName, Epoch: 1, Awards: []domain.PrizeAward{{EntryID: "p1", Rank: 1}}},
+		State:       domain.DeliveryPending,
+		NextAttempt: now,
+	}
+	later := pending
+	later.NextAttempt = now.Add(time.Hour)
+
+	// the first attempt fails and is retried after the backoff
+	repo.EXPECT().GetPrizeDeliveries(domain.DeliveryPending).Return([]domain.PrizeDelivery{pending, later}, nil)
+	repo.EXPECT().UpdatePrizeDelivery(gomock.Any(), 0).Return(true, nil)
+	notifier.EXPECT().Notify(*config.Webhook, pending.Batch).Return(fmt.Errorf("unavailable"))
+	repo.EXPECT().UpdatePrizeDelivery(gomock.Any(), 1).DoAndReturn(func(d domain.PrizeDelivery, _ int) (bool, error) {
+		assert.Equal(t, domain.DeliveryPending, d.State)
+		assert.Equal(t, now.Add(time.Minute), d.NextAttempt)
+		assert.Equal(t, "unavailable", d.LastError)
+		return true, nil
+	})
+	assert.NoError(t, prizes.DeliverPending(now))
+
+	// the last attempt moves the batch to the dead-letter list
+	pending.Attempts = 1
+	repo.EXPECT().GetPrizeDeliveries(domain.DeliveryPending).Return([]domain.PrizeDelivery{pending}, nil)
+	repo.EXPECT().UpdatePrizeDelivery(gomock.Any(), 1).Return(true, nil)
+	notifier.EXPECT().Notify(*config.Webhook, pending.Batch).Return(fmt.Errorf("unavailable"))
+	repo.EXPECT().UpdatePrizeDelivery(gomock.Any(), 2).DoAndReturn(func(d domain.PrizeDelivery, _ int) (bool, error) {
+		assert.Equal(t, domain.DeliveryDead, d.State)
+		return true, nil
+	})
+	assert.NoError(t, prizes.DeliverPending(now))
+
+	// batches claimed by another instance are not posted
+	pending.Attempts = 0
+	repo.EXPECT().GetPrizeDeliveries(domain.DeliveryPending).Return([]domain.PrizeDelivery{pending}, nil)
+	repo.EXPECT().UpdatePrizeDelivery(gomock.Any(), 0).Return(false, nil)
+	assert.NoError(t, prizes.DeliverPending(now))
+}
+
+func TestRedeliverNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockRepository(ctrl)
+	prizes := NewPrizesService(repo, mocks.NewMockScoreboard(ctrl), mocks.NewMockConfigProvider(ctrl), mocks.NewMockPrizeNotifier(ctrl), nil, nil, logging.NewSimpleLogger())
+
+	repo.EXPECT().GetPrizeDelivery("weekly", int64(3)).Return(domain.PrizeDelivery{}, false, nil)
+	_, err := prizes.Redeliver("weekly", 3)
+	var notFound *domain.PrizeDeliveryNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestCloseEpochsClaimPrizes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lbName := testutil.NewUnique(testutil.Name(t))
+	repo := mocks.NewMockRepository(ctrl)
+	scoreboard := mocks.NewMockScoreboard(ctrl)
+	config := testutil.NewLeaderboardConfig(lbName, 1, 1, "gems:100")
+	config.ClaimPrizes = true
+	configProvider := mocks.NewMockConfigProvider(ctrl)
+	configProvider.EXPECT().Provide().Return(domain.LeaderboardsConfigMap{lbName: config}, nil).AnyTimes()
+	prizes := NewPrizesService(repo, scoreboard, configProvider, mocks.NewMockPrizeNotifier(ctrl), nil, nil, logging.NewSimpleLogger())
+
+	now := time.Now()
+	epoch := config.CronExpression.GetEpochFromReferenceUnixTimestamp(now.Add(-prizeGracePeriod).Unix()) - 1
+	repo.EXPECT().GetClosedEpoch(lbName).Return(int64(0), false, nil)
+	repo.EXPECT().GetPrizeDelivery(lbName, epoch).Return(domain.PrizeDelivery{}, false, nil)
+	scoreboard.EXPECT().GetTopN(gomock.Any(), int64(0), domain.Descending).Return([]domain.ScoreboardResult{{EntryID: "p1", Score: 30, Rank: 1}}, nil)
+	repo.EXPECT().CreateEntryPrizes(gomock.Any()).DoAndReturn(func(batch domain.AwardBatch) error {
+		assert.Equal(t, []domain.PrizeAward{{EntryID: "p1", Rank: 1, Score: 30, Action: "gems:100", Epoch: epoch}}, batch.Awards)
+		return nil
+	})
+	repo.EXPECT().CreatePrizeDelivery(gomock.Any()).DoAndReturn(func(d domain.PrizeDelivery) (bool, error) {
+		assert.Equal(t, domain.DeliveryClaimable, d.State)
+		return true, nil
+	})
+	repo.EXPECT().SetClosedEpoch(lbName, epoch).Return(nil)
+	assert.NoError(t, prizes.CloseEpochs(now))
+}
+
+func TestClaimPrize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockRepository(ctrl)
+	prizes := NewPrizesService(repo, mocks.NewMockScoreboard(ctrl), mocks.NewMockConfigProvider(ctrl), mocks.NewMockPrizeNotifier(ctrl), nil, nil, logging.NewSimpleLogger())
+
+	claimedAt := time.Now()
+	prize := domain.EntryPrize{
+		PrizeAward:  domain.PrizeAward{EntryID: "p1", Rank: 1, Action: "gems:100", Epoch: 3},
+		Leaderboard: "weekly",
+		ClaimedAt:   &claimedAt,
+		ClaimID:     "key-1",
+	}
+
+	repo.EXPECT().ClaimPrize("p1", "weekly", int64(3), "key-1", gomock.Any()).Return(true, nil)
+	repo.EXPECT().GetEntryPrize("p1", "weekly", int64(3)).Return(prize, true, nil)
+	v, err := prizes.ClaimPrize("p1", "weekly", 3, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, prize, v)
+
+	// retries with the same claim id return the claimed prize
+	repo.EXPECT().ClaimPrize("p1", "weekly", int64(3), "key-1", gomock.Any()).Return(false, nil)
+	repo.EXPECT().GetEntryPrize("p1", "weekly", int64(3)).Return(prize, true, nil)
+	_, err = prizes.ClaimPrize("p1", "weekly", 3, "key-1")
+	assert.NoError(t, err)
+
+	repo.EXPECT().ClaimPrize("p1", "weekly", int64(3), "key-2", gomock.Any()).Return(false, nil)
+	repo.EXPECT().GetEntryPrize("p1", "weekly", int64(3)).Return(prize, true, nil)
+	_, err = prizes.ClaimPrize("p1", "weekly", 3, "key-2")
+	var claimed *domain.PrizeClaimedError
+	assert.ErrorAs(t, err, &claimed)
+
+	repo.EXPECT().ClaimPrize("p1", "daily", int64(3), "", gomock.Any()).Return(false, nil)
+	repo.EXPECT().GetEntryPrize("p1", "daily", int64(3)).Return(domain.EntryPrize{}, false, nil)
+	_, err = prizes.ClaimPrize("p1", "daily", 3, "")
+	var notFound *domain.PrizeNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
@@ -9,6 +9,9 @@ module "dynamodb_table" {
   hash_key     = "pk"
   range_key    = "sk"
   billing_mode = var.dynamodb_billing_mode
+  # keep in sync with the schema migrations of simpleboards db migrate
+  ttl_enabled   = true
+  ttl_attribute = "Expires"
 
   dynamodb_attributes = [
     {
@@ -20,6 +23,18 @@ module "dynamodb_table" {
       type = "S"
     }
   ]
+
+  global_secondary_index_map = [
+    {
+      name               = "sk-pk-index"
+      hash_key           = "sk"
+      range_key          = "pk"
+      projection_type    = "KEYS_ONLY"
+      non_key_attributes = []
+      read_capacity      = null
+      write_capacity     = null
+    }
+  ]
 }
 
 data "aws_iam_policy_document" "dynamodb-full-access" {
@@ -20,7 +20,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/posilva/simpleboards/internal/testutil"
 
 	"github.com/redis/rueidis"
@@ -181,32 +180,8 @@ func setupDDBContainer(suite *BaseTestSuite) {
 }
 
 func createTable(suite *BaseTestSuite) {
-	_, err := suite.DDBClient.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
-		AttributeDefinitions: []types.AttributeDefinition{{
-			AttributeName: aws.String("pk"),
-			AttributeType: types.ScalarAttributeTypeS,
-		}, {
-			AttributeName: aws.String("sk"),
-			AttributeType: types.ScalarAttributeTypeS,
-		}},
-		KeySchema: []types.KeySchemaElement{{
-			AttributeName: aws.String("pk"),
-			KeyType:       types.KeyTypeHash,
-		}, {
-			AttributeName: aws.String("sk"),
-			KeyType:       types.KeyTypeRange,
-		}},
-		TableName: aws.String(testutil.DynamoDBLocalTableName),
-		ProvisionedThroughput: &types.ProvisionedThroughput{
-			ReadCapacityUnits:  aws.Int64(10),
-			WriteCapacityUnits: aws.Int64(10),
-		},
-	})
-	suite.NoError(err)
-	waiter := dynamodb.NewTableExistsWaiter(suite.DDBClient)
-	err = waiter.Wait(context.TODO(), &dynamodb.DescribeTableInput{
-		TableName: aws.String(testutil.DynamoDBLocalTableName),
-	}, 10*time.Second)
+	_, err := repository.NewSchemaManager(suite.DDBClient, testutil.DynamoDBLocalTableName).
+		WithPollInterval(100 * time.Millisecond).Init()
 	suite.NoError(err)
 }
 
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/carlmjohnson/requests"
+	"github.com/posilva/simpleboards/internal/adapters/output/repository"
 	"github.com/posilva/simpleboards/internal/core/domain"
 	"github.com/posilva/simpleboards/internal/testutil"
 	"github.com/stretchr/testify/suite"
@@ -269,6 +270,23 @@ func (suite *E2ETestSuite) TestMultipleSumEntry() {
 	suite.Equal(list.Scores[0].Scores[1].Rank, 2)
 }
 
+func (suite *E2ETestSuite) TestSchemaInitIsIdempotent() {
+	manager := repository.NewSchemaManager(suite.DDBClient, testutil.DynamoDBLocalTableName)
+	applied, err := manager.Init()
+	suite.NoError(err)
+	suite.Empty(applied)
+
+	applied, err = manager.Migrate()
+	suite.NoError(err)
+	suite.Empty(applied)
+
+	status, err := manager.Status()
+	suite.NoError(err)
+	suite.True(status.Exists)
+	suite.Equal(repository.LatestSchemaVersion(), status.Version)
+	suite.Empty(status.Pending)
+}
+
 func TestE2E(t *testing.T) {
 	suite.Run(t, new(E2ETestSuite))
 }
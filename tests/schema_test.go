@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/posilva/simpleboards/internal/adapters/output/repository"
+	"github.com/posilva/simpleboards/internal/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+type SchemaTestSuite struct {
+	BaseTestSuite
+}
+
+func (suite *SchemaTestSuite) SetupSuite() {
+	suite.Context = context.Background()
+	testcontainers.Logger = log.New(&ioutils.NopWriter{}, "", 0)
+	setupDDBContainer(&suite.BaseTestSuite)
+}
+
+func (suite *SchemaTestSuite) TearDownSuite() {
+	suite.NoError(suite.DDBContainer.Terminate(suite.Context))
+}
+
+func (suite *SchemaTestSuite) manager(table string) *repository.SchemaManager {
+	return repository.NewSchemaManager(suite.DDBClient, table).WithPollInterval(100 * time.Millisecond)
+}
+
+// assertLatest checks the table has the index, the time to live and the version of the latest
+// layout
+func (suite *SchemaTestSuite) assertLatest(table string) {
+	output, err := suite.DDBClient.DescribeTable(suite.Context, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	suite.NoError(err)
+	suite.Len(output.Table.GlobalSecondaryIndexes, 1)
+	suite.Equal(repository.InvertedIndexName, aws.ToString(output.Table.GlobalSecondaryIndexes[0].IndexName))
+
+	ttl, err := suite.DDBClient.DescribeTimeToLive(suite.Context, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(table)})
+	suite.NoError(err)
+	suite.Equal(repository.TTLAttribute, aws.ToString(ttl.TimeToLiveDescription.AttributeName))
+
+	status, err := suite.manager(table).Status()
+	suite.NoError(err)
+	suite.Equal(repository.LatestSchemaVersion(), status.Version)
+	suite.Empty(status.Pending)
+}
+
+func (suite *SchemaTestSuite) TestInitIsIdempotent() {
+	table := testutil.NewUnique(testutil.DynamoDBLocalTableName)
+
+	applied, err := suite.manager(table).Init()
+	suite.NoError(err)
+	suite.Len(applied, repository.LatestSchemaVersion())
+	suite.assertLatest(table)
+
+	applied, err = suite.manager(table).Init()
+	suite.NoError(err)
+	suite.Empty(applied)
+	applied, err = suite.manager(table).Migrate()
+	suite.NoError(err)
+	suite.Empty(applied)
+	suite.assertLatest(table)
+}
+
+func (suite *SchemaTestSuite) TestMigrateExistingTable() {
+	// a table created before the versions were recorded only has its keys
+	table := testutil.NewUnique(testutil.DynamoDBLocalTableName)
+	_, err := suite.DDBClient.CreateTable(suite.Context, &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	suite.NoError(err)
+
+	applied, err := suite.manager(table).Migrate()
+	suite.NoError(err)
+	suite.Len(applied, repository.LatestSchemaVersion())
+	suite.assertLatest(table)
+
+	applied, err = suite.manager(table).Migrate()
+	suite.NoError(err)
+	suite.Empty(applied)
+	suite.assertLatest(table)
+}
+
+func TestSchema(t *testing.T) {
+	suite.Run(t, new(SchemaTestSuite))
+}